@@ -0,0 +1,229 @@
+// Package cache provides a response cache middleware for llm.Provider, so identical
+// requests are answered from a local store instead of calling the provider again.
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ulgerang/llm-module/llm"
+	"github.com/ulgerang/llm-module/logger"
+)
+
+// keyVersion is part of every key so that changes to the key format invalidate old entries.
+const keyVersion = 1
+
+const defaultReplayChunkSize = 64
+
+// responseCache holds the configuration of a cache middleware.
+type responseCache struct {
+	store           Store
+	ttl             time.Duration
+	replayChunkSize int
+	logger          logger.Logger
+	now             func() time.Time
+}
+
+// Option configures a response cache.
+type Option func(*responseCache)
+
+// WithTTL expires entries after ttl. Zero keeps entries until the store evicts them.
+func WithTTL(ttl time.Duration) Option {
+	return func(c *responseCache) {
+		c.ttl = ttl
+	}
+}
+
+// WithReplayChunkSize sets how many characters each replayed stream chunk carries.
+func WithReplayChunkSize(size int) Option {
+	return func(c *responseCache) {
+		if size > 0 {
+			c.replayChunkSize = size
+		}
+	}
+}
+
+// WithLogger reports store failures, which otherwise only degrade the cache to a miss.
+func WithLogger(log logger.Logger) Option {
+	return func(c *responseCache) {
+		c.logger = log
+	}
+}
+
+func newResponseCache(store Store, opts ...Option) *responseCache {
+	c := &responseCache{
+		store:           store,
+		replayChunkSize: defaultReplayChunkSize,
+		now:             time.Now,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Middleware returns an llm.Middleware that answers repeated requests from store.
+// Streaming hits are replayed as chunks. Errors are never cached.
+func Middleware(store Store, opts ...Option) llm.Middleware {
+	return newResponseCache(store, opts...).middleware
+}
+
+// New wraps provider with a response cache backed by store.
+func New(provider llm.Provider, store Store, opts ...Option) llm.Provider {
+	return llm.Wrap(provider, Middleware(store, opts...))
+}
+
+type contextKey struct{}
+
+type mode int
+
+const (
+	modeDefault mode = iota
+	modeBypass
+	modeRefresh
+)
+
+// WithBypass returns a context whose requests neither read nor write the cache.
+func WithBypass(ctx context.Context) context.Context {
+	return context.WithValue(ctx, contextKey{}, modeBypass)
+}
+
+// WithRefresh returns a context whose requests skip cached entries but store the new response.
+func WithRefresh(ctx context.Context) context.Context {
+	return context.WithValue(ctx, contextKey{}, modeRefresh)
+}
+
+func modeFrom(ctx context.Context) mode {
+	if m, ok := ctx.Value(contextKey{}).(mode); ok {
+		return m
+	}
+	return modeDefault
+}
+
+// Key returns the cache key of a request: a SHA-256 digest of the model, the request kind
+// (text or stream), the prompt and every generation option, including messages, schema and tools.
+func Key(model, kind, prompt string, options *llm.GenerationOptions) (string, error) {
+	if options.Model != nil && *options.Model != "" {
+		model = *options.Model
+	}
+	payload, err := json.Marshal(struct {
+		Version int                    `json:"v"`
+		Model   string                 `json:"model"`
+		Kind    string                 `json:"kind"`
+		Prompt  string                 `json:"prompt"`
+		Options *llm.GenerationOptions `json:"options"`
+	}{keyVersion, model, kind, prompt, options})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode cache key: %w", err)
+	}
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func (c *responseCache) middleware(next llm.Handler) llm.Handler {
+	return func(ctx context.Context, req *llm.Request, emit llm.EmitFunc) (*llm.Response, error) {
+		m := modeFrom(ctx)
+		if m == modeBypass {
+			return next(ctx, req, emit)
+		}
+
+		kind := "text"
+		if req.Stream {
+			kind = "stream"
+		}
+		key, err := Key(req.Model, kind, req.Prompt, req.Options)
+		if err != nil {
+			return nil, err
+		}
+		if m != modeRefresh {
+			if entry := c.lookup(ctx, key); entry != nil {
+				resp := &llm.Response{Text: entry.Text, ToolCalls: entry.ToolCalls, Usage: &llm.UsageInfo{ResponseCacheHit: true}}
+				if req.Stream {
+					return resp, Replay(entry, c.replayChunkSize, emit)
+				}
+				return resp, nil
+			}
+		}
+
+		resp, err := next(ctx, req, emit)
+		if err != nil || resp == nil || ctx.Err() != nil {
+			return resp, err
+		}
+		c.save(ctx, key, &Entry{Text: resp.Text, ToolCalls: resp.ToolCalls, Usage: usageValue(resp.Usage)})
+		return resp, nil
+	}
+}
+
+// lookup returns a live entry for key, deleting it if it has expired.
+func (c *responseCache) lookup(ctx context.Context, key string) *Entry {
+	entry, ok, err := c.store.Get(ctx, key)
+	if err != nil {
+		c.logError("[Cache] Failed to read entry", err)
+		return nil
+	}
+	if !ok {
+		return nil
+	}
+	if entry.Expired(c.now()) {
+		if err := c.store.Delete(ctx, key); err != nil {
+			c.logError("[Cache] Failed to delete expired entry", err)
+		}
+		return nil
+	}
+	return entry
+}
+
+func (c *responseCache) save(ctx context.Context, key string, entry *Entry) {
+	entry.CreatedAt = c.now()
+	if c.ttl > 0 {
+		entry.ExpiresAt = entry.CreatedAt.Add(c.ttl)
+	}
+	if err := c.store.Set(ctx, key, entry); err != nil {
+		c.logError("[Cache] Failed to store entry", err)
+	}
+}
+
+// Replay emits a cached response as text chunks of chunkSize characters, followed by
+// its tool calls and a final chunk.
+func Replay(entry *Entry, chunkSize int, emit llm.EmitFunc) error {
+	if chunkSize <= 0 {
+		chunkSize = defaultReplayChunkSize
+	}
+
+	text := entry.Text
+	for text != "" {
+		end := 0
+		for i := 0; i < chunkSize && end < len(text); i++ {
+			_, size := utf8.DecodeRuneInString(text[end:])
+			end += size
+		}
+		if err := emit(llm.StreamChunk{Delta: text[:end]}); err != nil {
+			return err
+		}
+		text = text[end:]
+	}
+	if len(entry.ToolCalls) > 0 {
+		if err := emit(llm.StreamChunk{ToolCalls: entry.ToolCalls}); err != nil {
+			return err
+		}
+	}
+	return emit(llm.StreamChunk{IsFinal: true})
+}
+
+func (c *responseCache) logError(message string, err error) {
+	if c.logger != nil {
+		c.logger.Error(message, err)
+	}
+}
+
+func usageValue(usage *llm.UsageInfo) llm.UsageInfo {
+	if usage == nil {
+		return llm.UsageInfo{}
+	}
+	return *usage
+}
@@ -0,0 +1,273 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ulgerang/llm-module/llm"
+)
+
+type countingProvider struct {
+	calls     int
+	text      string
+	toolCalls []llm.ToolCall
+	err       error
+}
+
+func (p *countingProvider) GenerateText(_ context.Context, prompt string, _ ...llm.GenerationOption) (string, *llm.UsageInfo, error) {
+	p.calls++
+	if p.err != nil {
+		return "", nil, p.err
+	}
+	return p.text + prompt, &llm.UsageInfo{InputTokens: 10, OutputTokens: 5}, nil
+}
+
+func (p *countingProvider) GenerateTextStream(_ context.Context, prompt string, outChan chan<- llm.StreamChunk, _ ...llm.GenerationOption) (*llm.UsageInfo, error) {
+	defer close(outChan)
+	p.calls++
+	if p.err != nil {
+		outChan <- llm.StreamChunk{Err: p.err}
+		return nil, p.err
+	}
+	for _, word := range strings.SplitAfter(p.text+prompt, " ") {
+		outChan <- llm.StreamChunk{Delta: word}
+	}
+	if len(p.toolCalls) > 0 {
+		outChan <- llm.StreamChunk{ToolCalls: p.toolCalls}
+	}
+	outChan <- llm.StreamChunk{IsFinal: true}
+	return &llm.UsageInfo{InputTokens: 10, OutputTokens: 5}, nil
+}
+
+func (p *countingProvider) GetModelName() string { return "test-model" }
+func (p *countingProvider) Close() error         { return nil }
+
+func collect(t *testing.T, provider llm.Provider, ctx context.Context, prompt string, opts ...llm.GenerationOption) (string, []llm.ToolCall, *llm.UsageInfo, int) {
+	t.Helper()
+	out := make(chan llm.StreamChunk)
+	type result struct {
+		usage *llm.UsageInfo
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		usage, err := provider.GenerateTextStream(ctx, prompt, out, opts...)
+		done <- result{usage, err}
+	}()
+
+	var text strings.Builder
+	var toolCalls []llm.ToolCall
+	chunks := 0
+	for chunk := range out {
+		chunks++
+		text.WriteString(chunk.Delta)
+		toolCalls = append(toolCalls, chunk.ToolCalls...)
+	}
+	res := <-done
+	if res.err != nil {
+		t.Fatalf("stream failed: %v", res.err)
+	}
+	return text.String(), toolCalls, res.usage, chunks
+}
+
+func TestGenerateTextCachesResponses(t *testing.T) {
+	inner := &countingProvider{text: "answer to "}
+	provider := New(inner, NewMemoryStore(10))
+	ctx := context.Background()
+
+	text, usage, err := provider.GenerateText(ctx, "question", llm.WithTemperature(0.2))
+	if err != nil {
+		t.Fatalf("GenerateText failed: %v", err)
+	}
+	if usage.ResponseCacheHit || usage.InputTokens != 10 {
+		t.Fatalf("first call should report provider usage, got %+v", usage)
+	}
+
+	cached, usage, err := provider.GenerateText(ctx, "question", llm.WithTemperature(0.2))
+	if err != nil {
+		t.Fatalf("GenerateText failed: %v", err)
+	}
+	if cached != text {
+		t.Errorf("cached text = %q, want %q", cached, text)
+	}
+	if !usage.ResponseCacheHit || usage.InputTokens != 0 || usage.OutputTokens != 0 {
+		t.Errorf("cache hit should report zero usage, got %+v", usage)
+	}
+	if inner.calls != 1 {
+		t.Errorf("provider called %d times, want 1", inner.calls)
+	}
+
+	if _, _, err := provider.GenerateText(ctx, "question", llm.WithTemperature(0.3)); err != nil {
+		t.Fatalf("GenerateText failed: %v", err)
+	}
+	if inner.calls != 2 {
+		t.Errorf("different options should miss the cache, provider called %d times", inner.calls)
+	}
+}
+
+func TestKeyCoversOptions(t *testing.T) {
+	base := llm.ResolveOptions(llm.WithSystem("be brief"))
+	withTools := llm.ResolveOptions(llm.WithSystem("be brief"), llm.WithTools([]*llm.Tool{{Name: "lookup"}}))
+	withModel := llm.ResolveOptions(llm.WithSystem("be brief"), llm.WithModel("other-model"))
+
+	keys := map[string]string{}
+	for name, options := range map[string]*llm.GenerationOptions{"base": base, "tools": withTools, "model": withModel} {
+		key, err := Key("test-model", "text", "prompt", options)
+		if err != nil {
+			t.Fatalf("Key failed: %v", err)
+		}
+		if other, ok := keys[key]; ok {
+			t.Errorf("%s and %s share a key", name, other)
+		}
+		keys[key] = name
+	}
+
+	again, _ := Key("test-model", "text", "prompt", llm.ResolveOptions(llm.WithSystem("be brief")))
+	if keys[again] != "base" {
+		t.Error("identical requests should produce the same key")
+	}
+}
+
+func TestErrorsAreNotCached(t *testing.T) {
+	inner := &countingProvider{err: errors.New("boom")}
+	provider := New(inner, NewMemoryStore(10))
+
+	for i := 0; i < 2; i++ {
+		if _, _, err := provider.GenerateText(context.Background(), "question"); err == nil {
+			t.Fatal("expected error")
+		}
+	}
+	if inner.calls != 2 {
+		t.Errorf("provider called %d times, want 2", inner.calls)
+	}
+}
+
+func TestTTLExpiresEntries(t *testing.T) {
+	inner := &countingProvider{text: "answer"}
+	store := NewMemoryStore(10)
+	c := newResponseCache(store, WithTTL(time.Minute))
+	now := time.Now()
+	c.now = func() time.Time { return now }
+	provider := llm.Wrap(inner, c.middleware)
+
+	provider.GenerateText(context.Background(), "question")
+	provider.GenerateText(context.Background(), "question")
+	if inner.calls != 1 {
+		t.Fatalf("provider called %d times before expiry, want 1", inner.calls)
+	}
+
+	now = now.Add(2 * time.Minute)
+	provider.GenerateText(context.Background(), "question")
+	if inner.calls != 2 {
+		t.Errorf("provider called %d times after expiry, want 2", inner.calls)
+	}
+}
+
+func TestBypassAndRefresh(t *testing.T) {
+	inner := &countingProvider{text: "answer"}
+	store := NewMemoryStore(10)
+	provider := New(inner, store)
+	ctx := context.Background()
+
+	provider.GenerateText(WithBypass(ctx), "question")
+	if store.Len() != 0 {
+		t.Fatal("bypass should not write the cache")
+	}
+
+	provider.GenerateText(ctx, "question")
+	provider.GenerateText(WithRefresh(ctx), "question")
+	if inner.calls != 3 {
+		t.Errorf("refresh should call the provider, got %d calls", inner.calls)
+	}
+
+	inner.text = "updated"
+	provider.GenerateText(WithRefresh(ctx), "question")
+	text, usage, _ := provider.GenerateText(ctx, "question")
+	if text != "updatedquestion" || !usage.ResponseCacheHit {
+		t.Errorf("refresh should replace the entry, got %q (hit=%v)", text, usage.ResponseCacheHit)
+	}
+}
+
+func TestStreamReplaysCachedResponse(t *testing.T) {
+	toolCalls := []llm.ToolCall{{ID: "call_1", Name: "lookup", Input: json.RawMessage(`{"q":"x"}`)}}
+	inner := &countingProvider{text: "a streamed answer to ", toolCalls: toolCalls}
+	provider := New(inner, NewMemoryStore(10), WithReplayChunkSize(4))
+	ctx := context.Background()
+
+	text, calls, usage, _ := collect(t, provider, ctx, "question")
+	if usage.ResponseCacheHit {
+		t.Fatal("first stream should not be a cache hit")
+	}
+
+	replayed, replayedCalls, usage, chunks := collect(t, provider, ctx, "question")
+	if replayed != text {
+		t.Errorf("replayed text = %q, want %q", replayed, text)
+	}
+	if len(replayedCalls) != len(calls) || replayedCalls[0].ID != "call_1" {
+		t.Errorf("replayed tool calls = %+v, want %+v", replayedCalls, calls)
+	}
+	if !usage.ResponseCacheHit {
+		t.Error("replay should be a cache hit")
+	}
+	if want := (len(text)+3)/4 + 2; chunks != want {
+		t.Errorf("replayed %d chunks, want %d", chunks, want)
+	}
+	if inner.calls != 1 {
+		t.Errorf("provider called %d times, want 1", inner.calls)
+	}
+}
+
+func TestMemoryStoreEvictsLeastRecentlyUsed(t *testing.T) {
+	store := NewMemoryStore(2)
+	ctx := context.Background()
+
+	store.Set(ctx, "a", &Entry{Text: "a"})
+	store.Set(ctx, "b", &Entry{Text: "b"})
+	store.Get(ctx, "a")
+	store.Set(ctx, "c", &Entry{Text: "c"})
+
+	if _, ok, _ := store.Get(ctx, "b"); ok {
+		t.Error("b should have been evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok, _ := store.Get(ctx, key); !ok {
+			t.Errorf("%s should still be cached", key)
+		}
+	}
+}
+
+func TestDiskStoreRoundTrip(t *testing.T) {
+	store, err := NewDiskStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDiskStore failed: %v", err)
+	}
+	ctx := context.Background()
+	entry := &Entry{
+		Text:      "cached",
+		ToolCalls: []llm.ToolCall{{ID: "call_1", Name: "lookup"}},
+		Usage:     llm.UsageInfo{InputTokens: 3},
+		CreatedAt: time.Now().UTC().Truncate(time.Second),
+	}
+
+	if err := store.Set(ctx, "key", entry); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	got, ok, err := store.Get(ctx, "key")
+	if err != nil || !ok {
+		t.Fatalf("Get = %v, %v", ok, err)
+	}
+	if got.Text != entry.Text || got.ToolCalls[0].Name != "lookup" || got.Usage.InputTokens != 3 || !got.CreatedAt.Equal(entry.CreatedAt) {
+		t.Errorf("round trip mismatch: %+v", got)
+	}
+
+	if err := store.Delete(ctx, "key"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, ok, _ := store.Get(ctx, "key"); ok {
+		t.Error("entry should be gone after Delete")
+	}
+}
@@ -0,0 +1,108 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ulgerang/llm-module/llm"
+)
+
+// Entry is a cached response. Text holds the GenerateText result or the concatenated
+// stream deltas; ToolCalls holds tool calls received on stream chunks.
+type Entry struct {
+	Text      string         `json:"text"`
+	ToolCalls []llm.ToolCall `json:"tool_calls,omitempty"`
+	Usage     llm.UsageInfo  `json:"usage"`
+	CreatedAt time.Time      `json:"created_at"`
+	ExpiresAt time.Time      `json:"expires_at,omitempty"`
+}
+
+// Expired reports whether the entry has a TTL that has passed.
+func (e *Entry) Expired(now time.Time) bool {
+	return !e.ExpiresAt.IsZero() && now.After(e.ExpiresAt)
+}
+
+// Store persists cache entries by key. Implementations must be safe for concurrent use.
+type Store interface {
+	Get(ctx context.Context, key string) (*Entry, bool, error)
+	Set(ctx context.Context, key string, entry *Entry) error
+	Delete(ctx context.Context, key string) error
+}
+
+// MemoryStore is an in-memory Store that evicts the least recently used entry once
+// it holds more than its capacity.
+type MemoryStore struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List
+	items    map[string]*list.Element
+}
+
+type memoryItem struct {
+	key   string
+	entry *Entry
+}
+
+// NewMemoryStore creates an LRU store holding at most capacity entries.
+// A capacity of zero or less means unbounded.
+func NewMemoryStore(capacity int) *MemoryStore {
+	return &MemoryStore{
+		capacity: capacity,
+		order:    list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+// Get returns the entry for key and marks it as recently used.
+func (s *MemoryStore) Get(_ context.Context, key string) (*Entry, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	element, ok := s.items[key]
+	if !ok {
+		return nil, false, nil
+	}
+	s.order.MoveToFront(element)
+	return element.Value.(*memoryItem).entry, true, nil
+}
+
+// Set stores the entry, evicting the least recently used entry when full.
+func (s *MemoryStore) Set(_ context.Context, key string, entry *Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if element, ok := s.items[key]; ok {
+		element.Value.(*memoryItem).entry = entry
+		s.order.MoveToFront(element)
+		return nil
+	}
+
+	s.items[key] = s.order.PushFront(&memoryItem{key: key, entry: entry})
+	if s.capacity > 0 && s.order.Len() > s.capacity {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.items, oldest.Value.(*memoryItem).key)
+	}
+	return nil
+}
+
+// Delete removes the entry for key.
+func (s *MemoryStore) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if element, ok := s.items[key]; ok {
+		s.order.Remove(element)
+		delete(s.items, key)
+	}
+	return nil
+}
+
+// Len returns the number of stored entries.
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.order.Len()
+}
@@ -0,0 +1,425 @@
+// Package config loads provider settings from a providers.yaml file, validates them and
+// constructs ready-to-use providers from them.
+//
+// A file looks like:
+//
+//	default: smart
+//	providers:
+//	  openai:
+//	    api_key: ${OPENAI_API_KEY}
+//	    default_model: gpt-4o
+//	    timeouts: {connect: 10s, stream_idle: 30s}
+//	    headers: {X-Team: search}
+//	    generation: {temperature: 0.2}
+//	  claude:
+//	    api_key_file: ~/.keys/anthropic
+//	profiles:
+//	  smart:
+//	    provider: claude
+//	    model: claude-opus-4-20250514
+//	    generation: {max_tokens: 4096}
+//	default_route: fast
+//	routes:
+//	  fast:
+//	    - {provider: openai, model: gpt-4o-mini, weight: 9}
+//	    - {provider: claude, model: claude-3-5-haiku-latest, weight: 1}
+//
+// ${VAR} references in api_key, api_key_file, base_url and header values are replaced
+// by environment variables when the file is loaded.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/ulgerang/llm-module/llm"
+)
+
+// Config is the content of a providers.yaml file.
+type Config struct {
+	// Default names the profile or provider used when NewProvider gets no name.
+	Default string `yaml:"default"`
+	// Providers holds the settings of each provider, keyed by provider name.
+	Providers map[string]*Provider `yaml:"providers"`
+	// Profiles are named combinations of a provider, a model and generation defaults.
+	Profiles map[string]*Profile `yaml:"profiles"`
+	// Routes map logical model names to weighted targets, used by the routing package.
+	Routes map[string][]*Route `yaml:"routes"`
+	// DefaultRoute names the route used when a call does not name a model.
+	DefaultRoute string `yaml:"default_route"`
+}
+
+// Provider holds the settings of one provider.
+type Provider struct {
+	APIKey string `yaml:"api_key"`
+	// APIKeyFile is read when the provider is created and takes precedence over APIKey.
+	APIKeyFile   string            `yaml:"api_key_file"`
+	DefaultModel string            `yaml:"default_model"`
+	BaseURL      string            `yaml:"base_url"`
+	Timeouts     *Timeouts         `yaml:"timeouts"`
+	Headers      map[string]string `yaml:"headers"`
+	Generation   *Generation       `yaml:"generation"`
+}
+
+// Timeouts mirrors llm.Timeouts. Values are Go durations such as "30s" or "2m".
+type Timeouts struct {
+	Connect    time.Duration `yaml:"connect"`
+	FirstByte  time.Duration `yaml:"first_byte"`
+	Total      time.Duration `yaml:"total"`
+	StreamIdle time.Duration `yaml:"stream_idle"`
+}
+
+// Generation holds default generation options. Options passed to a call take
+// precedence over them.
+type Generation struct {
+	Temperature *float32 `yaml:"temperature"`
+	MaxTokens   *int32   `yaml:"max_tokens"`
+	TopP        *float32 `yaml:"top_p"`
+	TopK        *float32 `yaml:"top_k"`
+	System      string   `yaml:"system"`
+	Language    string   `yaml:"language"`
+	Stop        []string `yaml:"stop"`
+	Seed        *int64   `yaml:"seed"`
+	// PresencePenalty and FrequencyPenalty are in [-2, 2].
+	PresencePenalty  *float32 `yaml:"presence_penalty"`
+	FrequencyPenalty *float32 `yaml:"frequency_penalty"`
+	// Strict turns options a provider does not support into errors instead of warnings.
+	Strict bool `yaml:"strict"`
+}
+
+// Profile is a named provider, model and set of generation defaults.
+type Profile struct {
+	Provider string `yaml:"provider"`
+	// Model overrides the provider's default model.
+	Model string `yaml:"model"`
+	// Generation overrides the provider's generation defaults field by field.
+	Generation *Generation `yaml:"generation"`
+}
+
+// Route is a target of a route: a provider, a model and generation defaults, chosen
+// with a probability proportional to Weight among the targets of the route. Weight
+// defaults to 1; 0 disables the target.
+type Route struct {
+	Profile `yaml:",inline"`
+	Weight  *int `yaml:"weight"`
+}
+
+// DefaultPath returns ~/.holon/providers.yaml, or "" when the home directory is unknown.
+func DefaultPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return ""
+	}
+	return filepath.Join(home, ".holon", "providers.yaml")
+}
+
+// Load reads, parses and validates the file at path.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	config, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return config, nil
+}
+
+// Parse parses and validates the content of a providers.yaml file. Unknown keys are
+// rejected so that typos do not silently drop settings.
+func Parse(data []byte) (*Config, error) {
+	var config Config
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&config); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	if err := config.normalize(); err != nil {
+		return nil, err
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+var envVarRe = regexp.MustCompile(`\$\{([^}]+)\}`)
+
+// expandEnv replaces ${VAR} references with environment variables.
+func expandEnv(s string) string {
+	return envVarRe.ReplaceAllStringFunc(s, func(match string) string {
+		return os.Getenv(match[2 : len(match)-1])
+	})
+}
+
+// expandPath expands a leading ~/ to the home directory.
+func expandPath(path string) string {
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil && home != "" {
+			return filepath.Join(home, path[2:])
+		}
+	}
+	return path
+}
+
+// normalize replaces provider names and aliases with the name of the provider they
+// refer to, so that "anthropic" and "claude" share settings, and expands environment
+// references. It fails when two keys of providers name the same provider.
+func (c *Config) normalize() error {
+	providers := make(map[string]*Provider, len(c.Providers))
+	keys := make(map[string]string, len(c.Providers))
+	for _, name := range sortedKeys(c.Providers) {
+		provider := c.Providers[name]
+		canonical := canonicalName(name)
+		if previous, ok := keys[canonical]; ok {
+			return fmt.Errorf("providers.%s: names the same provider as providers.%s", name, previous)
+		}
+		keys[canonical] = name
+		if provider == nil {
+			provider = &Provider{}
+		}
+		provider.APIKey = expandEnv(provider.APIKey)
+		provider.APIKeyFile = expandPath(expandEnv(provider.APIKeyFile))
+		provider.BaseURL = expandEnv(provider.BaseURL)
+		for header, value := range provider.Headers {
+			provider.Headers[header] = expandEnv(value)
+		}
+		providers[canonical] = provider
+	}
+	c.Providers = providers
+	for _, profile := range c.Profiles {
+		if profile != nil {
+			profile.Provider = canonicalName(profile.Provider)
+		}
+	}
+	for _, targets := range c.Routes {
+		for _, target := range targets {
+			if target == nil {
+				continue
+			}
+			target.Provider = canonicalName(target.Provider)
+			if target.Weight == nil {
+				target.Weight = llm.ValuePtr(1)
+			}
+		}
+	}
+	return nil
+}
+
+// Validate checks the configuration and reports every problem found, each prefixed
+// with the path of the offending setting.
+func (c *Config) Validate() error {
+	var errs []error
+	fail := func(path, format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf("%s: %s", path, fmt.Sprintf(format, args...)))
+	}
+
+	for _, name := range sortedKeys(c.Providers) {
+		provider := c.Providers[name]
+		path := "providers." + name
+		providerType, ok := resolveType(name)
+		if !ok {
+			fail(path, "unknown provider (supported: %s)", strings.Join(Supported(), ", "))
+			continue
+		}
+		if provider.BaseURL != "" {
+			if !constructors[providerType].baseURL {
+				fail(path+".base_url", "%s does not support a custom base URL", providerType)
+			} else if u, err := url.Parse(provider.BaseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				fail(path+".base_url", "%q is not an http or https URL", provider.BaseURL)
+			}
+		}
+		if t := provider.Timeouts; t != nil {
+			for _, field := range []struct {
+				name  string
+				value time.Duration
+			}{{"connect", t.Connect}, {"first_byte", t.FirstByte}, {"total", t.Total}, {"stream_idle", t.StreamIdle}} {
+				if field.value < 0 {
+					fail(path+".timeouts."+field.name, "must not be negative")
+				}
+			}
+		}
+		for header := range provider.Headers {
+			if header == "" || strings.ContainsAny(header, " :\r\n") {
+				fail(path+".headers", "invalid header name %q", header)
+			}
+		}
+		errs = append(errs, provider.Generation.validate(path+".generation")...)
+	}
+
+	for _, name := range sortedKeys(c.Profiles) {
+		profile := c.Profiles[name]
+		path := "profiles." + name
+		if profile == nil || profile.Provider == "" {
+			fail(path+".provider", "is required")
+			continue
+		}
+		if _, ok := c.Providers[canonicalName(name)]; ok {
+			fail(path, "has the same name as a provider")
+		}
+		if _, ok := resolveType(profile.Provider); !ok {
+			fail(path+".provider", "unknown provider %q (supported: %s)", profile.Provider, strings.Join(Supported(), ", "))
+		}
+		errs = append(errs, profile.Generation.validate(path+".generation")...)
+	}
+
+	for _, name := range sortedKeys(c.Routes) {
+		path := "routes." + name
+		total := 0
+		for i, target := range c.Routes[name] {
+			path := fmt.Sprintf("%s[%d]", path, i)
+			if target == nil || target.Provider == "" {
+				fail(path+".provider", "is required")
+				continue
+			}
+			if _, ok := resolveType(target.Provider); !ok {
+				fail(path+".provider", "unknown provider %q (supported: %s)", target.Provider, strings.Join(Supported(), ", "))
+			}
+			if target.Weight != nil && *target.Weight < 0 {
+				fail(path+".weight", "must not be negative")
+			} else if target.Weight != nil {
+				total += *target.Weight
+			}
+			errs = append(errs, target.Generation.validate(path+".generation")...)
+		}
+		if total == 0 {
+			fail(path, "needs a target with a positive weight")
+		}
+	}
+
+	if c.Default != "" {
+		if _, _, err := c.resolve(c.Default); err != nil {
+			fail("default", "%v", err)
+		}
+	}
+	if c.DefaultRoute != "" {
+		if _, ok := c.Routes[c.DefaultRoute]; !ok {
+			fail("default_route", "unknown route %q", c.DefaultRoute)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (g *Generation) validate(path string) []error {
+	if g == nil {
+		return nil
+	}
+	var errs []error
+	if g.Temperature != nil && (*g.Temperature < 0 || *g.Temperature > 2) {
+		errs = append(errs, fmt.Errorf("%s.temperature: %g is outside [0, 2]", path, *g.Temperature))
+	}
+	if g.MaxTokens != nil && *g.MaxTokens <= 0 {
+		errs = append(errs, fmt.Errorf("%s.max_tokens: must be positive", path))
+	}
+	if g.TopP != nil && (*g.TopP < 0 || *g.TopP > 1) {
+		errs = append(errs, fmt.Errorf("%s.top_p: %g is outside [0, 1]", path, *g.TopP))
+	}
+	if g.TopK != nil && *g.TopK < 0 {
+		errs = append(errs, fmt.Errorf("%s.top_k: must not be negative", path))
+	}
+	if g.PresencePenalty != nil && (*g.PresencePenalty < -2 || *g.PresencePenalty > 2) {
+		errs = append(errs, fmt.Errorf("%s.presence_penalty: %g is outside [-2, 2]", path, *g.PresencePenalty))
+	}
+	if g.FrequencyPenalty != nil && (*g.FrequencyPenalty < -2 || *g.FrequencyPenalty > 2) {
+		errs = append(errs, fmt.Errorf("%s.frequency_penalty: %g is outside [-2, 2]", path, *g.FrequencyPenalty))
+	}
+	return errs
+}
+
+// Options returns the generation options set in g.
+func (g *Generation) Options() []llm.GenerationOption {
+	if g == nil {
+		return nil
+	}
+	var opts []llm.GenerationOption
+	if g.Temperature != nil {
+		opts = append(opts, llm.WithTemperature(*g.Temperature))
+	}
+	if g.MaxTokens != nil {
+		opts = append(opts, llm.WithMaxTokens(*g.MaxTokens))
+	}
+	if g.TopP != nil {
+		opts = append(opts, llm.WithTopP(*g.TopP))
+	}
+	if g.TopK != nil {
+		opts = append(opts, llm.WithTopK(*g.TopK))
+	}
+	if g.System != "" {
+		opts = append(opts, llm.WithSystem(g.System))
+	}
+	if g.Language != "" {
+		opts = append(opts, llm.WithLanguage(g.Language))
+	}
+	if len(g.Stop) > 0 {
+		opts = append(opts, llm.WithStopSequences(g.Stop...))
+	}
+	if g.Seed != nil {
+		opts = append(opts, llm.WithSeed(*g.Seed))
+	}
+	if g.PresencePenalty != nil {
+		opts = append(opts, llm.WithPresencePenalty(*g.PresencePenalty))
+	}
+	if g.FrequencyPenalty != nil {
+		opts = append(opts, llm.WithFrequencyPenalty(*g.FrequencyPenalty))
+	}
+	if g.Strict {
+		opts = append(opts, llm.WithStrict())
+	}
+	return opts
+}
+
+// ProviderOptions returns the provider options for the timeouts and headers of p.
+func (p *Provider) ProviderOptions() []llm.ProviderOption {
+	var opts []llm.ProviderOption
+	if t := p.Timeouts; t != nil {
+		opts = append(opts, llm.WithTimeouts(llm.Timeouts{Connect: t.Connect, FirstByte: t.FirstByte, Total: t.Total, StreamIdle: t.StreamIdle}))
+	}
+	if len(p.Headers) > 0 {
+		headers := make(map[string][]string, len(p.Headers))
+		for name, value := range p.Headers {
+			headers[name] = []string{value}
+		}
+		opts = append(opts, llm.WithDefaultHeaders(headers))
+	}
+	return opts
+}
+
+// ResolveAPIKey returns the key from APIKeyFile when set, otherwise APIKey. An empty
+// result lets the provider fall back to its environment variable.
+func (p *Provider) ResolveAPIKey() (string, error) {
+	if p.APIKeyFile == "" {
+		return p.APIKey, nil
+	}
+	data, err := os.ReadFile(p.APIKeyFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read api_key_file: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// Provider returns the settings of the named provider, or nil when it has none. name
+// may be an alias such as "anthropic".
+func (c *Config) Provider(name string) *Provider {
+	return c.Providers[canonicalName(name)]
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
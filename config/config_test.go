@@ -0,0 +1,212 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ulgerang/llm-module/logger"
+)
+
+func TestParseExpandsEnvironment(t *testing.T) {
+	t.Setenv("TEST_OPENAI_KEY", "sk-from-env")
+	config, err := Parse([]byte(`
+providers:
+  OpenAI:
+    api_key: ${TEST_OPENAI_KEY}
+    default_model: gpt-test
+    timeouts: {connect: 5s, stream_idle: 30s}
+    headers: {X-Key: "${TEST_OPENAI_KEY}"}
+`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	p := config.Provider("openai")
+	if p == nil || p.APIKey != "sk-from-env" || p.Headers["X-Key"] != "sk-from-env" {
+		t.Fatalf("unexpected provider %+v", p)
+	}
+	if p.Timeouts.Connect != 5*time.Second || p.Timeouts.StreamIdle != 30*time.Second {
+		t.Errorf("unexpected timeouts %+v", p.Timeouts)
+	}
+}
+
+func TestParseCanonicalizesAliases(t *testing.T) {
+	config, err := Parse([]byte(`
+providers:
+  anthropic: {api_key: sk-ant, default_model: claude-test}
+profiles:
+  smart: {provider: Anthropic}
+routes:
+  fast:
+    - {provider: xai}
+`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	for _, name := range []string{"claude", "anthropic"} {
+		if p := config.Provider(name); p == nil || p.APIKey != "sk-ant" {
+			t.Errorf("Provider(%q) = %+v", name, p)
+		}
+	}
+	if got := config.Profiles["smart"].Provider; got != "claude" {
+		t.Errorf("profile provider = %q, want claude", got)
+	}
+	if got := config.Routes["fast"][0].Provider; got != "grok" {
+		t.Errorf("route provider = %q, want grok", got)
+	}
+
+	_, err = Parse([]byte(`
+providers:
+  anthropic: {api_key: a}
+  claude: {api_key: b}
+`))
+	if err == nil || !strings.Contains(err.Error(), "providers.claude: names the same provider as providers.anthropic") {
+		t.Errorf("expected a duplicate provider error, got %v", err)
+	}
+}
+
+func TestValidateReportsEveryProblem(t *testing.T) {
+	_, err := Parse([]byte(`
+default: missing
+providers:
+  mistral: {}
+  gemini:
+    base_url: https://example.com
+  openai:
+    base_url: example.com
+    timeouts: {total: -1s}
+    generation: {temperature: 3}
+profiles:
+  fast: {model: x}
+`))
+	if err == nil {
+		t.Fatal("expected validation errors")
+	}
+	for _, want := range []string{
+		"providers.mistral: unknown provider",
+		"providers.gemini.base_url: gemini does not support a custom base URL",
+		`providers.openai.base_url: "example.com" is not an http or https URL`,
+		"providers.openai.timeouts.total: must not be negative",
+		"providers.openai.generation.temperature: 3 is outside [0, 2]",
+		"profiles.fast.provider: is required",
+		`default: "missing" is neither a profile nor a supported provider`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+}
+
+func TestParseRejectsUnknownKeys(t *testing.T) {
+	if _, err := Parse([]byte("providers:\n  openai:\n    apikey: x\n")); err == nil {
+		t.Fatal("expected an error for an unknown key")
+	}
+}
+
+func TestNewProviderAppliesProfile(t *testing.T) {
+	var request map[string]interface{}
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+		json.NewDecoder(r.Body).Decode(&request)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"c1","object":"chat.completion","model":"gpt-test","choices":[{"index":0,"message":{"role":"assistant","content":"hi"},"finish_reason":"stop"}]}`))
+	}))
+	defer server.Close()
+
+	keyFile := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(keyFile, []byte("sk-from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	config, err := Parse([]byte(`
+default: precise
+providers:
+  openai:
+    api_key_file: ` + keyFile + `
+    base_url: ` + server.URL + `
+    default_model: gpt-default
+    headers: {X-Team: search}
+    generation: {temperature: 0.7, max_tokens: 100}
+profiles:
+  precise:
+    provider: openai
+    model: gpt-test
+    generation: {temperature: 0}
+`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	provider, err := config.NewProvider(logger.Nop(), "")
+	if err != nil {
+		t.Fatalf("NewProvider failed: %v", err)
+	}
+	if got := provider.GetModelName(); got != "gpt-test" {
+		t.Errorf("expected model gpt-test, got %q", got)
+	}
+	if _, _, err := provider.GenerateText(context.Background(), "Hello"); err != nil {
+		t.Fatalf("GenerateText failed: %v", err)
+	}
+	if request["model"] != "gpt-test" || request["temperature"] != float64(0) || request["max_tokens"] != float64(100) {
+		t.Errorf("profile defaults not applied: %v", request)
+	}
+	if header.Get("Authorization") != "Bearer sk-from-file" || header.Get("X-Team") != "search" {
+		t.Errorf("unexpected headers %v", header)
+	}
+}
+
+func TestNewProviderRejectsUnknownName(t *testing.T) {
+	config, err := Parse([]byte("providers:\n  openai: {}\n"))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if _, err := config.NewProvider(logger.Nop(), "nope"); err == nil {
+		t.Fatal("expected an error for an unknown name")
+	}
+}
+
+func TestWatcherReloadsChangedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "providers.yaml")
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("default: openai\n")
+
+	errs := make(chan error, 10)
+	w, err := Watch(path, WithPollInterval(10*time.Millisecond), WithErrorHandler(func(err error) { errs <- err }))
+	if err != nil {
+		t.Fatalf("Watch failed: %v", err)
+	}
+	defer w.Close()
+	changes := make(chan *Config, 10)
+	w.OnChange(func(c *Config) { changes <- c })
+
+	write("default: deepseek\n")
+	select {
+	case c := <-changes:
+		if c.Default != "deepseek" || w.Config().Default != "deepseek" {
+			t.Errorf("expected reloaded default deepseek, got %q", c.Default)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("config was not reloaded")
+	}
+
+	write("default: not-a-provider\n")
+	select {
+	case <-errs:
+	case <-time.After(2 * time.Second):
+		t.Fatal("invalid config was not reported")
+	}
+	if got := w.Config().Default; got != "deepseek" {
+		t.Errorf("expected previous config to be kept, got default %q", got)
+	}
+}
@@ -0,0 +1,176 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ulgerang/llm-module/llm"
+	"github.com/ulgerang/llm-module/logger"
+	"github.com/ulgerang/llm-module/providers/ai302"
+	"github.com/ulgerang/llm-module/providers/cerebras"
+	"github.com/ulgerang/llm-module/providers/claude"
+	"github.com/ulgerang/llm-module/providers/deepseek"
+	"github.com/ulgerang/llm-module/providers/gemini"
+	"github.com/ulgerang/llm-module/providers/grok"
+	"github.com/ulgerang/llm-module/providers/groq"
+	"github.com/ulgerang/llm-module/providers/inception"
+	"github.com/ulgerang/llm-module/providers/openai"
+	"github.com/ulgerang/llm-module/providers/openrouter"
+	"github.com/ulgerang/llm-module/providers/zai"
+)
+
+type newFunc func(log logger.Logger, apiKey, model, baseURL string, opts ...llm.ProviderOption) (llm.Provider, error)
+
+type constructor struct {
+	new newFunc
+	// baseURL reports whether the provider accepts a custom base URL.
+	baseURL bool
+}
+
+// provider converts a typed constructor result, so a failed constructor never yields a
+// non-nil interface holding a nil pointer.
+func provider[P llm.Provider](p P, err error) (llm.Provider, error) {
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+var constructors = map[string]constructor{
+	"ai302": {baseURL: true, new: func(log logger.Logger, apiKey, model, baseURL string, opts ...llm.ProviderOption) (llm.Provider, error) {
+		return provider(ai302.NewWithBaseURL(log, apiKey, model, baseURL, opts...))
+	}},
+	"cerebras": {baseURL: true, new: func(log logger.Logger, apiKey, model, baseURL string, opts ...llm.ProviderOption) (llm.Provider, error) {
+		return provider(cerebras.NewWithBaseURL(log, apiKey, model, baseURL, opts...))
+	}},
+	"claude": {baseURL: true, new: func(log logger.Logger, apiKey, model, baseURL string, opts ...llm.ProviderOption) (llm.Provider, error) {
+		return provider(claude.NewWithBaseURL(log, apiKey, model, baseURL, opts...))
+	}},
+	"deepseek": {baseURL: true, new: func(log logger.Logger, apiKey, model, baseURL string, opts ...llm.ProviderOption) (llm.Provider, error) {
+		return provider(deepseek.NewWithBaseURL(log, apiKey, model, baseURL, opts...))
+	}},
+	"gemini": {new: func(log logger.Logger, apiKey, model, _ string, opts ...llm.ProviderOption) (llm.Provider, error) {
+		return provider(gemini.New(log, apiKey, model, opts...))
+	}},
+	"grok": {baseURL: true, new: func(log logger.Logger, apiKey, model, baseURL string, opts ...llm.ProviderOption) (llm.Provider, error) {
+		return provider(grok.NewWithBaseURL(log, apiKey, model, baseURL, opts...))
+	}},
+	"groq": {baseURL: true, new: func(log logger.Logger, apiKey, model, baseURL string, opts ...llm.ProviderOption) (llm.Provider, error) {
+		return provider(groq.NewWithBaseURL(log, apiKey, model, baseURL, opts...))
+	}},
+	"inception": {baseURL: true, new: func(log logger.Logger, apiKey, model, baseURL string, opts ...llm.ProviderOption) (llm.Provider, error) {
+		return provider(inception.NewWithBaseURL(log, apiKey, model, baseURL, opts...))
+	}},
+	"openai": {baseURL: true, new: func(log logger.Logger, apiKey, model, baseURL string, opts ...llm.ProviderOption) (llm.Provider, error) {
+		return provider(openai.NewWithBaseURL(log, apiKey, model, baseURL, 0, opts...))
+	}},
+	"openrouter": {baseURL: true, new: func(log logger.Logger, apiKey, model, baseURL string, opts ...llm.ProviderOption) (llm.Provider, error) {
+		return provider(openrouter.NewWithBaseURL(log, apiKey, model, baseURL, opts...))
+	}},
+	"zai": {baseURL: true, new: func(log logger.Logger, apiKey, model, baseURL string, opts ...llm.ProviderOption) (llm.Provider, error) {
+		return provider(zai.NewWithBaseURL(log, apiKey, model, baseURL, opts...))
+	}},
+}
+
+// aliases maps vendor names to the provider that serves them.
+var aliases = map[string]string{
+	"anthropic": "claude",
+	"google":    "gemini",
+	"xai":       "grok",
+}
+
+// Supported returns the provider names that can be configured, sorted.
+func Supported() []string {
+	names := make([]string, 0, len(constructors))
+	for name := range constructors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// resolveType returns the provider type for a provider name or alias.
+func resolveType(name string) (string, bool) {
+	name = strings.ToLower(name)
+	if alias, ok := aliases[name]; ok {
+		name = alias
+	}
+	_, ok := constructors[name]
+	return name, ok
+}
+
+// canonicalName returns the provider a name or alias refers to, or the lower-cased name
+// when it is not a supported provider.
+func canonicalName(name string) string {
+	canonical, _ := resolveType(name)
+	return canonical
+}
+
+// resolve returns the provider name and profile, if any, that name refers to.
+func (c *Config) resolve(name string) (string, *Profile, error) {
+	if profile, ok := c.Profiles[name]; ok && profile != nil {
+		return profile.Provider, profile, nil
+	}
+	if canonical, ok := resolveType(name); ok {
+		return canonical, nil, nil
+	}
+	return "", nil, fmt.Errorf("%q is neither a profile nor a supported provider", name)
+}
+
+// NewProvider creates the provider for name, which is a profile or a provider name; an
+// empty name uses Default. Timeouts and headers from the file are applied before opts,
+// so opts can override them. Generation defaults from the provider and profile wrap the
+// returned provider, with options given to a call taking precedence.
+func (c *Config) NewProvider(log logger.Logger, name string, opts ...llm.ProviderOption) (llm.Provider, error) {
+	if name == "" {
+		name = c.Default
+		if name == "" {
+			return nil, fmt.Errorf("no provider name given and no default configured")
+		}
+	}
+	providerName, profile, err := c.resolve(name)
+	if err != nil {
+		return nil, err
+	}
+	if profile == nil {
+		profile = &Profile{Provider: providerName}
+	}
+	return c.NewProfileProvider(log, profile, opts...)
+}
+
+// NewProfileProvider creates the provider described by profile, which need not be one
+// of the file's profiles, such as the target of a route. Settings are applied as by
+// NewProvider.
+func (c *Config) NewProfileProvider(log logger.Logger, profile *Profile, opts ...llm.ProviderOption) (llm.Provider, error) {
+	providerName := profile.Provider
+	providerType, ok := resolveType(providerName)
+	if !ok {
+		return nil, fmt.Errorf("unknown provider %q", providerName)
+	}
+
+	settings := c.Provider(providerName)
+	if settings == nil {
+		settings = &Provider{}
+	}
+	apiKey, err := settings.ResolveAPIKey()
+	if err != nil {
+		return nil, fmt.Errorf("providers.%s: %w", providerName, err)
+	}
+
+	model := settings.DefaultModel
+	if profile.Model != "" {
+		model = profile.Model
+	}
+	defaults := append(settings.Generation.Options(), profile.Generation.Options()...)
+
+	providerOpts := append(settings.ProviderOptions(), opts...)
+	p, err := constructors[providerType].new(log, apiKey, model, settings.BaseURL, providerOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s provider: %w", providerType, err)
+	}
+	if len(defaults) > 0 {
+		p = llm.Wrap(p, llm.Defaults(defaults...))
+	}
+	return p, nil
+}
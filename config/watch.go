@@ -0,0 +1,149 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+const defaultPollInterval = 2 * time.Second
+
+// Watcher keeps a Config in sync with its file, reloading it when the file changes.
+// A file that fails to load or validate is reported and the previous Config is kept.
+type Watcher struct {
+	path     string
+	interval time.Duration
+	onError  func(error)
+
+	mu       sync.RWMutex
+	current  *Config
+	modTime  time.Time
+	size     int64
+	handlers []func(*Config)
+
+	done      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+// WatchOption configures a Watcher.
+type WatchOption func(*Watcher)
+
+// WithPollInterval sets how often the file is checked for changes. The default is 2s.
+func WithPollInterval(interval time.Duration) WatchOption {
+	return func(w *Watcher) {
+		if interval > 0 {
+			w.interval = interval
+		}
+	}
+}
+
+// WithErrorHandler receives errors from reloading a changed file.
+func WithErrorHandler(handler func(error)) WatchOption {
+	return func(w *Watcher) {
+		w.onError = handler
+	}
+}
+
+// Watch loads the file at path and starts watching it. It fails if the initial load
+// fails. Call Close to stop watching.
+func Watch(path string, opts ...WatchOption) (*Watcher, error) {
+	w := &Watcher{path: path, interval: defaultPollInterval, done: make(chan struct{})}
+	for _, opt := range opts {
+		opt(w)
+	}
+	if err := w.Reload(); err != nil {
+		return nil, err
+	}
+
+	w.wg.Add(1)
+	go w.poll()
+	return w, nil
+}
+
+// Config returns the current configuration.
+func (w *Watcher) Config() *Config {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.current
+}
+
+// OnChange registers fn to be called with each newly loaded configuration.
+func (w *Watcher) OnChange(fn func(*Config)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.handlers = append(w.handlers, fn)
+}
+
+// Reload loads the file now, for example on SIGHUP, and notifies the OnChange
+// handlers when it succeeds.
+func (w *Watcher) Reload() error {
+	info, err := os.Stat(w.path)
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+	config, err := Load(w.path)
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	w.current = config
+	w.modTime, w.size = info.ModTime(), info.Size()
+	handlers := append([]func(*Config){}, w.handlers...)
+	w.mu.Unlock()
+
+	for _, handler := range handlers {
+		handler(config)
+	}
+	return nil
+}
+
+// Close stops watching the file.
+func (w *Watcher) Close() error {
+	w.closeOnce.Do(func() {
+		close(w.done)
+	})
+	w.wg.Wait()
+	return nil
+}
+
+func (w *Watcher) poll() {
+	defer w.wg.Done()
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-ticker.C:
+		}
+
+		info, err := os.Stat(w.path)
+		if err != nil {
+			w.report(fmt.Errorf("failed to read config: %w", err))
+			continue
+		}
+		w.mu.RLock()
+		changed := !info.ModTime().Equal(w.modTime) || info.Size() != w.size
+		w.mu.RUnlock()
+		if !changed {
+			continue
+		}
+		if err := w.Reload(); err != nil {
+			// Remember the broken file so the error is reported once per change.
+			w.mu.Lock()
+			w.modTime, w.size = info.ModTime(), info.Size()
+			w.mu.Unlock()
+			w.report(err)
+		}
+	}
+}
+
+func (w *Watcher) report(err error) {
+	if w.onError != nil {
+		w.onError(err)
+	}
+}
@@ -0,0 +1,137 @@
+// Package credential provides llm.CredentialSource implementations: fixed keys,
+// environment variables, files re-read when they change, commands such as secret
+// manager CLIs, and pools that rotate between several keys.
+//
+//	pool := credential.NewPool([]string{key1, key2, key3})
+//	provider, err := openai.New(log, "", "gpt-4o", 0, llm.WithCredentials(pool))
+package credential
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ulgerang/llm-module/llm"
+)
+
+// ErrNoCredential is returned when a source has no key to offer.
+var ErrNoCredential = errors.New("no credential available")
+
+// Static returns a source that always supplies key.
+func Static(key string) llm.CredentialSource {
+	return staticSource(key)
+}
+
+type staticSource string
+
+func (s staticSource) Credential(context.Context) (string, error) {
+	if s == "" {
+		return "", ErrNoCredential
+	}
+	return string(s), nil
+}
+
+// Env returns a source that reads the environment variable name on every request.
+func Env(name string) llm.CredentialSource {
+	return envSource(name)
+}
+
+type envSource string
+
+func (s envSource) Credential(context.Context) (string, error) {
+	key := os.Getenv(string(s))
+	if key == "" {
+		return "", fmt.Errorf("%w: %s is not set", ErrNoCredential, string(s))
+	}
+	return key, nil
+}
+
+// FileSource supplies the trimmed content of a file, re-reading it when its
+// modification time or size changes.
+type FileSource struct {
+	path string
+
+	mu      sync.Mutex
+	key     string
+	modTime time.Time
+	size    int64
+}
+
+// File returns a source that reads the key from the file at path.
+func File(path string) *FileSource {
+	return &FileSource{path: path}
+}
+
+// Credential implements llm.CredentialSource.
+func (s *FileSource) Credential(context.Context) (string, error) {
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read credential file: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.key != "" && info.ModTime().Equal(s.modTime) && info.Size() == s.size {
+		return s.key, nil
+	}
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read credential file: %w", err)
+	}
+	key := strings.TrimSpace(string(data))
+	if key == "" {
+		return "", fmt.Errorf("%w: %s is empty", ErrNoCredential, s.path)
+	}
+	s.key, s.modTime, s.size = key, info.ModTime(), info.Size()
+	return key, nil
+}
+
+// ExecSource supplies the trimmed standard output of a command, such as a secret
+// manager CLI. The output is cached for the TTL given to Exec.
+type ExecSource struct {
+	name string
+	args []string
+	ttl  time.Duration
+
+	mu      sync.Mutex
+	key     string
+	expires time.Time
+}
+
+// Exec returns a source that runs name with args to get the key. The key is reused
+// for ttl; a ttl of zero runs the command for every request.
+func Exec(ttl time.Duration, name string, args ...string) *ExecSource {
+	return &ExecSource{name: name, args: args, ttl: ttl}
+}
+
+// Credential implements llm.CredentialSource.
+func (s *ExecSource) Credential(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.key != "" && time.Now().Before(s.expires) {
+		return s.key, nil
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, s.name, s.args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("credential command %s failed: %w: %s", s.name, err, msg)
+		}
+		return "", fmt.Errorf("credential command %s failed: %w", s.name, err)
+	}
+	key := strings.TrimSpace(string(out))
+	if key == "" {
+		return "", fmt.Errorf("%w: %s printed nothing", ErrNoCredential, s.name)
+	}
+	s.key, s.expires = key, time.Now().Add(s.ttl)
+	return key, nil
+}
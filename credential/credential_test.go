@@ -0,0 +1,93 @@
+package credential
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileIsRereadWhenChanged(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(path, []byte("first-key\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	source := File(path)
+	if key, err := source.Credential(context.Background()); err != nil || key != "first-key" {
+		t.Fatalf("expected first-key, got %q, %v", key, err)
+	}
+
+	if err := os.WriteFile(path, []byte("rotated-key\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if key, err := source.Credential(context.Background()); err != nil || key != "rotated-key" {
+		t.Fatalf("expected rotated-key, got %q, %v", key, err)
+	}
+}
+
+func TestExecCachesOutput(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	counter := filepath.Join(t.TempDir(), "runs")
+	source := Exec(time.Hour, "sh", "-c", "echo run >> "+counter+"; echo exec-key")
+	for i := 0; i < 2; i++ {
+		if key, err := source.Credential(context.Background()); err != nil || key != "exec-key" {
+			t.Fatalf("expected exec-key, got %q, %v", key, err)
+		}
+	}
+	data, _ := os.ReadFile(counter)
+	if string(data) != "run\n" {
+		t.Errorf("expected the command to run once, got %q", data)
+	}
+}
+
+func TestEnvReportsMissingVariable(t *testing.T) {
+	t.Setenv("TEST_CREDENTIAL_KEY", "")
+	if _, err := Env("TEST_CREDENTIAL_KEY").Credential(context.Background()); !errors.Is(err, ErrNoCredential) {
+		t.Fatalf("expected ErrNoCredential, got %v", err)
+	}
+	t.Setenv("TEST_CREDENTIAL_KEY", "env-key")
+	if key, _ := Env("TEST_CREDENTIAL_KEY").Credential(context.Background()); key != "env-key" {
+		t.Errorf("expected env-key, got %q", key)
+	}
+}
+
+func TestPoolRotatesAndBenches(t *testing.T) {
+	now := time.Unix(0, 0)
+	pool := NewPool([]string{"a", "b", "c"}, WithRateLimitCooldown(time.Minute))
+	pool.now = func() time.Time { return now }
+
+	next := func() string {
+		t.Helper()
+		key, err := pool.Credential(context.Background())
+		if err != nil {
+			t.Fatalf("Credential failed: %v", err)
+		}
+		return key
+	}
+	if got := []string{next(), next(), next(), next()}; got[0] != "a" || got[1] != "b" || got[2] != "c" || got[3] != "a" {
+		t.Fatalf("expected round-robin order, got %v", got)
+	}
+
+	pool.Report("b", http.StatusTooManyRequests)
+	pool.Report("c", http.StatusOK)
+	if got := []string{next(), next()}; got[0] != "c" || got[1] != "a" {
+		t.Fatalf("expected benched key to be skipped, got %v", got)
+	}
+
+	pool.Report("a", http.StatusUnauthorized)
+	pool.Report("c", http.StatusUnauthorized)
+	if _, err := pool.Credential(context.Background()); !errors.Is(err, ErrNoCredential) {
+		t.Fatalf("expected ErrNoCredential with every key benched, got %v", err)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if key := next(); key != "b" {
+		t.Errorf("expected b back after its cooldown, got %q", key)
+	}
+}
@@ -0,0 +1,106 @@
+package credential
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	defaultRateLimitCooldown = time.Minute
+	defaultAuthCooldown      = 10 * time.Minute
+)
+
+// Pool rotates between several keys round-robin. A key that receives a 429 or 401
+// response is benched for a cooldown and skipped until it expires.
+type Pool struct {
+	keys              []string
+	rateLimitCooldown time.Duration
+	authCooldown      time.Duration
+	now               func() time.Time
+
+	mu      sync.Mutex
+	next    int
+	benched map[string]time.Time
+}
+
+// PoolOption configures a Pool.
+type PoolOption func(*Pool)
+
+// WithRateLimitCooldown sets how long a key is benched after a 429 response. The
+// default is one minute.
+func WithRateLimitCooldown(d time.Duration) PoolOption {
+	return func(p *Pool) {
+		p.rateLimitCooldown = d
+	}
+}
+
+// WithAuthCooldown sets how long a key is benched after a 401 response. The default
+// is ten minutes.
+func WithAuthCooldown(d time.Duration) PoolOption {
+	return func(p *Pool) {
+		p.authCooldown = d
+	}
+}
+
+// NewPool returns a pool of keys. Empty keys are ignored.
+func NewPool(keys []string, opts ...PoolOption) *Pool {
+	p := &Pool{
+		rateLimitCooldown: defaultRateLimitCooldown,
+		authCooldown:      defaultAuthCooldown,
+		now:               time.Now,
+		benched:           make(map[string]time.Time),
+	}
+	for _, key := range keys {
+		if key != "" {
+			p.keys = append(p.keys, key)
+		}
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Credential implements llm.CredentialSource. It returns the next key that is not
+// benched, or an error wrapping ErrNoCredential when every key is.
+func (p *Pool) Credential(context.Context) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.keys) == 0 {
+		return "", fmt.Errorf("%w: the pool is empty", ErrNoCredential)
+	}
+
+	now := p.now()
+	for range p.keys {
+		key := p.keys[p.next]
+		p.next = (p.next + 1) % len(p.keys)
+		if until, ok := p.benched[key]; ok {
+			if now.Before(until) {
+				continue
+			}
+			delete(p.benched, key)
+		}
+		return key, nil
+	}
+	return "", fmt.Errorf("%w: all %d keys are benched", ErrNoCredential, len(p.keys))
+}
+
+// Report implements llm.CredentialReporter, benching key after a 429 or 401 response.
+func (p *Pool) Report(key string, statusCode int) {
+	var cooldown time.Duration
+	switch statusCode {
+	case http.StatusTooManyRequests:
+		cooldown = p.rateLimitCooldown
+	case http.StatusUnauthorized:
+		cooldown = p.authCooldown
+	default:
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.benched[key] = p.now().Add(cooldown)
+}
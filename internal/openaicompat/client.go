@@ -0,0 +1,48 @@
+package openaicompat
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/openai/openai-go/option"
+
+	"github.com/ulgerang/llm-module/llm"
+	"github.com/ulgerang/llm-module/transport"
+)
+
+// ClientOptions returns the SDK options that install the HTTP client built from config
+// and base. It returns no options when neither configures anything, so the SDK keeps
+// its default client.
+func ClientOptions(config *llm.ProviderConfig, base *http.Client) ([]option.RequestOption, error) {
+	client, err := transport.NewClient(config, base)
+	if err != nil || client == nil {
+		return nil, err
+	}
+	return []option.RequestOption{option.WithHTTPClient(client)}, nil
+}
+
+// RequestOptions returns the SDK options that add the headers and extra body fields of
+// options to a request and send the metadata user ID as "user".
+func RequestOptions(options *llm.GenerationOptions) []option.RequestOption {
+	var opts []option.RequestOption
+	for name, values := range options.Headers {
+		for i, value := range values {
+			if i == 0 {
+				opts = append(opts, option.WithHeader(name, value))
+			} else {
+				opts = append(opts, option.WithHeaderAdd(name, value))
+			}
+		}
+	}
+	if user := options.Metadata[llm.MetadataUserID]; user != "" {
+		opts = append(opts, option.WithJSONSet("user", user))
+	}
+	for key, value := range options.ExtraBody {
+		opts = append(opts, option.WithJSONSet(jsonPathEscaper.Replace(key), value))
+	}
+	return opts
+}
+
+// jsonPathEscaper escapes the characters sjson treats as path syntax, so extra body
+// keys are always set at the top level.
+var jsonPathEscaper = strings.NewReplacer(".", `\.`, "*", `\*`, "?", `\?`, "|", `\|`, "#", `\#`, "@", `\@`, ":", `\:`)
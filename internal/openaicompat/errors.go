@@ -0,0 +1,33 @@
+package openaicompat
+
+import (
+	"errors"
+
+	sdk "github.com/openai/openai-go"
+
+	"github.com/ulgerang/llm-module/llm"
+)
+
+// Error converts an SDK error response into an *llm.APIError that keeps the SDK error
+// as its cause. Other errors are returned unchanged.
+func Error(provider string, err error) error {
+	var sdkErr *sdk.Error
+	if !errors.As(err, &sdkErr) {
+		return err
+	}
+	errType := sdkErr.Type
+	if errType == "" {
+		errType = sdkErr.Code
+	}
+	message := sdkErr.Message
+	if message == "" {
+		message = sdkErr.Error()
+	}
+	return &llm.APIError{
+		Provider:   provider,
+		StatusCode: sdkErr.StatusCode,
+		Type:       errType,
+		Message:    message,
+		Err:        err,
+	}
+}
@@ -0,0 +1,109 @@
+package openaicompat
+
+import (
+	"fmt"
+
+	sdk "github.com/openai/openai-go"
+
+	"github.com/ulgerang/llm-module/llm"
+)
+
+// Messages builds the chat messages for a request: the system prompt, the conversation
+// history from options.Messages and the prompt as the final user message.
+func Messages(systemPrompt string, options *llm.GenerationOptions, prompt string) ([]sdk.ChatCompletionMessageParamUnion, error) {
+	messages := []sdk.ChatCompletionMessageParamUnion{}
+	if systemPrompt != "" {
+		messages = append(messages, sdk.SystemMessage(systemPrompt))
+	}
+	if len(options.Messages) == 0 {
+		return append(messages, sdk.UserMessage(prompt)), nil
+	}
+
+	for _, message := range llm.ConversationMessages(options, prompt) {
+		switch message.Role {
+		case llm.RoleUser:
+			messages = append(messages, sdk.UserMessage(message.Content))
+		case llm.RoleAssistant:
+			messages = append(messages, AssistantMessage(message))
+		case llm.RoleTool:
+			messages = append(messages, sdk.ToolMessage(message.Content, message.ToolCallID))
+		default:
+			return nil, fmt.Errorf("unsupported message role '%s'", message.Role)
+		}
+	}
+	return messages, nil
+}
+
+// AssistantMessage converts an assistant turn, including any tool calls it requested.
+func AssistantMessage(message llm.Message) sdk.ChatCompletionMessageParamUnion {
+	if len(message.ToolCalls) == 0 {
+		return sdk.AssistantMessage(message.Content)
+	}
+
+	assistant := sdk.ChatCompletionAssistantMessageParam{}
+	if message.Content != "" {
+		assistant.Content.OfString = sdk.String(message.Content)
+	}
+	for _, call := range message.ToolCalls {
+		arguments := string(call.Input)
+		if arguments == "" {
+			arguments = "{}"
+		}
+		assistant.ToolCalls = append(assistant.ToolCalls, sdk.ChatCompletionMessageToolCallParam{
+			ID: call.ID,
+			Function: sdk.ChatCompletionMessageToolCallFunctionParam{
+				Name:      call.Name,
+				Arguments: arguments,
+			},
+		})
+	}
+	return sdk.ChatCompletionMessageParamUnion{OfAssistant: &assistant}
+}
+
+// ToolCallAccumulator assembles tool calls from streamed deltas. Each call arrives as
+// fragments sharing an index: the first carries the ID and name, the rest append to
+// the arguments string.
+type ToolCallAccumulator struct {
+	calls   []*accumulatedToolCall
+	byIndex map[int64]*accumulatedToolCall
+}
+
+type accumulatedToolCall struct {
+	id        string
+	name      string
+	arguments []byte
+}
+
+// Add merges the tool call deltas of one stream chunk.
+func (a *ToolCallAccumulator) Add(deltas []sdk.ChatCompletionChunkChoiceDeltaToolCall) {
+	for _, delta := range deltas {
+		if a.byIndex == nil {
+			a.byIndex = make(map[int64]*accumulatedToolCall)
+		}
+		call, ok := a.byIndex[delta.Index]
+		if !ok {
+			call = &accumulatedToolCall{}
+			a.byIndex[delta.Index] = call
+			a.calls = append(a.calls, call)
+		}
+		if delta.ID != "" {
+			call.id = delta.ID
+		}
+		if delta.Function.Name != "" {
+			call.name = delta.Function.Name
+		}
+		call.arguments = append(call.arguments, delta.Function.Arguments...)
+	}
+}
+
+// ToolCalls returns the assembled calls in the order they were first seen.
+func (a *ToolCallAccumulator) ToolCalls() []llm.ToolCall {
+	if len(a.calls) == 0 {
+		return nil
+	}
+	result := make([]llm.ToolCall, 0, len(a.calls))
+	for _, call := range a.calls {
+		result = append(result, llm.ToolCall{ID: call.id, Name: call.name, Input: ToolArguments(string(call.arguments))})
+	}
+	return result
+}
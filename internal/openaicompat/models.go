@@ -0,0 +1,29 @@
+package openaicompat
+
+import (
+	"context"
+	"time"
+
+	sdk "github.com/openai/openai-go"
+
+	"github.com/ulgerang/llm-module/llm"
+)
+
+// ListModels lists the models served by an OpenAI-compatible API from its /models
+// endpoint, filling token limits from the model registry.
+func ListModels(ctx context.Context, client sdk.Client, provider string) ([]llm.Model, error) {
+	var models []llm.Model
+	pager := client.Models.ListAutoPaging(ctx)
+	for pager.Next() {
+		model := pager.Current()
+		listed := llm.Model{ID: model.ID, OwnedBy: model.OwnedBy}
+		if model.Created > 0 {
+			listed.Created = time.Unix(model.Created, 0)
+		}
+		models = append(models, llm.FillModelLimits(listed))
+	}
+	if err := pager.Err(); err != nil {
+		return nil, Error(provider, err)
+	}
+	return models, nil
+}
@@ -0,0 +1,110 @@
+package openaicompat
+
+import (
+	"strconv"
+
+	sdk "github.com/openai/openai-go"
+
+	"github.com/ulgerang/llm-module/llm"
+)
+
+// Sampling lists the optional sampling parameters an OpenAI-compatible API accepts.
+// Every one of them accepts stop sequences.
+type Sampling struct {
+	Seed       bool
+	Penalties  bool
+	LogitBias  bool
+	Logprobs   bool
+	Candidates bool
+}
+
+// Stream returns the parameters s accepts for streaming calls, which carry the text
+// of a single candidate without log probabilities.
+func (s Sampling) Stream() Sampling {
+	s.Logprobs = false
+	s.Candidates = false
+	return s
+}
+
+// ApplySampling sets the stop sequences, seed, penalties, logit bias, logprobs and
+// candidate count of options on req. Parameters the API does not accept are reported
+// through CheckUnsupported, as is a Gemini cached content name, which no
+// OpenAI-compatible API accepts.
+func ApplySampling(req *sdk.ChatCompletionNewParams, options *llm.GenerationOptions, provider string, supported Sampling, warn func(string)) error {
+	unsupported := []string{"CachedContent"}
+	if len(options.StopSequences) > 0 {
+		req.Stop = sdk.ChatCompletionNewParamsStopUnion{OfChatCompletionNewsStopArray: options.StopSequences}
+	}
+
+	if supported.Seed {
+		if options.Seed != nil {
+			req.Seed = sdk.Int(*options.Seed)
+		}
+	} else {
+		unsupported = append(unsupported, "Seed")
+	}
+
+	if supported.Penalties {
+		if options.PresencePenalty != nil {
+			req.PresencePenalty = sdk.Float(float64(*options.PresencePenalty))
+		}
+		if options.FrequencyPenalty != nil {
+			req.FrequencyPenalty = sdk.Float(float64(*options.FrequencyPenalty))
+		}
+	} else {
+		unsupported = append(unsupported, "PresencePenalty", "FrequencyPenalty")
+	}
+
+	if supported.LogitBias {
+		if len(options.LogitBias) > 0 {
+			req.LogitBias = make(map[string]int64, len(options.LogitBias))
+			for token, bias := range options.LogitBias {
+				req.LogitBias[strconv.Itoa(token)] = int64(bias)
+			}
+		}
+	} else {
+		unsupported = append(unsupported, "LogitBias")
+	}
+
+	if supported.Logprobs {
+		if options.Logprobs != nil {
+			req.Logprobs = sdk.Bool(true)
+			if *options.Logprobs > 0 {
+				req.TopLogprobs = sdk.Int(int64(*options.Logprobs))
+			}
+		}
+	} else {
+		unsupported = append(unsupported, "Logprobs")
+	}
+
+	if supported.Candidates {
+		if options.CandidateCount != nil {
+			req.N = sdk.Int(int64(*options.CandidateCount))
+		}
+	} else {
+		unsupported = append(unsupported, "CandidateCount")
+	}
+
+	return options.CheckUnsupported(provider, warn, unsupported...)
+}
+
+// Completion converts every choice into an llm.Candidate, or returns nil unless the
+// options ask for logprobs or several candidates.
+func Completion(options *llm.GenerationOptions, choices []sdk.ChatCompletionChoice) *llm.Completion {
+	if options.Logprobs == nil && options.CandidateCount == nil {
+		return nil
+	}
+	candidates := make([]llm.Candidate, 0, len(choices))
+	for _, choice := range choices {
+		candidate := llm.Candidate{Text: choice.Message.Content, FinishReason: choice.FinishReason}
+		for _, token := range choice.Logprobs.Content {
+			logprob := llm.TokenLogprob{Token: token.Token, Logprob: token.Logprob}
+			for _, top := range token.TopLogprobs {
+				logprob.TopLogprobs = append(logprob.TopLogprobs, llm.TopLogprob{Token: top.Token, Logprob: top.Logprob})
+			}
+			candidate.Logprobs = append(candidate.Logprobs, logprob)
+		}
+		candidates = append(candidates, candidate)
+	}
+	return &llm.Completion{Candidates: candidates}
+}
@@ -0,0 +1,98 @@
+// Package openaicompat holds request and response conversions shared by the providers
+// that talk to OpenAI-compatible chat completion APIs through the openai-go SDK.
+package openaicompat
+
+import (
+	"encoding/json"
+	"fmt"
+
+	sdk "github.com/openai/openai-go"
+
+	"github.com/ulgerang/llm-module/llm"
+	"github.com/ulgerang/llm-module/utils"
+)
+
+// ConvertTools converts llm tools into OpenAI function tool definitions.
+// Tools without an InputSchema are declared with an empty object schema.
+func ConvertTools(tools []*llm.Tool) ([]sdk.ChatCompletionToolParam, error) {
+	params := make([]sdk.ChatCompletionToolParam, 0, len(tools))
+	for _, tool := range tools {
+		schemaMap := map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+		if tool.InputSchema != nil {
+			converted, err := llm.ConvertSchemaToMap(tool.InputSchema)
+			if err != nil {
+				return nil, fmt.Errorf("failed to convert schema for tool '%s': %w", tool.Name, err)
+			}
+			schemaMap = converted
+		}
+
+		params = append(params, sdk.ChatCompletionToolParam{
+			Function: sdk.FunctionDefinitionParam{
+				Name:        tool.Name,
+				Description: sdk.String(tool.Description),
+				Parameters:  schemaMap,
+			},
+		})
+	}
+	return params, nil
+}
+
+// ToolChoice converts an llm.ToolChoice into the OpenAI tool_choice union.
+func ToolChoice(choice *llm.ToolChoice) sdk.ChatCompletionToolChoiceOptionUnionParam {
+	if choice == nil {
+		return sdk.ChatCompletionToolChoiceOptionUnionParam{}
+	}
+	if choice.Mode == llm.ToolChoiceModeSpecific {
+		return sdk.ChatCompletionToolChoiceOptionUnionParam{
+			OfChatCompletionNamedToolChoice: &sdk.ChatCompletionNamedToolChoiceParam{
+				Function: sdk.ChatCompletionNamedToolChoiceFunctionParam{Name: choice.Name},
+			},
+		}
+	}
+	return sdk.ChatCompletionToolChoiceOptionUnionParam{OfAuto: sdk.String(string(choice.Mode))}
+}
+
+// ApplyTools sets tools, tool_choice and parallel_tool_calls on a chat completion request.
+func ApplyTools(params *sdk.ChatCompletionNewParams, options *llm.GenerationOptions) error {
+	if err := llm.ValidateToolChoice(options); err != nil {
+		return err
+	}
+
+	tools, err := ConvertTools(options.Tools)
+	if err != nil {
+		return err
+	}
+	params.Tools = tools
+	if options.ToolChoice != nil {
+		params.ToolChoice = ToolChoice(options.ToolChoice)
+	}
+	if options.ParallelToolCalls != nil {
+		params.ParallelToolCalls = sdk.Bool(*options.ParallelToolCalls)
+	}
+	return nil
+}
+
+// ToolCalls converts the tool calls of a chat completion message into llm.ToolCall values.
+func ToolCalls(calls []sdk.ChatCompletionMessageToolCall) []llm.ToolCall {
+	result := make([]llm.ToolCall, 0, len(calls))
+	for _, call := range calls {
+		result = append(result, llm.ToolCall{ID: call.ID, Name: call.Function.Name, Input: ToolArguments(call.Function.Arguments)})
+	}
+	return result
+}
+
+// ToolArguments turns a model-generated arguments string into raw JSON, repairing it
+// when the model produced malformed or truncated JSON.
+func ToolArguments(arguments string) json.RawMessage {
+	if arguments == "" {
+		return json.RawMessage("{}")
+	}
+	if json.Valid([]byte(arguments)) {
+		return json.RawMessage(arguments)
+	}
+	if repaired, _, err := utils.RepairJSON(arguments); err == nil {
+		return json.RawMessage(repaired)
+	}
+	quoted, _ := json.Marshal(arguments)
+	return json.RawMessage(quoted)
+}
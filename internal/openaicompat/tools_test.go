@@ -0,0 +1,69 @@
+package openaicompat
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	sdk "github.com/openai/openai-go"
+
+	"github.com/ulgerang/llm-module/llm"
+)
+
+func TestApplyToolsWireFormat(t *testing.T) {
+	tools := []*llm.Tool{{
+		Name:        "extract",
+		Description: "Extract fields",
+		InputSchema: &llm.SchemaProperty{Type: "object", Properties: map[string]*llm.SchemaProperty{"name": {Type: "string"}}},
+	}}
+
+	tests := []struct {
+		choice llm.ToolChoice
+		want   string
+	}{
+		{llm.ToolChoiceAuto, `"tool_choice":"auto"`},
+		{llm.ToolChoiceNone, `"tool_choice":"none"`},
+		{llm.ToolChoiceRequired, `"tool_choice":"required"`},
+		{llm.ToolChoiceFor("extract"), `"tool_choice":{"function":{"name":"extract"},"type":"function"}`},
+	}
+
+	for _, tt := range tests {
+		options := &llm.GenerationOptions{}
+		llm.WithTools(tools)(options)
+		llm.WithToolChoice(tt.choice)(options)
+		llm.WithParallelToolCalls(false)(options)
+
+		params := sdk.ChatCompletionNewParams{Model: "test", Messages: []sdk.ChatCompletionMessageParamUnion{sdk.UserMessage("hi")}}
+		if err := ApplyTools(&params, options); err != nil {
+			t.Fatalf("ApplyTools failed: %v", err)
+		}
+		body, err := json.Marshal(params)
+		if err != nil {
+			t.Fatalf("marshal failed: %v", err)
+		}
+		for _, want := range []string{tt.want, `"parallel_tool_calls":false`, `"name":"extract"`} {
+			if !strings.Contains(string(body), want) {
+				t.Errorf("expected %s in %s", want, body)
+			}
+		}
+	}
+}
+
+func TestApplyToolsRejectsUnknownTool(t *testing.T) {
+	options := &llm.GenerationOptions{}
+	llm.WithTools([]*llm.Tool{{Name: "a"}})(options)
+	llm.WithToolChoice(llm.ToolChoiceFor("b"))(options)
+
+	if err := ApplyTools(&sdk.ChatCompletionNewParams{}, options); err == nil {
+		t.Fatal("expected error for unknown tool")
+	}
+}
+
+func TestToolArgumentsRepairsMalformedJSON(t *testing.T) {
+	if got := string(ToolArguments(`{"a": 1,`)); got != `{"a": 1}` {
+		t.Errorf("unexpected repaired arguments: %s", got)
+	}
+	if got := string(ToolArguments("")); got != "{}" {
+		t.Errorf("unexpected empty arguments: %s", got)
+	}
+}
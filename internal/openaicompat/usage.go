@@ -0,0 +1,46 @@
+package openaicompat
+
+import (
+	"strconv"
+
+	sdk "github.com/openai/openai-go"
+
+	"github.com/ulgerang/llm-module/llm"
+)
+
+// Usage converts chat completion usage into llm.UsageInfo, including prompt cache
+// statistics. OpenAI reports cache reads in prompt_tokens_details.cached_tokens;
+// DeepSeek reports prompt_cache_hit_tokens and prompt_cache_miss_tokens instead.
+func Usage(usage sdk.CompletionUsage) *llm.UsageInfo {
+	info := &llm.UsageInfo{
+		InputTokens:  int(usage.PromptTokens),
+		OutputTokens: int(usage.CompletionTokens),
+	}
+
+	hit, hasHit := extraInt(usage, "prompt_cache_hit_tokens")
+	miss, hasMiss := extraInt(usage, "prompt_cache_miss_tokens")
+	if hasHit || hasMiss {
+		info.CacheHitTokens = hit
+		info.CacheMissTokens = miss
+		return info
+	}
+
+	if usage.PromptTokensDetails.JSON.CachedTokens.IsPresent() {
+		info.CacheHitTokens = int(usage.PromptTokensDetails.CachedTokens)
+		info.CacheMissTokens = info.InputTokens - info.CacheHitTokens
+	}
+	return info
+}
+
+func extraInt(usage sdk.CompletionUsage, name string) (int, bool) {
+	// Unknown fields are kept raw; the SDK does not mark them as present.
+	field, ok := usage.JSON.ExtraFields[name]
+	if !ok {
+		return 0, false
+	}
+	value, err := strconv.Atoi(field.Raw())
+	if err != nil {
+		return 0, false
+	}
+	return value, true
+}
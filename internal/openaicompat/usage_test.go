@@ -0,0 +1,46 @@
+package openaicompat
+
+import (
+	"encoding/json"
+	"testing"
+
+	sdk "github.com/openai/openai-go"
+
+	"github.com/ulgerang/llm-module/llm"
+)
+
+func TestUsageCacheReporting(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want llm.UsageInfo
+	}{
+		{
+			name: "openai cached tokens",
+			raw:  `{"prompt_tokens":1200,"completion_tokens":20,"total_tokens":1220,"prompt_tokens_details":{"cached_tokens":1024}}`,
+			want: llm.UsageInfo{InputTokens: 1200, OutputTokens: 20, CacheHitTokens: 1024, CacheMissTokens: 176},
+		},
+		{
+			name: "deepseek hit and miss",
+			raw:  `{"prompt_tokens":300,"completion_tokens":7,"total_tokens":307,"prompt_cache_hit_tokens":256,"prompt_cache_miss_tokens":44}`,
+			want: llm.UsageInfo{InputTokens: 300, OutputTokens: 7, CacheHitTokens: 256, CacheMissTokens: 44},
+		},
+		{
+			name: "no cache stats",
+			raw:  `{"prompt_tokens":5,"completion_tokens":1,"total_tokens":6}`,
+			want: llm.UsageInfo{InputTokens: 5, OutputTokens: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var usage sdk.CompletionUsage
+			if err := json.Unmarshal([]byte(tt.raw), &usage); err != nil {
+				t.Fatalf("unmarshal: %v", err)
+			}
+			if got := Usage(usage); *got != tt.want {
+				t.Fatalf("got %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package llm
+
+import "fmt"
+
+// CacheTTL selects how long a provider keeps a prompt cache entry.
+type CacheTTL string
+
+const (
+	// CacheTTLDefault uses the provider's default lifetime.
+	CacheTTLDefault CacheTTL = ""
+	// CacheTTL5Minutes keeps entries for five minutes, refreshed on every hit.
+	CacheTTL5Minutes CacheTTL = "5m"
+	// CacheTTL1Hour keeps entries for an hour at a higher write cost.
+	CacheTTL1Hour CacheTTL = "1h"
+)
+
+// Validate reports whether the TTL is one of the supported values.
+func (ttl CacheTTL) Validate() error {
+	switch ttl {
+	case CacheTTLDefault, CacheTTL5Minutes, CacheTTL1Hour:
+		return nil
+	}
+	return fmt.Errorf("unsupported cache TTL '%s'", ttl)
+}
+
+// WithCacheTTL sets the lifetime of the cache breakpoints placed for this request.
+// Only Claude supports choosing a TTL.
+func WithCacheTTL(ttl CacheTTL) GenerationOption {
+	return func(options *GenerationOptions) {
+		options.CacheTTL = ttl
+	}
+}
+
+// WithCacheKey sets a key that routes requests sharing a long prefix to the same
+// cache, improving hit rates on OpenAI (prompt_cache_key).
+func WithCacheKey(key string) GenerationOption {
+	return func(options *GenerationOptions) {
+		options.CacheKey = key
+	}
+}
+
+// WithCachedContent references a provider-managed cached context by resource name,
+// such as one created with the Gemini provider's CreateCache.
+func WithCachedContent(name string) GenerationOption {
+	return func(options *GenerationOptions) {
+		options.CachedContent = name
+	}
+}
@@ -0,0 +1,166 @@
+package llm
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// StructuredOutputMode is how a provider honors WithResponseSchema.
+type StructuredOutputMode int
+
+const (
+	// StructuredOutputNone means the schema is not supported.
+	StructuredOutputNone StructuredOutputMode = iota
+	// StructuredOutputPrompted means the schema is added to the prompt and JSON is
+	// extracted from the reply, which may not match the schema.
+	StructuredOutputPrompted
+	// StructuredOutputNative means the API constrains the reply to the schema.
+	StructuredOutputNative
+)
+
+func (m StructuredOutputMode) String() string {
+	switch m {
+	case StructuredOutputPrompted:
+		return "prompted"
+	case StructuredOutputNative:
+		return "native"
+	default:
+		return "none"
+	}
+}
+
+// Capabilities describes what a provider and its model support. Model-dependent
+// fields come from the model registry and are zero for unknown models.
+type Capabilities struct {
+	Streaming bool
+	// Tools reports tool calling for GenerateText, and StreamingTools tool calls on
+	// stream chunks.
+	Tools          bool
+	StreamingTools bool
+	ParallelTools  bool
+	// StructuredOutput is how WithResponseSchema is honored.
+	StructuredOutput StructuredOutputMode
+	// Caching reports prompt caching, explicit or automatic.
+	Caching    bool
+	Logprobs   bool
+	Candidates bool
+	// Vision and Reasoning report whether the model accepts images and thinks before
+	// answering.
+	Vision    bool
+	Reasoning bool
+	// ContextWindow and MaxOutputTokens are token limits, 0 when unknown.
+	ContextWindow   int
+	MaxOutputTokens int
+}
+
+// CapabilityReporter is implemented by providers that describe their capabilities.
+// Use CapabilitiesOf to query providers returned by Wrap.
+type CapabilityReporter interface {
+	Capabilities() Capabilities
+}
+
+// CapabilitiesOf returns the capabilities of provider, looking through Wrap. The
+// boolean is false when the provider does not report them.
+func CapabilitiesOf(provider Provider) (Capabilities, bool) {
+	reporter, ok := As[CapabilityReporter](provider)
+	if !ok {
+		return Capabilities{}, false
+	}
+	return reporter.Capabilities(), true
+}
+
+// ModelCapabilities returns the capabilities of a provider with the model-dependent
+// fields filled in from the model registry.
+func ModelCapabilities(provider Capabilities, model string) Capabilities {
+	if info, ok := LookupModel(model); ok {
+		provider.Vision = info.Vision
+		provider.Reasoning = info.Reasoning
+		provider.ContextWindow = info.ContextWindow
+		provider.MaxOutputTokens = info.MaxOutputTokens
+	}
+	return provider
+}
+
+// ModelInfo holds the model-dependent capabilities in the model registry.
+type ModelInfo struct {
+	Vision          bool
+	Reasoning       bool
+	ContextWindow   int
+	MaxOutputTokens int
+}
+
+var (
+	modelsMu sync.RWMutex
+	models   = map[string]ModelInfo{
+		"gpt-3.5-turbo": {ContextWindow: 16385, MaxOutputTokens: 4096},
+		"gpt-4":         {ContextWindow: 8192, MaxOutputTokens: 8192},
+		"gpt-4-turbo":   {Vision: true, ContextWindow: 128000, MaxOutputTokens: 4096},
+		"gpt-4o":        {Vision: true, ContextWindow: 128000, MaxOutputTokens: 16384},
+		"gpt-4.1":       {Vision: true, ContextWindow: 1047576, MaxOutputTokens: 32768},
+		"gpt-5":         {Vision: true, Reasoning: true, ContextWindow: 400000, MaxOutputTokens: 128000},
+		"o1":            {Vision: true, Reasoning: true, ContextWindow: 200000, MaxOutputTokens: 100000},
+		"o1-mini":       {Reasoning: true, ContextWindow: 128000, MaxOutputTokens: 65536},
+		"o3":            {Vision: true, Reasoning: true, ContextWindow: 200000, MaxOutputTokens: 100000},
+		"o4-mini":       {Vision: true, Reasoning: true, ContextWindow: 200000, MaxOutputTokens: 100000},
+
+		"claude-3-haiku":    {Vision: true, ContextWindow: 200000, MaxOutputTokens: 4096},
+		"claude-3-opus":     {Vision: true, ContextWindow: 200000, MaxOutputTokens: 4096},
+		"claude-3-5-haiku":  {ContextWindow: 200000, MaxOutputTokens: 8192},
+		"claude-3-5-sonnet": {Vision: true, ContextWindow: 200000, MaxOutputTokens: 8192},
+		"claude-3-7-sonnet": {Vision: true, Reasoning: true, ContextWindow: 200000, MaxOutputTokens: 64000},
+		"claude-sonnet-4":   {Vision: true, Reasoning: true, ContextWindow: 200000, MaxOutputTokens: 64000},
+		"claude-opus-4":     {Vision: true, Reasoning: true, ContextWindow: 200000, MaxOutputTokens: 32000},
+
+		"gemini-1.5-flash": {Vision: true, ContextWindow: 1048576, MaxOutputTokens: 8192},
+		"gemini-1.5-pro":   {Vision: true, ContextWindow: 2097152, MaxOutputTokens: 8192},
+		"gemini-2.0-flash": {Vision: true, ContextWindow: 1048576, MaxOutputTokens: 8192},
+		"gemini-2.5-flash": {Vision: true, Reasoning: true, ContextWindow: 1048576, MaxOutputTokens: 65536},
+		"gemini-2.5-pro":   {Vision: true, Reasoning: true, ContextWindow: 1048576, MaxOutputTokens: 65536},
+
+		"deepseek-chat":     {ContextWindow: 128000, MaxOutputTokens: 8192},
+		"deepseek-reasoner": {Reasoning: true, ContextWindow: 128000, MaxOutputTokens: 65536},
+		"grok-3":            {ContextWindow: 131072},
+		"grok-4":            {Vision: true, Reasoning: true, ContextWindow: 256000},
+		"llama-3.3-70b":     {ContextWindow: 131072, MaxOutputTokens: 32768},
+		"glm-4.5":           {Reasoning: true, ContextWindow: 131072, MaxOutputTokens: 98304},
+		"glm-4.6":           {Reasoning: true, ContextWindow: 200000, MaxOutputTokens: 128000},
+	}
+)
+
+// RegisterModel adds or replaces the capabilities of the models whose name starts with
+// prefix, such as "gpt-4o" or a fine-tuned model's full name.
+func RegisterModel(prefix string, info ModelInfo) {
+	modelsMu.Lock()
+	defer modelsMu.Unlock()
+	models[prefix] = info
+}
+
+// LookupModel returns the registered capabilities of the longest prefix of model.
+// A vendor namespace such as OpenRouter's "openai/" is ignored when the full name is
+// not registered.
+func LookupModel(model string) (ModelInfo, bool) {
+	modelsMu.RLock()
+	defer modelsMu.RUnlock()
+	if info, ok := lookupModel(model); ok {
+		return info, true
+	}
+	if i := strings.LastIndex(model, "/"); i >= 0 {
+		return lookupModel(model[i+1:])
+	}
+	return ModelInfo{}, false
+}
+
+func lookupModel(model string) (ModelInfo, bool) {
+	prefixes := make([]string, 0, len(models))
+	for prefix := range models {
+		if strings.HasPrefix(model, prefix) {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	if len(prefixes) == 0 {
+		return ModelInfo{}, false
+	}
+	sort.Slice(prefixes, func(i, j int) bool { return len(prefixes[i]) > len(prefixes[j]) })
+	return models[prefixes[0]], true
+}
@@ -0,0 +1,39 @@
+package llm
+
+import "testing"
+
+func TestModelCapabilitiesUseLongestPrefix(t *testing.T) {
+	base := Capabilities{Streaming: true, Tools: true}
+
+	caps := ModelCapabilities(base, "gpt-4o-mini-2024-07-18")
+	if !caps.Streaming || !caps.Vision || caps.ContextWindow != 128000 {
+		t.Errorf("gpt-4o-mini capabilities = %+v", caps)
+	}
+	if caps := ModelCapabilities(base, "openai/o1-mini"); !caps.Reasoning || caps.Vision {
+		t.Errorf("openai/o1-mini capabilities = %+v", caps)
+	}
+	if caps := ModelCapabilities(base, "unknown-model"); caps != base {
+		t.Errorf("unknown model capabilities = %+v", caps)
+	}
+
+	RegisterModel("ft:gpt-4o", ModelInfo{ContextWindow: 64000})
+	if caps := ModelCapabilities(base, "ft:gpt-4o:acme"); caps.ContextWindow != 64000 {
+		t.Errorf("registered model capabilities = %+v", caps)
+	}
+}
+
+type reportingProvider struct{ stubProvider }
+
+func (p *reportingProvider) Capabilities() Capabilities {
+	return Capabilities{Streaming: true, StructuredOutput: StructuredOutputNative}
+}
+
+func TestCapabilitiesOfLooksThroughWrap(t *testing.T) {
+	caps, ok := CapabilitiesOf(Wrap(Wrap(&reportingProvider{}), Defaults(WithStrict())))
+	if !ok || caps.StructuredOutput != StructuredOutputNative {
+		t.Errorf("CapabilitiesOf() = %+v, %v", caps, ok)
+	}
+	if _, ok := CapabilitiesOf(&stubProvider{}); ok {
+		t.Error("a provider without Capabilities should not report any")
+	}
+}
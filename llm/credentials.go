@@ -0,0 +1,51 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+// CredentialSource supplies the API key of a provider. It is consulted for every HTTP
+// request, so keys can be rotated without recreating the provider. The credential
+// package has static, environment, file, command and pool sources.
+type CredentialSource interface {
+	Credential(ctx context.Context) (string, error)
+}
+
+// CredentialReporter is implemented by credential sources that want to know the HTTP
+// status each credential received, for example to stop using rate-limited keys.
+type CredentialReporter interface {
+	Report(credential string, statusCode int)
+}
+
+// WithCredentials makes the provider ask source for its API key on every request. The
+// key replaces the one passed to the constructor. Gemini on Vertex AI authenticates
+// with Google Cloud credentials and ignores it.
+func WithCredentials(source CredentialSource) ProviderOption {
+	return func(config *ProviderConfig) {
+		config.Credentials = source
+	}
+}
+
+// ResolveAPIKey returns the key a provider is created with: apiKey, else the value of
+// the environment variable envVar, else the current credential of c.Credentials. It
+// returns "" when none is set.
+func (c *ProviderConfig) ResolveAPIKey(apiKey, envVar string) (string, error) {
+	if apiKey != "" {
+		return apiKey, nil
+	}
+	if envVar != "" {
+		if key := os.Getenv(envVar); key != "" {
+			return key, nil
+		}
+	}
+	if c.Credentials == nil {
+		return "", nil
+	}
+	key, err := c.Credentials.Credential(context.Background())
+	if err != nil {
+		return "", fmt.Errorf("failed to get credential: %w", err)
+	}
+	return key, nil
+}
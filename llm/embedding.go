@@ -0,0 +1,13 @@
+package llm
+
+import "context"
+
+// Embedder turns texts into embedding vectors, one per input text and in input order.
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// EmbedderOf returns the embedder of provider, looking through Wrap.
+func EmbedderOf(provider Provider) (Embedder, bool) {
+	return As[Embedder](provider)
+}
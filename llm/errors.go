@@ -0,0 +1,196 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/ulgerang/llm-module/redact"
+)
+
+// ErrUnsupportedOption is matched by errors.Is for any *UnsupportedOptionError.
+var ErrUnsupportedOption = errors.New("unsupported option")
+
+// UnsupportedOptionError reports a generation option that a provider cannot honour.
+type UnsupportedOptionError struct {
+	Provider string
+	Option   string
+	Reason   string
+}
+
+// NewUnsupportedOptionError creates an UnsupportedOptionError.
+func NewUnsupportedOptionError(provider, option, reason string) *UnsupportedOptionError {
+	return &UnsupportedOptionError{Provider: provider, Option: option, Reason: reason}
+}
+
+// CheckUnsupported handles options the provider cannot honor. names are
+// GenerationOptions field names, such as "Seed"; those set in o are unsupported. In
+// strict mode the first one is returned as an *UnsupportedOptionError. Otherwise warn
+// is called for each and nil is returned, so the options are ignored.
+func (o *GenerationOptions) CheckUnsupported(provider string, warn func(string), names ...string) error {
+	fields := reflect.ValueOf(o).Elem()
+	for _, name := range names {
+		field := fields.FieldByName(name)
+		if !field.IsValid() || field.IsZero() {
+			continue
+		}
+		if err := o.Unsupported(provider, name, "", warn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Unsupported reports that the provider cannot honor an option that is set. In strict
+// mode it returns an *UnsupportedOptionError; otherwise it calls warn and returns nil,
+// so the option is ignored.
+func (o *GenerationOptions) Unsupported(provider, option, reason string, warn func(string)) error {
+	err := NewUnsupportedOptionError(provider, option, reason)
+	if o.Strict {
+		return err
+	}
+	warn(err.Error() + ", ignoring it")
+	return nil
+}
+
+func (e *UnsupportedOptionError) Error() string {
+	if e.Reason == "" {
+		return fmt.Sprintf("%s: option %s is not supported", e.Provider, e.Option)
+	}
+	return fmt.Sprintf("%s: option %s is not supported: %s", e.Provider, e.Option, e.Reason)
+}
+
+// Is reports whether target is ErrUnsupportedOption.
+func (e *UnsupportedOptionError) Is(target error) bool {
+	return target == ErrUnsupportedOption
+}
+
+// ErrStreamStalled is matched by errors.Is for any *StreamStallError.
+var ErrStreamStalled = errors.New("stream stalled")
+
+// StreamStallError reports a streamed response that sent no data for longer than the
+// configured stream inactivity timeout.
+type StreamStallError struct {
+	Timeout time.Duration
+}
+
+func (e *StreamStallError) Error() string {
+	return fmt.Sprintf("stream stalled: no data received for %s", e.Timeout)
+}
+
+// Is reports whether target is ErrStreamStalled.
+func (e *StreamStallError) Is(target error) bool {
+	return target == ErrStreamStalled
+}
+
+// APIError is an error response from a provider's API.
+type APIError struct {
+	Provider   string
+	StatusCode int
+	// Type is the provider's error type or code, when it reports one.
+	Type    string
+	Message string
+	// Err is the underlying SDK error, if any.
+	Err error
+}
+
+// Error formats the error with credentials and registered secrets redacted, since
+// providers may echo request headers or keys back in their error payloads.
+func (e *APIError) Error() string {
+	detail := e.Message
+	if e.Type != "" {
+		detail = fmt.Sprintf("%s: %s", e.Type, e.Message)
+	}
+	if e.StatusCode == 0 {
+		return redact.String(fmt.Sprintf("%s API error: %s", e.Provider, detail))
+	}
+	return redact.String(fmt.Sprintf("%s API error (status %d): %s", e.Provider, e.StatusCode, detail))
+}
+
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorClass is a provider-independent category of a failed call.
+type ErrorClass string
+
+const (
+	ErrorClassCanceled       ErrorClass = "canceled"
+	ErrorClassTimeout        ErrorClass = "timeout"
+	ErrorClassNetwork        ErrorClass = "network"
+	ErrorClassRateLimit      ErrorClass = "rate_limit"
+	ErrorClassAuthentication ErrorClass = "authentication"
+	ErrorClassPermission     ErrorClass = "permission"
+	ErrorClassNotFound       ErrorClass = "not_found"
+	ErrorClassInvalidRequest ErrorClass = "invalid_request"
+	ErrorClassUnsupported    ErrorClass = "unsupported_option"
+	ErrorClassOverloaded     ErrorClass = "overloaded"
+	ErrorClassServer         ErrorClass = "server"
+	ErrorClassUnknown        ErrorClass = "unknown"
+)
+
+// Retryable reports whether a call that failed with this class may succeed if retried.
+func (c ErrorClass) Retryable() bool {
+	switch c {
+	case ErrorClassTimeout, ErrorClassNetwork, ErrorClassRateLimit, ErrorClassOverloaded, ErrorClassServer:
+		return true
+	}
+	return false
+}
+
+// ClassifyError returns the class of err, or an empty class for a nil error.
+func ClassifyError(err error) ErrorClass {
+	if err == nil {
+		return ""
+	}
+	if errors.Is(err, context.Canceled) {
+		return ErrorClassCanceled
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrStreamStalled) {
+		return ErrorClassTimeout
+	}
+	if errors.Is(err, ErrUnsupportedOption) {
+		return ErrorClassUnsupported
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode != 0 {
+		return classifyStatus(apiErr.StatusCode)
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return ErrorClassTimeout
+		}
+		return ErrorClassNetwork
+	}
+	return ErrorClassUnknown
+}
+
+func classifyStatus(status int) ErrorClass {
+	switch {
+	case status == http.StatusTooManyRequests:
+		return ErrorClassRateLimit
+	case status == http.StatusUnauthorized:
+		return ErrorClassAuthentication
+	case status == http.StatusForbidden:
+		return ErrorClassPermission
+	case status == http.StatusNotFound:
+		return ErrorClassNotFound
+	case status == http.StatusRequestTimeout || status == http.StatusGatewayTimeout:
+		return ErrorClassTimeout
+	// 529 is Anthropic's overloaded status.
+	case status == http.StatusServiceUnavailable || status == 529:
+		return ErrorClassOverloaded
+	case status >= 500:
+		return ErrorClassServer
+	case status >= 400:
+		return ErrorClassInvalidRequest
+	}
+	return ErrorClassUnknown
+}
@@ -0,0 +1,76 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want ErrorClass
+	}{
+		{nil, ""},
+		{context.Canceled, ErrorClassCanceled},
+		{fmt.Errorf("call failed: %w", context.DeadlineExceeded), ErrorClassTimeout},
+		{NewUnsupportedOptionError("test", "Tools", ""), ErrorClassUnsupported},
+		{&APIError{Provider: "test", StatusCode: 429}, ErrorClassRateLimit},
+		{&APIError{Provider: "test", StatusCode: 401}, ErrorClassAuthentication},
+		{&APIError{Provider: "test", StatusCode: 400}, ErrorClassInvalidRequest},
+		{&APIError{Provider: "test", StatusCode: 529}, ErrorClassOverloaded},
+		{fmt.Errorf("wrapped: %w", &APIError{Provider: "test", StatusCode: 502}), ErrorClassServer},
+		{errors.New("something else"), ErrorClassUnknown},
+	}
+	for _, tt := range tests {
+		if got := ClassifyError(tt.err); got != tt.want {
+			t.Errorf("ClassifyError(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestAPIErrorUnwrapsCause(t *testing.T) {
+	cause := errors.New("sdk error")
+	err := &APIError{Provider: "test", StatusCode: 500, Type: "server_error", Message: "boom", Err: cause}
+	if !errors.Is(err, cause) {
+		t.Error("APIError should unwrap to its cause")
+	}
+	if got := err.Error(); got != "test API error (status 500): server_error: boom" {
+		t.Errorf("Error() = %q", got)
+	}
+	if !ErrorClassServer.Retryable() || ErrorClassInvalidRequest.Retryable() {
+		t.Error("unexpected Retryable result")
+	}
+}
+
+func TestAPIErrorRedactsKeys(t *testing.T) {
+	const key = "sk-proj-abcdefghijklmnopqrstuvwxyz012345"
+	err := &APIError{Provider: "openai", StatusCode: 401, Type: "invalid_api_key", Message: "Incorrect API key provided: " + key}
+	if got := err.Error(); strings.Contains(got, key) || !strings.Contains(got, "Incorrect API key provided") {
+		t.Errorf("Error() = %q", got)
+	}
+}
+
+func TestCheckUnsupportedWarnsOrFailsInStrictMode(t *testing.T) {
+	options := &GenerationOptions{}
+	WithSeed(7)(options)
+	WithStopSequences("END")(options)
+
+	var warnings []string
+	warn := func(msg string) { warnings = append(warnings, msg) }
+	if err := options.CheckUnsupported("test", warn, "Seed", "LogitBias"); err != nil {
+		t.Fatalf("CheckUnsupported() error = %v", err)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "option Seed is not supported") {
+		t.Errorf("warnings = %q", warnings)
+	}
+
+	WithStrict()(options)
+	err := options.CheckUnsupported("test", warn, "LogitBias", "Seed")
+	var unsupported *UnsupportedOptionError
+	if !errors.As(err, &unsupported) || unsupported.Option != "Seed" || !errors.Is(err, ErrUnsupportedOption) {
+		t.Errorf("CheckUnsupported() error = %v", err)
+	}
+}
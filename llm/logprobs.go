@@ -0,0 +1,125 @@
+package llm
+
+import (
+	"errors"
+	"math"
+	"strings"
+)
+
+// Completion is the structured result of a request made with WithLogprobs or
+// WithCandidates. The text returned by GenerateText is that of the first candidate.
+type Completion struct {
+	Candidates []Candidate
+}
+
+// Candidate is one of the responses generated for a request.
+type Candidate struct {
+	Text         string
+	FinishReason string
+	// Logprobs holds the log probability of every generated token when WithLogprobs
+	// is used.
+	Logprobs []TokenLogprob
+}
+
+// TokenLogprob is the natural log probability of a generated token, with the most
+// likely alternatives at its position.
+type TokenLogprob struct {
+	Token       string
+	Logprob     float64
+	TopLogprobs []TopLogprob
+}
+
+// TopLogprob is an alternative token and its log probability.
+type TopLogprob struct {
+	Token   string
+	Logprob float64
+}
+
+// WithLogprobs returns the log probability of each generated token, and of the topN
+// most likely alternatives at each position, in UsageInfo.Completion. topN may be 0.
+// OpenAI, Groq, DeepSeek, OpenRouter and Gemini support it, for non-streaming calls.
+func WithLogprobs(topN int) GenerationOption {
+	return func(options *GenerationOptions) {
+		options.Logprobs = ValuePtr(int32(topN))
+	}
+}
+
+// WithCandidates generates n responses in one call and returns all of them in
+// UsageInfo.Completion, for example for best-of sampling. OpenAI, Groq, DeepSeek and
+// Gemini support it, for non-streaming calls.
+func WithCandidates(n int) GenerationOption {
+	return func(options *GenerationOptions) {
+		options.CandidateCount = ValuePtr(int32(n))
+	}
+}
+
+// ClassProbabilities estimates how likely the model was to answer with each of the
+// classes, such as the values of an enum, from the logprobs of a candidate generated
+// with WithLogprobs. It reads the alternatives of the first non-blank token, so the
+// classes must differ in their first token and topN must be large enough to cover
+// them. Tokens are matched to the start of a class ignoring case and surrounding
+// space or quotes. The probabilities are normalized over the classes.
+func ClassProbabilities(logprobs []TokenLogprob, classes []string) (map[string]float64, error) {
+	if len(classes) == 0 {
+		return nil, errors.New("no classes given")
+	}
+	var first *TokenLogprob
+	for i := range logprobs {
+		if normalizeClassToken(logprobs[i].Token) != "" {
+			first = &logprobs[i]
+			break
+		}
+	}
+	if first == nil {
+		return nil, errors.New("no token log probabilities in the response")
+	}
+
+	alternatives := first.TopLogprobs
+	if len(alternatives) == 0 {
+		alternatives = []TopLogprob{{Token: first.Token, Logprob: first.Logprob}}
+	}
+
+	probabilities := make(map[string]float64, len(classes))
+	var total float64
+	for _, alternative := range alternatives {
+		class, ok := matchClass(normalizeClassToken(alternative.Token), classes)
+		if !ok {
+			continue
+		}
+		p := math.Exp(alternative.Logprob)
+		probabilities[class] += p
+		total += p
+	}
+	if total == 0 {
+		return nil, errors.New("no alternative of the first token matches a class")
+	}
+	for _, class := range classes {
+		probabilities[class] /= total
+	}
+	return probabilities, nil
+}
+
+// matchClass returns the only class that starts with token. An exact match wins over
+// prefixes shared by several classes.
+func matchClass(token string, classes []string) (string, bool) {
+	if token == "" {
+		return "", false
+	}
+	var match string
+	matches := 0
+	for _, class := range classes {
+		normalized := strings.ToLower(class)
+		if normalized == token {
+			return class, true
+		}
+		if strings.HasPrefix(normalized, token) {
+			match = class
+			matches++
+		}
+	}
+	return match, matches == 1
+}
+
+func normalizeClassToken(token string) string {
+	return strings.ToLower(strings.Trim(token, " \t\n\"'`"))
+}
@@ -0,0 +1,34 @@
+package llm
+
+import (
+	"math"
+	"testing"
+)
+
+func TestClassProbabilities(t *testing.T) {
+	logprobs := []TokenLogprob{
+		{Token: " ", Logprob: 0},
+		{Token: "Pos", Logprob: math.Log(0.6), TopLogprobs: []TopLogprob{
+			{Token: "Pos", Logprob: math.Log(0.6)},
+			{Token: " neg", Logprob: math.Log(0.2)},
+			{Token: "\"neutral", Logprob: math.Log(0.1)},
+			{Token: "Maybe", Logprob: math.Log(0.1)},
+		}},
+		{Token: "itive", Logprob: 0},
+	}
+
+	got, err := ClassProbabilities(logprobs, []string{"positive", "negative", "neutral"})
+	if err != nil {
+		t.Fatalf("ClassProbabilities() error = %v", err)
+	}
+	want := map[string]float64{"positive": 0.6 / 0.9, "negative": 0.2 / 0.9, "neutral": 0.1 / 0.9}
+	for class, p := range want {
+		if math.Abs(got[class]-p) > 1e-9 {
+			t.Errorf("P(%s) = %g, want %g", class, got[class], p)
+		}
+	}
+
+	if _, err := ClassProbabilities(logprobs, []string{"yes", "no"}); err == nil {
+		t.Error("expected an error when no alternative matches a class")
+	}
+}
@@ -0,0 +1,69 @@
+package llm
+
+// Role identifies the author of a conversation message.
+type Role string
+
+const (
+	RoleUser      Role = "user"
+	RoleAssistant Role = "assistant"
+	RoleTool      Role = "tool"
+)
+
+// Message is a single turn of a multi-turn conversation passed with WithMessages.
+// Assistant messages may carry the tool calls the model requested; tool messages
+// carry the result of one of those calls. Cache places a prompt cache breakpoint
+// after the message on providers that support explicit breakpoints.
+type Message struct {
+	Role       Role
+	Content    string
+	ToolCalls  []ToolCall
+	ToolCallID string
+	Name       string
+	IsError    bool
+	Cache      bool
+}
+
+// UserMessage creates a user turn.
+func UserMessage(content string) Message {
+	return Message{Role: RoleUser, Content: content}
+}
+
+// AssistantMessage creates an assistant turn containing text.
+func AssistantMessage(content string) Message {
+	return Message{Role: RoleAssistant, Content: content}
+}
+
+// AssistantToolCallMessage creates an assistant turn that requested tool calls.
+func AssistantToolCallMessage(calls []ToolCall) Message {
+	return Message{Role: RoleAssistant, ToolCalls: calls}
+}
+
+// ToolResultMessage creates a tool turn from the result of a tool call.
+func ToolResultMessage(result ToolResult) Message {
+	return Message{
+		Role:       RoleTool,
+		Content:    result.Content,
+		ToolCallID: result.ToolCallID,
+		Name:       result.Name,
+		IsError:    result.IsError,
+	}
+}
+
+// WithMessages sets the conversation history that precedes the prompt. When the prompt
+// passed to GenerateText is non-empty it is appended as the final user message, so a
+// follow-up turn after tool calls can pass an empty prompt.
+func WithMessages(messages []Message) GenerationOption {
+	return func(options *GenerationOptions) {
+		options.Messages = messages
+	}
+}
+
+// ConversationMessages returns the messages followed by the prompt as a user message.
+func ConversationMessages(options *GenerationOptions, prompt string) []Message {
+	messages := make([]Message, 0, len(options.Messages)+1)
+	messages = append(messages, options.Messages...)
+	if prompt != "" {
+		messages = append(messages, UserMessage(prompt))
+	}
+	return messages
+}
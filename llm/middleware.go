@@ -0,0 +1,273 @@
+package llm
+
+import (
+	"context"
+	"reflect"
+	"strings"
+)
+
+// Request is a provider call as seen by middleware. Options holds the options of the
+// call resolved without provider defaults; changes a middleware makes to Prompt or
+// Options are passed on to the provider.
+type Request struct {
+	Prompt  string
+	Options *GenerationOptions
+	// Provider is the ProviderName of the wrapped provider.
+	Provider string
+	// Model is the model that serves the request: Options.Model when set, otherwise
+	// the wrapped provider's model.
+	Model  string
+	Stream bool
+}
+
+// Messages returns the conversation of the request, ending with the prompt.
+func (r *Request) Messages() []Message {
+	return ConversationMessages(r.Options, r.Prompt)
+}
+
+// Response is the result of a provider call as seen by middleware. For GenerateText
+// calls Text is the returned string. For streams Text is the concatenated deltas and
+// ToolCalls holds the tool calls received on chunks.
+type Response struct {
+	Text      string
+	ToolCalls []ToolCall
+	Usage     *UsageInfo
+}
+
+// EmitFunc delivers a stream chunk to the caller. It returns an error once the caller's
+// context is done.
+type EmitFunc func(chunk StreamChunk) error
+
+// Handler serves a request. For streaming requests it delivers chunks through emit as
+// they arrive and returns the accumulated Response; for other requests emit discards
+// chunks, so a handler can treat both kinds of call the same way.
+type Handler func(ctx context.Context, req *Request, emit EmitFunc) (*Response, error)
+
+// Middleware wraps a Handler to observe, modify or short-circuit requests and responses.
+// A middleware can change the stream by passing its own EmitFunc to next.
+type Middleware func(next Handler) Handler
+
+// Wrap returns a Provider that runs every GenerateText and GenerateTextStream call
+// through the middleware, the first middleware being the outermost. When a streaming
+// call returns a Response without emitting any chunk, for example because a middleware
+// answered it from a cache, the Response is replayed to the caller as chunks.
+func Wrap(provider Provider, middleware ...Middleware) Provider {
+	handler := providerHandler(provider)
+	for i := len(middleware) - 1; i >= 0; i-- {
+		handler = middleware[i](handler)
+	}
+	return &wrappedProvider{provider: provider, name: ProviderName(provider), handler: handler}
+}
+
+// As returns the first provider in the chain of Wrap calls around provider, starting with
+// provider itself, that is a T. T is usually an optional interface such as ModelLister or
+// Embedder, or a concrete provider type whose extra methods are hidden by Wrap.
+func As[T any](provider Provider) (T, bool) {
+	for provider != nil {
+		if target, ok := provider.(T); ok {
+			return target, true
+		}
+		wrapped, ok := provider.(*wrappedProvider)
+		if !ok {
+			break
+		}
+		provider = wrapped.provider
+	}
+	var zero T
+	return zero, false
+}
+
+// ProviderName returns a short name for provider, such as "openai" or "claude": the
+// result of its Name method when it has one, otherwise the package name of its type.
+// Providers returned by Wrap report the name of the provider they wrap.
+func ProviderName(provider Provider) string {
+	if named, ok := provider.(interface{ Name() string }); ok {
+		return named.Name()
+	}
+	t := reflect.TypeOf(provider)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	name := t.PkgPath()
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
+
+// WithOptions applies every field set in options, so a resolved GenerationOptions can be
+// passed back to a provider on top of its defaults. Zero-valued fields are left alone.
+func WithOptions(options *GenerationOptions) GenerationOption {
+	return func(target *GenerationOptions) {
+		if options == nil {
+			return
+		}
+		src := reflect.ValueOf(options).Elem()
+		dst := reflect.ValueOf(target).Elem()
+		for i := 0; i < src.NumField(); i++ {
+			field := src.Field(i)
+			if field.IsZero() {
+				continue
+			}
+			// Maps such as Headers and ExtraBody are merged key by key.
+			if target := dst.Field(i); field.Kind() == reflect.Map && !target.IsNil() {
+				merged := reflect.MakeMapWithSize(field.Type(), target.Len()+field.Len())
+				for _, m := range []reflect.Value{target, field} {
+					iter := m.MapRange()
+					for iter.Next() {
+						merged.SetMapIndex(iter.Key(), iter.Value())
+					}
+				}
+				field = merged
+			}
+			dst.Field(i).Set(field)
+		}
+	}
+}
+
+// Defaults returns a middleware that applies opts before the options of each call, so
+// options given to the call take precedence over the defaults.
+func Defaults(opts ...GenerationOption) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, req *Request, emit EmitFunc) (*Response, error) {
+			merged := *req
+			merged.Options = ResolveOptions(append(append([]GenerationOption(nil), opts...), WithOptions(req.Options))...)
+			if model := merged.Options.Model; model != nil && *model != "" {
+				merged.Model = *model
+			}
+			return next(ctx, &merged, emit)
+		}
+	}
+}
+
+type wrappedProvider struct {
+	provider Provider
+	name     string
+	handler  Handler
+}
+
+func (w *wrappedProvider) GenerateText(ctx context.Context, prompt string, options ...GenerationOption) (string, *UsageInfo, error) {
+	req := w.newRequest(prompt, options, false)
+	resp, err := w.handler(ctx, req, func(StreamChunk) error { return nil })
+	if resp == nil {
+		return "", nil, err
+	}
+	return resp.Text, resp.Usage, err
+}
+
+func (w *wrappedProvider) GenerateTextStream(ctx context.Context, prompt string, outChan chan<- StreamChunk, options ...GenerationOption) (*UsageInfo, error) {
+	defer close(outChan)
+
+	emitted, sentErr := false, false
+	emit := func(chunk StreamChunk) error {
+		select {
+		case outChan <- chunk:
+			emitted = true
+			sentErr = sentErr || chunk.Err != nil
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	req := w.newRequest(prompt, options, true)
+	resp, err := w.handler(ctx, req, emit)
+	if err != nil {
+		if !sentErr {
+			emit(StreamChunk{Err: err})
+		}
+		if resp == nil {
+			return nil, err
+		}
+		return resp.Usage, err
+	}
+	if resp == nil {
+		resp = &Response{}
+	}
+	if !emitted {
+		if err := replayResponse(resp, emit); err != nil {
+			return resp.Usage, err
+		}
+	}
+	return resp.Usage, nil
+}
+
+func (w *wrappedProvider) GetModelName() string {
+	return w.provider.GetModelName()
+}
+
+func (w *wrappedProvider) Close() error {
+	return w.provider.Close()
+}
+
+func (w *wrappedProvider) Name() string {
+	return w.name
+}
+
+func (w *wrappedProvider) newRequest(prompt string, options []GenerationOption, stream bool) *Request {
+	resolved := ResolveOptions(options...)
+	model := w.provider.GetModelName()
+	if resolved.Model != nil && *resolved.Model != "" {
+		model = *resolved.Model
+	}
+	return &Request{Prompt: prompt, Options: resolved, Provider: w.name, Model: model, Stream: stream}
+}
+
+// providerHandler is the innermost Handler, which calls the provider itself.
+func providerHandler(provider Provider) Handler {
+	return func(ctx context.Context, req *Request, emit EmitFunc) (*Response, error) {
+		if !req.Stream {
+			text, usage, err := provider.GenerateText(ctx, req.Prompt, WithOptions(req.Options))
+			return &Response{Text: text, Usage: usage}, err
+		}
+
+		inner := make(chan StreamChunk)
+		var usage *UsageInfo
+		var streamErr error
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			usage, streamErr = provider.GenerateTextStream(ctx, req.Prompt, inner, WithOptions(req.Options))
+		}()
+
+		resp := &Response{}
+		var text strings.Builder
+		var chunkErr, emitErr error
+		for chunk := range inner {
+			text.WriteString(chunk.Delta)
+			resp.ToolCalls = append(resp.ToolCalls, chunk.ToolCalls...)
+			if chunk.Err != nil && chunkErr == nil {
+				chunkErr = chunk.Err
+			}
+			// Once the caller is gone, keep draining so the provider can finish.
+			if emitErr == nil {
+				emitErr = emit(chunk)
+			}
+		}
+		<-done
+
+		resp.Text = text.String()
+		resp.Usage = usage
+		switch {
+		case streamErr != nil:
+			return resp, streamErr
+		case chunkErr != nil:
+			return resp, chunkErr
+		}
+		return resp, emitErr
+	}
+}
+
+func replayResponse(resp *Response, emit EmitFunc) error {
+	if resp.Text != "" {
+		if err := emit(StreamChunk{Delta: resp.Text}); err != nil {
+			return err
+		}
+	}
+	if len(resp.ToolCalls) > 0 {
+		if err := emit(StreamChunk{ToolCalls: resp.ToolCalls}); err != nil {
+			return err
+		}
+	}
+	return emit(StreamChunk{IsFinal: true})
+}
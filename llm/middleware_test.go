@@ -0,0 +1,260 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type stubProvider struct {
+	options []*GenerationOptions
+	err     error
+}
+
+func (p *stubProvider) record(opts []GenerationOption) *GenerationOptions {
+	temperature := float32(0.7)
+	options := &GenerationOptions{Temperature: &temperature, System: "default system"}
+	for _, opt := range opts {
+		opt(options)
+	}
+	p.options = append(p.options, options)
+	return options
+}
+
+func (p *stubProvider) GenerateText(_ context.Context, prompt string, opts ...GenerationOption) (string, *UsageInfo, error) {
+	p.record(opts)
+	if p.err != nil {
+		return "", nil, p.err
+	}
+	return "reply to " + prompt, &UsageInfo{InputTokens: 3, OutputTokens: 2}, nil
+}
+
+func (p *stubProvider) GenerateTextStream(_ context.Context, prompt string, outChan chan<- StreamChunk, opts ...GenerationOption) (*UsageInfo, error) {
+	defer close(outChan)
+	p.record(opts)
+	if p.err != nil {
+		outChan <- StreamChunk{Err: p.err}
+		return nil, p.err
+	}
+	outChan <- StreamChunk{Delta: "reply to "}
+	outChan <- StreamChunk{Delta: prompt}
+	outChan <- StreamChunk{IsFinal: true}
+	return &UsageInfo{InputTokens: 3, OutputTokens: 2}, nil
+}
+
+func (p *stubProvider) GetModelName() string { return "stub-model" }
+func (p *stubProvider) Close() error         { return nil }
+
+func drain(t *testing.T, provider Provider, prompt string, opts ...GenerationOption) ([]StreamChunk, *UsageInfo, error) {
+	t.Helper()
+	out := make(chan StreamChunk)
+	var usage *UsageInfo
+	var err error
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		usage, err = provider.GenerateTextStream(context.Background(), prompt, out, opts...)
+	}()
+	var chunks []StreamChunk
+	for chunk := range out {
+		chunks = append(chunks, chunk)
+	}
+	<-done
+	return chunks, usage, err
+}
+
+func streamText(chunks []StreamChunk) string {
+	var text strings.Builder
+	for _, chunk := range chunks {
+		text.WriteString(chunk.Delta)
+	}
+	return text.String()
+}
+
+func TestWrapObservesBothCallKinds(t *testing.T) {
+	var seen []*Response
+	var requests []Request
+	observe := func(next Handler) Handler {
+		return func(ctx context.Context, req *Request, emit EmitFunc) (*Response, error) {
+			requests = append(requests, *req)
+			resp, err := next(ctx, req, emit)
+			seen = append(seen, resp)
+			return resp, err
+		}
+	}
+	provider := Wrap(&stubProvider{}, observe)
+
+	text, usage, err := provider.GenerateText(context.Background(), "hi", WithModel("other-model"))
+	if err != nil || text != "reply to hi" || usage.OutputTokens != 2 {
+		t.Fatalf("GenerateText = %q, %+v, %v", text, usage, err)
+	}
+	chunks, usage, err := drain(t, provider, "there")
+	if err != nil || streamText(chunks) != "reply to there" || usage.OutputTokens != 2 {
+		t.Fatalf("stream = %q, %+v, %v", streamText(chunks), usage, err)
+	}
+
+	if len(seen) != 2 || seen[0].Text != "reply to hi" || seen[1].Text != "reply to there" {
+		t.Errorf("middleware saw responses %+v", seen)
+	}
+	if requests[0].Stream || requests[0].Model != "other-model" || !requests[1].Stream || requests[1].Model != "stub-model" {
+		t.Errorf("middleware saw requests %+v", requests)
+	}
+}
+
+func TestWrapPassesModifiedOptionsOverDefaults(t *testing.T) {
+	inner := &stubProvider{}
+	addSystem := func(next Handler) Handler {
+		return func(ctx context.Context, req *Request, emit EmitFunc) (*Response, error) {
+			req.Options.System = "from middleware"
+			req.Prompt = strings.ToUpper(req.Prompt)
+			return next(ctx, req, emit)
+		}
+	}
+	provider := Wrap(inner, addSystem)
+
+	text, _, _ := provider.GenerateText(context.Background(), "hi", WithMaxTokens(10))
+	if text != "reply to HI" {
+		t.Errorf("prompt change not applied: %q", text)
+	}
+	options := inner.options[0]
+	if options.System != "from middleware" || *options.MaxTokens != 10 || *options.Temperature != 0.7 {
+		t.Errorf("provider got options %+v", options)
+	}
+}
+
+func TestDefaultsYieldToCallOptions(t *testing.T) {
+	inner := &stubProvider{}
+	provider := Wrap(inner, Defaults(WithTemperature(0.1), WithMaxTokens(100), WithSystem("house style")))
+
+	provider.GenerateText(context.Background(), "hi", WithMaxTokens(10))
+	options := inner.options[0]
+	if *options.Temperature != 0.1 || *options.MaxTokens != 10 || options.System != "house style" {
+		t.Errorf("provider got options %+v", options)
+	}
+}
+
+func TestDefaultsMergeMapsKeyByKey(t *testing.T) {
+	inner := &stubProvider{}
+	provider := Wrap(inner, Defaults(
+		WithHeaders(http.Header{"X-Team": {"search"}, "X-Env": {"prod"}}),
+		WithExtraBody(map[string]any{"provider": "a", "seed": 1}),
+	))
+
+	provider.GenerateText(context.Background(), "hi",
+		WithHeaders(http.Header{"x-env": {"staging"}}),
+		WithExtraBody(map[string]any{"provider": "b"}))
+	options := inner.options[0]
+	if options.Headers.Get("X-Team") != "search" || options.Headers.Get("X-Env") != "staging" {
+		t.Errorf("unexpected headers %v", options.Headers)
+	}
+	if options.ExtraBody["provider"] != "b" || options.ExtraBody["seed"] != 1 {
+		t.Errorf("unexpected extra body %v", options.ExtraBody)
+	}
+}
+
+func TestWrapShortCircuitReplaysStream(t *testing.T) {
+	inner := &stubProvider{}
+	shortCircuit := func(next Handler) Handler {
+		return func(ctx context.Context, req *Request, emit EmitFunc) (*Response, error) {
+			return &Response{
+				Text:      "canned",
+				ToolCalls: []ToolCall{{ID: "call_1", Name: "lookup"}},
+				Usage:     &UsageInfo{ResponseCacheHit: true},
+			}, nil
+		}
+	}
+	provider := Wrap(inner, shortCircuit)
+
+	chunks, usage, err := drain(t, provider, "hi")
+	if err != nil || !usage.ResponseCacheHit {
+		t.Fatalf("stream = %+v, %v", usage, err)
+	}
+	if len(chunks) != 3 || chunks[0].Delta != "canned" || chunks[1].ToolCalls[0].ID != "call_1" || !chunks[2].IsFinal {
+		t.Errorf("replayed chunks %+v", chunks)
+	}
+	if len(inner.options) != 0 {
+		t.Error("provider should not be called")
+	}
+}
+
+func TestWrapRewritesStreamChunks(t *testing.T) {
+	upper := func(next Handler) Handler {
+		return func(ctx context.Context, req *Request, emit EmitFunc) (*Response, error) {
+			resp, err := next(ctx, req, func(chunk StreamChunk) error {
+				chunk.Delta = strings.ToUpper(chunk.Delta)
+				return emit(chunk)
+			})
+			if resp != nil {
+				resp.Text = strings.ToUpper(resp.Text)
+			}
+			return resp, err
+		}
+	}
+	provider := Wrap(&stubProvider{}, upper)
+
+	chunks, _, _ := drain(t, provider, "hi")
+	if got := streamText(chunks); got != "REPLY TO HI" {
+		t.Errorf("stream = %q", got)
+	}
+	if text, _, _ := provider.GenerateText(context.Background(), "hi"); text != "REPLY TO HI" {
+		t.Errorf("text = %q", text)
+	}
+}
+
+func TestWrapOrderAndErrors(t *testing.T) {
+	var order []string
+	named := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(ctx context.Context, req *Request, emit EmitFunc) (*Response, error) {
+				order = append(order, name)
+				return next(ctx, req, emit)
+			}
+		}
+	}
+	boom := errors.New("boom")
+	provider := Wrap(&stubProvider{err: boom}, named("outer"), named("inner"))
+
+	if _, _, err := provider.GenerateText(context.Background(), "hi"); !errors.Is(err, boom) {
+		t.Errorf("GenerateText error = %v", err)
+	}
+	if strings.Join(order, ",") != "outer,inner" {
+		t.Errorf("order = %v", order)
+	}
+
+	chunks, _, err := drain(t, provider, "hi")
+	if !errors.Is(err, boom) {
+		t.Errorf("stream error = %v", err)
+	}
+	errChunks := 0
+	for _, chunk := range chunks {
+		if chunk.Err != nil {
+			errChunks++
+		}
+	}
+	if errChunks != 1 {
+		t.Errorf("expected exactly one error chunk, got %+v", chunks)
+	}
+}
+
+type embeddingProvider struct{ stubProvider }
+
+func (p *embeddingProvider) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	return make([][]float32, len(texts)), nil
+}
+
+func TestAsLooksThroughWrap(t *testing.T) {
+	inner := &embeddingProvider{}
+	wrapped := Wrap(Wrap(inner), Defaults(WithStrict()))
+
+	if embedder, ok := EmbedderOf(wrapped); !ok || embedder != Embedder(inner) {
+		t.Errorf("EmbedderOf() = %v, %v", embedder, ok)
+	}
+	if provider, ok := As[*embeddingProvider](wrapped); !ok || provider != inner {
+		t.Errorf("As() = %v, %v", provider, ok)
+	}
+	if _, ok := As[ModelLister](wrapped); ok {
+		t.Error("a provider without ListModels should not be a ModelLister")
+	}
+}
@@ -0,0 +1,184 @@
+package llm
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Model describes a model served by a vendor. Fields the vendor does not report are
+// zero; ContextWindow and MaxOutputTokens then come from the model registry when the
+// model is known.
+type Model struct {
+	ID          string
+	DisplayName string
+	OwnedBy     string
+	Created     time.Time
+	// ContextWindow and MaxOutputTokens are token limits.
+	ContextWindow   int
+	MaxOutputTokens int
+	// InputPrice and OutputPrice are USD per million tokens. Only OpenRouter reports
+	// them.
+	InputPrice  float64
+	OutputPrice float64
+}
+
+// ModelLister is implemented by providers that can list the models their vendor serves.
+type ModelLister interface {
+	ListModels(ctx context.Context) ([]Model, error)
+}
+
+// ModelListerOf returns the model lister of provider, looking through Wrap.
+func ModelListerOf(provider Provider) (ModelLister, bool) {
+	return As[ModelLister](provider)
+}
+
+// FillModelLimits fills the token limits of model from the model registry when the
+// vendor did not report them.
+func FillModelLimits(model Model) Model {
+	if info, ok := LookupModel(model.ID); ok {
+		if model.ContextWindow == 0 {
+			model.ContextWindow = info.ContextWindow
+		}
+		if model.MaxOutputTokens == 0 {
+			model.MaxOutputTokens = info.MaxOutputTokens
+		}
+	}
+	return model
+}
+
+// modelRetryDelay is the delay before ModelCache lists the models again after a
+// failure. It doubles with each consecutive failure, up to the refresh interval.
+const modelRetryDelay = time.Second
+
+// ModelCache is a ModelLister that keeps the models of another lister and lists them
+// again once they are older than its refresh interval. One listing runs at a time,
+// outside the lock, and concurrent callers wait for it. When listing fails the
+// previous models are returned with the error and the next attempt backs off.
+type ModelCache struct {
+	lister   ModelLister
+	interval time.Duration
+	now      func() time.Time
+
+	mu      sync.Mutex
+	models  []Model
+	fetched time.Time
+	// listing is closed when the listing in progress ends; nil when none is.
+	listing chan struct{}
+	// err is the error of the last listing, failures the number of consecutive failed
+	// listings and retryAt when the next listing may start after one.
+	err      error
+	failures int
+	retryAt  time.Time
+}
+
+// NewModelCache returns a cache of the models of lister, refreshed every interval.
+func NewModelCache(lister ModelLister, interval time.Duration) *ModelCache {
+	return &ModelCache{lister: lister, interval: interval, now: time.Now}
+}
+
+// ListModels returns the cached models, listing them first when the cache is empty or
+// expired. When listing fails, or failed too recently to be retried, the previous
+// models, if any, are returned together with the error.
+func (c *ModelCache) ListModels(ctx context.Context) ([]Model, error) {
+	c.mu.Lock()
+	for c.listing != nil {
+		listing := c.listing
+		c.mu.Unlock()
+		select {
+		case <-listing:
+		case <-ctx.Done():
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			return append([]Model(nil), c.models...), ctx.Err()
+		}
+		c.mu.Lock()
+	}
+	if c.models != nil && c.now().Sub(c.fetched) < c.interval {
+		defer c.mu.Unlock()
+		return append([]Model(nil), c.models...), nil
+	}
+	if c.failures > 0 && c.now().Before(c.retryAt) {
+		defer c.mu.Unlock()
+		return append([]Model(nil), c.models...), c.err
+	}
+	listing := make(chan struct{})
+	c.listing = listing
+	c.mu.Unlock()
+	return c.list(ctx, listing)
+}
+
+// Start refreshes the models in the background every half interval until ctx is
+// done, so that ListModels does not wait for the lister once the first listing has
+// completed. A listing already in progress is not repeated.
+func (c *ModelCache) Start(ctx context.Context) {
+	if c.interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(c.interval / 2)
+		defer ticker.Stop()
+		for {
+			c.mu.Lock()
+			if c.listing == nil {
+				listing := make(chan struct{})
+				c.listing = listing
+				c.mu.Unlock()
+				c.list(ctx, listing)
+			} else {
+				c.mu.Unlock()
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// list runs the listing the caller registered as listing and records its result. A
+// listing cut short by ctx is not counted as a failure.
+func (c *ModelCache) list(ctx context.Context, listing chan struct{}) ([]Model, error) {
+	models, err := c.lister.ListModels(ctx)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.listing = nil
+	close(listing)
+	switch {
+	case err == nil:
+		if models == nil {
+			models = []Model{}
+		}
+		c.models, c.fetched = models, c.now()
+		c.err, c.failures = nil, 0
+	case ctx.Err() == nil:
+		c.err = err
+		c.failures++
+		delay := modelRetryDelay << min(c.failures-1, 16)
+		if c.interval > 0 && delay > c.interval {
+			delay = c.interval
+		}
+		c.retryAt = c.now().Add(delay)
+	}
+	return append([]Model(nil), c.models...), err
+}
+
+// Invalidate makes the next ListModels call list the models again.
+func (c *ModelCache) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.models = nil
+	c.err, c.failures = nil, 0
+}
+
+// FindModel returns the model with the given ID.
+func FindModel(models []Model, id string) (Model, bool) {
+	for _, model := range models {
+		if model.ID == id {
+			return model, true
+		}
+	}
+	return Model{}, false
+}
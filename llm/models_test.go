@@ -0,0 +1,130 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type countingLister struct {
+	calls int
+	err   error
+}
+
+func (l *countingLister) ListModels(context.Context) ([]Model, error) {
+	l.calls++
+	if l.err != nil {
+		return nil, l.err
+	}
+	return []Model{{ID: "gpt-4o-2024-08-06"}}, nil
+}
+
+func TestModelCacheRefreshesAfterInterval(t *testing.T) {
+	lister := &countingLister{}
+	cache := NewModelCache(lister, time.Hour)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		if _, err := cache.ListModels(context.Background()); err != nil {
+			t.Fatalf("ListModels() error = %v", err)
+		}
+	}
+	if lister.calls != 1 {
+		t.Errorf("expected one listing within the interval, got %d", lister.calls)
+	}
+
+	now = now.Add(2 * time.Hour)
+	lister.err = errors.New("unavailable")
+	for i := 0; i < 2; i++ {
+		stale, err := cache.ListModels(context.Background())
+		if err == nil || len(stale) != 1 {
+			t.Errorf("expected the stale models with the listing error, got %v, %v", stale, err)
+		}
+	}
+	if lister.calls != 2 {
+		t.Errorf("expected no retry before the backoff delay, got %d listings", lister.calls)
+	}
+
+	now = now.Add(modelRetryDelay)
+	lister.err = nil
+	models, err := cache.ListModels(context.Background())
+	if err != nil || lister.calls != 3 {
+		t.Fatalf("ListModels() = %v, %v after %d calls", models, err, lister.calls)
+	}
+	if model, ok := FindModel(models, "gpt-4o-2024-08-06"); !ok || FillModelLimits(model).ContextWindow != 128000 {
+		t.Errorf("FindModel() = %+v, %v", model, ok)
+	}
+}
+
+// blockingLister lists once release is closed.
+type blockingLister struct {
+	calls   atomic.Int32
+	release chan struct{}
+}
+
+func (l *blockingLister) ListModels(context.Context) ([]Model, error) {
+	l.calls.Add(1)
+	<-l.release
+	return []Model{{ID: "m"}}, nil
+}
+
+func TestModelCacheListsOnceForConcurrentCallers(t *testing.T) {
+	lister := &blockingLister{release: make(chan struct{})}
+	cache := NewModelCache(lister, time.Hour)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if models, err := cache.ListModels(context.Background()); err != nil || len(models) != 1 {
+				t.Errorf("ListModels() = %v, %v", models, err)
+			}
+		}()
+	}
+	for lister.calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	close(lister.release)
+	wg.Wait()
+	if calls := lister.calls.Load(); calls != 1 {
+		t.Errorf("expected one listing, got %d", calls)
+	}
+}
+
+func TestModelCacheStartRefreshesInBackground(t *testing.T) {
+	lister := &blockingLister{release: make(chan struct{})}
+	close(lister.release)
+	cache := NewModelCache(lister, 20*time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cache.Start(ctx)
+	deadline := time.Now().Add(time.Second)
+	for lister.calls.Load() < 3 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if calls := lister.calls.Load(); calls < 3 {
+		t.Errorf("expected periodic listings, got %d", calls)
+	}
+}
+
+type listingProvider struct {
+	stubProvider
+	countingLister
+}
+
+func TestModelListerOfLooksThroughWrap(t *testing.T) {
+	inner := &listingProvider{}
+	lister, ok := ModelListerOf(Wrap(inner, Defaults(WithStrict())))
+	if !ok || lister != ModelLister(inner) {
+		t.Fatalf("ModelListerOf() = %v, %v", lister, ok)
+	}
+	if _, ok := ModelListerOf(&stubProvider{}); ok {
+		t.Error("a provider without ListModels should not be a ModelLister")
+	}
+}
@@ -0,0 +1,77 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// MetadataUserID is the metadata key for an end-user identifier. Providers send it in
+// their end-user field: Claude's metadata.user_id, "user" for OpenAI-compatible APIs
+// and "user_id" for Z.AI.
+const MetadataUserID = "user_id"
+
+// WithHeaders sets HTTP headers on the request, replacing values set by the provider.
+// Repeated calls add to the headers.
+func WithHeaders(headers http.Header) GenerationOption {
+	return func(options *GenerationOptions) {
+		merged := options.Headers.Clone()
+		if merged == nil {
+			merged = make(http.Header, len(headers))
+		}
+		for name, values := range headers {
+			merged[http.CanonicalHeaderKey(name)] = append([]string(nil), values...)
+		}
+		options.Headers = merged
+	}
+}
+
+// WithMetadata attaches metadata to the request. Providers map the keys they know,
+// such as MetadataUserID, to their API and ignore the rest. Repeated calls add to the
+// metadata.
+func WithMetadata(metadata map[string]string) GenerationOption {
+	return func(options *GenerationOptions) {
+		options.Metadata = mergeMaps(options.Metadata, metadata)
+	}
+}
+
+// WithExtraBody merges fields into the top level of the JSON request body, replacing
+// fields the provider sets, for vendor parameters without a dedicated option such as
+// OpenRouter's "provider" routing. Repeated calls add to the fields. Gemini does not
+// support it.
+func WithExtraBody(fields map[string]any) GenerationOption {
+	return func(options *GenerationOptions) {
+		options.ExtraBody = mergeMaps(options.ExtraBody, fields)
+	}
+}
+
+// mergeMaps returns a copy of dst with the entries of src added, leaving both intact.
+func mergeMaps[V any](dst, src map[string]V) map[string]V {
+	merged := make(map[string]V, len(dst)+len(src))
+	for key, value := range dst {
+		merged[key] = value
+	}
+	for key, value := range src {
+		merged[key] = value
+	}
+	return merged
+}
+
+// MergeExtraBody sets the fields of extra at the top level of the JSON object body.
+func MergeExtraBody(body []byte, extra map[string]any) ([]byte, error) {
+	if len(extra) == 0 {
+		return body, nil
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, fmt.Errorf("failed to merge extra body: %w", err)
+	}
+	for key, value := range extra {
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal extra body field %q: %w", key, err)
+		}
+		fields[key] = raw
+	}
+	return json.Marshal(fields)
+}
@@ -1,6 +1,11 @@
 package llm
 
-import "context"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
 
 // SystemBlock represents a block of text for the system prompt, potentially cacheable.
 type SystemBlock struct {
@@ -14,29 +19,115 @@ type GenerationOptions struct {
 	MaxTokens          *int32
 	TopK               *float32
 	TopP               *float32
+	StopSequences      []string
+	Seed               *int64
+	PresencePenalty    *float32
+	FrequencyPenalty   *float32
+	LogitBias          map[int]int
+	Logprobs           *int32
+	CandidateCount     *int32
 	Language           string
 	System             string
 	SystemBlocks       []SystemBlock
+	Messages           []Message
 	ResponseFormat     string
 	ResponseSchema     *SchemaProperty
 	Tools              []*Tool
+	ToolChoice         *ToolChoice
+	ParallelToolCalls  *bool
 	UseCache           bool
+	CacheTTL           CacheTTL
+	CacheKey           string
+	CachedContent      string
 	AllowSexualContent bool
 	Model              *string
+	Headers            http.Header
+	Metadata           map[string]string
+	ExtraBody          map[string]any
+	Strict             bool
 }
 
 // StreamChunk represents a piece of the streamed response.
+// ToolCalls is set on chunks that carry complete tool calls requested by the model.
 type StreamChunk struct {
-	Delta   string
-	IsFinal bool
-	Err     error
+	Delta     string
+	ToolCalls []ToolCall
+	IsFinal   bool
+	Err       error
 }
 
 // Tool represents a function or capability the LLM can invoke.
+// Tools built with NewFuncTool can also be executed locally.
 type Tool struct {
 	Name        string
 	Description string
 	InputSchema *SchemaProperty
+
+	handler toolHandler
+}
+
+// ToolChoiceMode controls whether and how the model may call tools.
+type ToolChoiceMode string
+
+const (
+	ToolChoiceModeAuto     ToolChoiceMode = "auto"
+	ToolChoiceModeNone     ToolChoiceMode = "none"
+	ToolChoiceModeRequired ToolChoiceMode = "required"
+	ToolChoiceModeSpecific ToolChoiceMode = "specific"
+)
+
+// ToolChoice forces, forbids or leaves tool use up to the model.
+// Name is only used with ToolChoiceModeSpecific.
+type ToolChoice struct {
+	Mode ToolChoiceMode
+	Name string
+}
+
+var (
+	// ToolChoiceAuto lets the model decide whether to call a tool.
+	ToolChoiceAuto = ToolChoice{Mode: ToolChoiceModeAuto}
+	// ToolChoiceNone forbids tool calls.
+	ToolChoiceNone = ToolChoice{Mode: ToolChoiceModeNone}
+	// ToolChoiceRequired forces the model to call at least one tool.
+	ToolChoiceRequired = ToolChoice{Mode: ToolChoiceModeRequired}
+)
+
+// ToolChoiceFor forces the model to call the named tool.
+func ToolChoiceFor(name string) ToolChoice {
+	return ToolChoice{Mode: ToolChoiceModeSpecific, Name: name}
+}
+
+// ForcesToolUse reports whether the choice requires the model to call a tool.
+func (c *ToolChoice) ForcesToolUse() bool {
+	return c != nil && (c.Mode == ToolChoiceModeRequired || c.Mode == ToolChoiceModeSpecific)
+}
+
+// ValidateToolChoice checks that the tool choice is consistent with the configured tools.
+func ValidateToolChoice(options *GenerationOptions) error {
+	choice := options.ToolChoice
+	if choice == nil {
+		return nil
+	}
+	switch choice.Mode {
+	case ToolChoiceModeAuto, ToolChoiceModeNone:
+		return nil
+	case ToolChoiceModeRequired:
+		if len(options.Tools) == 0 {
+			return errors.New("tool choice 'required' needs at least one tool")
+		}
+		return nil
+	case ToolChoiceModeSpecific:
+		if choice.Name == "" {
+			return errors.New("tool choice 'specific' needs a tool name")
+		}
+		for _, tool := range options.Tools {
+			if tool.Name == choice.Name {
+				return nil
+			}
+		}
+		return fmt.Errorf("tool choice names unknown tool '%s'", choice.Name)
+	}
+	return fmt.Errorf("unknown tool choice mode '%s'", choice.Mode)
 }
 
 func ValuePtr[T any](value T) *T {
@@ -45,6 +136,16 @@ func ValuePtr[T any](value T) *T {
 
 type GenerationOption func(options *GenerationOptions)
 
+// ResolveOptions applies the options to an empty GenerationOptions, without any
+// provider defaults. Wrappers use it to inspect the options of a call.
+func ResolveOptions(opts ...GenerationOption) *GenerationOptions {
+	options := &GenerationOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	return options
+}
+
 func WithTemperature(temp float32) GenerationOption {
 	return func(options *GenerationOptions) {
 		options.Temperature = ValuePtr(temp)
@@ -69,6 +170,52 @@ func WithTopP(topP float32) GenerationOption {
 	}
 }
 
+// WithStopSequences stops generation at any of the given sequences, which are not
+// included in the output.
+func WithStopSequences(stops ...string) GenerationOption {
+	return func(options *GenerationOptions) {
+		options.StopSequences = stops
+	}
+}
+
+// WithSeed asks for deterministic sampling, so repeated calls with the same seed and
+// parameters return the same result where the vendor supports it.
+func WithSeed(seed int64) GenerationOption {
+	return func(options *GenerationOptions) {
+		options.Seed = ValuePtr(seed)
+	}
+}
+
+// WithPresencePenalty penalizes tokens that already appeared, between -2 and 2.
+func WithPresencePenalty(penalty float32) GenerationOption {
+	return func(options *GenerationOptions) {
+		options.PresencePenalty = ValuePtr(penalty)
+	}
+}
+
+// WithFrequencyPenalty penalizes tokens by how often they appeared, between -2 and 2.
+func WithFrequencyPenalty(penalty float32) GenerationOption {
+	return func(options *GenerationOptions) {
+		options.FrequencyPenalty = ValuePtr(penalty)
+	}
+}
+
+// WithLogitBias adjusts the likelihood of tokens, keyed by the vendor's token ID, with
+// biases between -100 (ban) and 100 (force).
+func WithLogitBias(bias map[int]int) GenerationOption {
+	return func(options *GenerationOptions) {
+		options.LogitBias = bias
+	}
+}
+
+// WithStrict makes providers fail with an *UnsupportedOptionError when an option is
+// not supported, instead of logging a warning and ignoring it.
+func WithStrict() GenerationOption {
+	return func(options *GenerationOptions) {
+		options.Strict = true
+	}
+}
+
 func WithLanguage(lang string) GenerationOption {
 	return func(options *GenerationOptions) {
 		options.Language = lang
@@ -99,12 +246,29 @@ func WithTools(tools []*Tool) GenerationOption {
 	}
 }
 
+// WithToolChoice controls whether the model may, must or must not call tools.
+func WithToolChoice(choice ToolChoice) GenerationOption {
+	return func(options *GenerationOptions) {
+		options.ToolChoice = &choice
+	}
+}
+
+// WithParallelToolCalls allows or forbids multiple tool calls in a single response.
+func WithParallelToolCalls(enabled bool) GenerationOption {
+	return func(options *GenerationOptions) {
+		options.ParallelToolCalls = ValuePtr(enabled)
+	}
+}
+
 func WithSystemBlocks(blocks []SystemBlock) GenerationOption {
 	return func(options *GenerationOptions) {
 		options.SystemBlocks = blocks
 	}
 }
 
+// WithCache enables prompt caching on providers that need explicit cache breakpoints.
+// Claude places breakpoints after the tool definitions, the system prompt and the
+// conversation history. Providers that cache automatically (OpenAI, DeepSeek) ignore it.
 func WithCache(useCache bool) GenerationOption {
 	return func(options *GenerationOptions) {
 		options.UseCache = useCache
@@ -123,13 +287,26 @@ func WithModel(model string) GenerationOption {
 	}
 }
 
-// UsageInfo contains token usage statistics
+// UsageInfo contains token usage statistics.
+// InputTokens counts every prompt token, including those read from or written to a
+// prompt cache. CacheHitTokens were served from the cache and CacheMissTokens were not;
+// CacheCreateTokens were written to the cache (Claude only). The cache fields are zero
+// when the provider does not report caching. ResponseCacheHit marks a response served
+// from a local response cache without calling the provider; its token counts are zero.
+// ResponseModel and FinishReason are the model and stop reason reported by the provider,
+// empty when it reports none.
 type UsageInfo struct {
 	InputTokens       int
 	OutputTokens      int
 	CacheCreateTokens int
 	CacheHitTokens    int
 	CacheMissTokens   int
+	ResponseCacheHit  bool
+	ResponseModel     string
+	FinishReason      string
+	// Completion holds every generated candidate when WithLogprobs or WithCandidates is
+	// used, and is nil otherwise.
+	Completion *Completion
 }
 
 // Provider defines interface for LLM providers
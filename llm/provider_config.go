@@ -0,0 +1,166 @@
+package llm
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// ProviderConfig holds the settings accepted by every provider constructor as
+// ProviderOptions. The transport package builds HTTP clients from it.
+type ProviderConfig struct {
+	// HTTPClient replaces the provider's default client. Its timeout and transport are
+	// kept unless other settings override them.
+	HTTPClient *http.Client
+	// Transport replaces the transport of the client.
+	Transport http.RoundTripper
+	// Proxy, TLSConfig, Pool and the connect and first-byte Timeouts adjust the
+	// client's *http.Transport, which is cloned first so shared transports are never
+	// modified.
+	Proxy     func(*http.Request) (*url.URL, error)
+	TLSConfig *tls.Config
+	Pool      *ConnectionPool
+	Timeouts  *Timeouts
+	// Headers are set on every request, replacing values set by the provider.
+	Headers http.Header
+	// Metadata and ExtraBody are applied to every generation request, under the values
+	// given with WithMetadata and WithExtraBody.
+	Metadata  map[string]string
+	ExtraBody map[string]any
+	// Credentials supplies the API key of each request.
+	Credentials CredentialSource
+	// RoundTrippers wrap the provider's HTTP transport, the first one outermost.
+	RoundTrippers []func(http.RoundTripper) http.RoundTripper
+}
+
+// ConnectionPool sets the connection reuse limits of an *http.Transport. Zero fields
+// keep the transport's values.
+type ConnectionPool struct {
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	MaxConnsPerHost     int
+	IdleConnTimeout     time.Duration
+}
+
+// Timeouts bounds the phases of a provider call. Zero fields keep the provider's
+// defaults.
+type Timeouts struct {
+	// Connect bounds dialing and the TLS handshake.
+	Connect time.Duration
+	// FirstByte bounds the wait for response headers after the request is sent. For
+	// non-streaming calls this includes the whole generation.
+	FirstByte time.Duration
+	// Total bounds the whole call, including reading a streamed body.
+	Total time.Duration
+	// StreamIdle aborts a server-sent event stream with a *StreamStallError when no
+	// data arrives for this long.
+	StreamIdle time.Duration
+}
+
+// ProviderOption configures a provider at construction time.
+type ProviderOption func(*ProviderConfig)
+
+// ResolveProviderOptions applies the options to an empty ProviderConfig.
+func ResolveProviderOptions(opts ...ProviderOption) *ProviderConfig {
+	config := &ProviderConfig{}
+	for _, opt := range opts {
+		opt(config)
+	}
+	return config
+}
+
+// WithHTTPClient makes the provider send requests with client instead of its default.
+// For Gemini on Vertex AI the client must add Google Cloud credentials itself.
+func WithHTTPClient(client *http.Client) ProviderOption {
+	return func(config *ProviderConfig) {
+		config.HTTPClient = client
+	}
+}
+
+// WithTransport makes the provider's client send requests through rt.
+func WithTransport(rt http.RoundTripper) ProviderOption {
+	return func(config *ProviderConfig) {
+		config.Transport = rt
+	}
+}
+
+// WithProxy routes requests through the proxy returned by proxy, such as
+// http.ProxyURL(u). Without it the transport's proxy, usually from the environment, is used.
+func WithProxy(proxy func(*http.Request) (*url.URL, error)) ProviderOption {
+	return func(config *ProviderConfig) {
+		config.Proxy = proxy
+	}
+}
+
+// WithTLSConfig sets the TLS configuration, for example custom root CAs or client
+// certificates for mTLS.
+func WithTLSConfig(tlsConfig *tls.Config) ProviderOption {
+	return func(config *ProviderConfig) {
+		config.TLSConfig = tlsConfig
+	}
+}
+
+// WithConnectionPool sets the connection reuse limits of the provider's transport.
+func WithConnectionPool(pool ConnectionPool) ProviderOption {
+	return func(config *ProviderConfig) {
+		config.Pool = &pool
+	}
+}
+
+// WithTimeouts sets connect, first-byte, total and stream inactivity timeouts.
+func WithTimeouts(timeouts Timeouts) ProviderOption {
+	return func(config *ProviderConfig) {
+		config.Timeouts = &timeouts
+	}
+}
+
+// WithDefaultHeaders sets headers on every request of the provider, replacing any
+// value the provider sets itself. Repeated calls add to the headers.
+func WithDefaultHeaders(headers http.Header) ProviderOption {
+	return func(config *ProviderConfig) {
+		if config.Headers == nil {
+			config.Headers = make(http.Header)
+		}
+		for name, values := range headers {
+			config.Headers[http.CanonicalHeaderKey(name)] = append([]string(nil), values...)
+		}
+	}
+}
+
+// WithDefaultMetadata attaches metadata to every generation request of the provider.
+// Metadata given to a call takes precedence key by key.
+func WithDefaultMetadata(metadata map[string]string) ProviderOption {
+	return func(config *ProviderConfig) {
+		config.Metadata = mergeMaps(config.Metadata, metadata)
+	}
+}
+
+// WithDefaultExtraBody merges fields into the body of every generation request of the
+// provider. Fields given to a call with WithExtraBody take precedence.
+func WithDefaultExtraBody(fields map[string]any) ProviderOption {
+	return func(config *ProviderConfig) {
+		config.ExtraBody = mergeMaps(config.ExtraBody, fields)
+	}
+}
+
+// RequestDefaults returns the generation options for the default metadata and extra
+// body, which providers apply before the options of each call.
+func (c *ProviderConfig) RequestDefaults() []GenerationOption {
+	var opts []GenerationOption
+	if len(c.Metadata) > 0 {
+		opts = append(opts, WithMetadata(c.Metadata))
+	}
+	if len(c.ExtraBody) > 0 {
+		opts = append(opts, WithExtraBody(c.ExtraBody))
+	}
+	return opts
+}
+
+// WithRoundTripper wraps the provider's HTTP transport, for example to trace or
+// rewrite requests. It applies to raw HTTP and SDK-based providers alike.
+func WithRoundTripper(wrap func(http.RoundTripper) http.RoundTripper) ProviderOption {
+	return func(config *ProviderConfig) {
+		config.RoundTrippers = append(config.RoundTrippers, wrap)
+	}
+}
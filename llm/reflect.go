@@ -0,0 +1,431 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var (
+	timeType           = reflect.TypeOf(time.Time{})
+	rawMessageType     = reflect.TypeOf(json.RawMessage{})
+	byteSliceType      = reflect.TypeOf([]byte{})
+	emptyInterfaceType = reflect.TypeOf((*interface{})(nil)).Elem()
+)
+
+// SchemaFor reflects a Go value's type into a SchemaProperty.
+// See NewFuncTool for the supported struct tags.
+func SchemaFor(v interface{}) (*SchemaProperty, error) {
+	t := reflect.TypeOf(v)
+	if t == nil {
+		return &SchemaProperty{}, nil
+	}
+	return SchemaForType(t)
+}
+
+// SchemaForType reflects a Go type into a SchemaProperty.
+func SchemaForType(t reflect.Type) (*SchemaProperty, error) {
+	return schemaForType(t, map[reflect.Type]bool{})
+}
+
+func schemaForType(t reflect.Type, visiting map[reflect.Type]bool) (*SchemaProperty, error) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return &SchemaProperty{Type: "string", Format: "date-time"}, nil
+	case t == rawMessageType || t == emptyInterfaceType:
+		return &SchemaProperty{}, nil
+	case t == byteSliceType:
+		return &SchemaProperty{Type: "string", Format: "byte"}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &SchemaProperty{Type: "string"}, nil
+	case reflect.Bool:
+		return &SchemaProperty{Type: "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &SchemaProperty{Type: "integer"}, nil
+	case reflect.Float32, reflect.Float64:
+		return &SchemaProperty{Type: "number"}, nil
+	case reflect.Slice, reflect.Array:
+		items, err := schemaForType(t.Elem(), visiting)
+		if err != nil {
+			return nil, err
+		}
+		return &SchemaProperty{Type: "array", Items: items}, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("unsupported map key type %s", t.Key())
+		}
+		return &SchemaProperty{Type: "object"}, nil
+	case reflect.Interface:
+		return &SchemaProperty{}, nil
+	case reflect.Struct:
+		return structSchema(t, visiting)
+	}
+	return nil, fmt.Errorf("unsupported type %s", t)
+}
+
+func structSchema(t reflect.Type, visiting map[reflect.Type]bool) (*SchemaProperty, error) {
+	if visiting[t] {
+		// Recursive types cannot be expressed without $ref; fall back to an open object.
+		return &SchemaProperty{Type: "object"}, nil
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	schema := &SchemaProperty{
+		Type:                 "object",
+		Properties:           map[string]*SchemaProperty{},
+		AdditionalProperties: ValuePtr(false),
+	}
+	if err := addStructFields(schema, t, visiting); err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+func addStructFields(schema *SchemaProperty, t reflect.Type, visiting map[reflect.Type]bool) error {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		jsonTag := field.Tag.Get("json")
+		if jsonTag == "-" {
+			continue
+		}
+		name, tagOpts, _ := strings.Cut(jsonTag, ",")
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			for embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				if err := addStructFields(schema, embedded, visiting); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		prop, err := schemaForType(field.Type, visiting)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", field.Name, err)
+		}
+		if desc := field.Tag.Get("description"); desc != "" {
+			prop.Description = desc
+		}
+
+		required := !strings.Contains(tagOpts, "omitempty") && field.Type.Kind() != reflect.Ptr
+		explicit, err := applySchemaTag(prop, field.Tag.Get("jsonschema"))
+		if err != nil {
+			return fmt.Errorf("field %s: %w", field.Name, err)
+		}
+		if explicit != nil {
+			required = *explicit
+		}
+
+		schema.Properties[name] = prop
+		if required {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	return nil
+}
+
+// applySchemaTag applies a `jsonschema:"key=value,..."` tag. It returns a non-nil
+// bool when the tag explicitly marks the field as required or optional.
+func applySchemaTag(prop *SchemaProperty, tag string) (*bool, error) {
+	if tag == "" {
+		return nil, nil
+	}
+
+	var required *bool
+	for _, part := range strings.Split(tag, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "":
+		case "required":
+			required = ValuePtr(true)
+		case "optional":
+			required = ValuePtr(false)
+		case "description":
+			prop.Description = value
+		case "format":
+			prop.Format = value
+		case "pattern":
+			prop.Pattern = value
+		case "enum":
+			for _, option := range strings.Split(value, "|") {
+				enumValue, err := parseTagValue(prop.Type, option)
+				if err != nil {
+					return nil, fmt.Errorf("invalid enum value %q: %w", option, err)
+				}
+				prop.Enum = append(prop.Enum, enumValue)
+			}
+		case "default":
+			defaultValue, err := parseTagValue(prop.Type, value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid default %q: %w", value, err)
+			}
+			prop.Default = defaultValue
+		case "minimum", "maximum", "multipleOf":
+			number, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s %q: %w", key, value, err)
+			}
+			switch key {
+			case "minimum":
+				prop.Minimum = &number
+			case "maximum":
+				prop.Maximum = &number
+			default:
+				prop.MultipleOf = &number
+			}
+		case "minLength", "maxLength", "minItems", "maxItems":
+			number, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s %q: %w", key, value, err)
+			}
+			switch key {
+			case "minLength":
+				prop.MinLength = &number
+			case "maxLength":
+				prop.MaxLength = &number
+			case "minItems":
+				prop.MinItems = &number
+			default:
+				prop.MaxItems = &number
+			}
+		case "uniqueItems":
+			prop.UniqueItems = true
+		default:
+			return nil, fmt.Errorf("unknown jsonschema tag key %q", key)
+		}
+	}
+	return required, nil
+}
+
+func parseTagValue(schemaType, value string) (interface{}, error) {
+	switch schemaType {
+	case "integer":
+		return strconv.ParseInt(value, 10, 64)
+	case "number":
+		return strconv.ParseFloat(value, 64)
+	case "boolean":
+		return strconv.ParseBool(value)
+	}
+	return value, nil
+}
+
+// ToolArgumentError reports tool arguments that do not satisfy the tool's InputSchema.
+type ToolArgumentError struct {
+	Path    string
+	Message string
+}
+
+func (e *ToolArgumentError) Error() string {
+	if e.Path == "" {
+		return "invalid tool arguments: " + e.Message
+	}
+	return fmt.Sprintf("invalid tool arguments at %s: %s", e.Path, e.Message)
+}
+
+// ValidateJSON checks raw JSON against a SchemaProperty. It returns a *ToolArgumentError
+// describing the first violation found.
+func ValidateJSON(schema *SchemaProperty, data json.RawMessage) error {
+	var value interface{}
+	decoder := json.NewDecoder(strings.NewReader(string(data)))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return &ToolArgumentError{Message: fmt.Sprintf("malformed JSON: %v", err)}
+	}
+	return validateValue(schema, value, "$")
+}
+
+func validateValue(schema *SchemaProperty, value interface{}, path string) error {
+	if schema == nil {
+		return nil
+	}
+	fail := func(format string, args ...interface{}) error {
+		return &ToolArgumentError{Path: path, Message: fmt.Sprintf(format, args...)}
+	}
+
+	if value == nil {
+		if schema.Type != "" && schema.Type != "null" {
+			return fail("expected %s, got null", schema.Type)
+		}
+		return nil
+	}
+
+	switch schema.Type {
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			return fail("expected string, got %s", jsonTypeName(value))
+		}
+		length := utf8.RuneCountInString(s)
+		if schema.MinLength != nil && length < *schema.MinLength {
+			return fail("length %d is shorter than %d", length, *schema.MinLength)
+		}
+		if schema.MaxLength != nil && length > *schema.MaxLength {
+			return fail("length %d is longer than %d", length, *schema.MaxLength)
+		}
+		if schema.Pattern != "" {
+			re, err := regexp.Compile(schema.Pattern)
+			if err != nil {
+				return fail("invalid pattern %q: %v", schema.Pattern, err)
+			}
+			if !re.MatchString(s) {
+				return fail("%q does not match pattern %q", s, schema.Pattern)
+			}
+		}
+	case "integer", "number":
+		n, ok := value.(json.Number)
+		if !ok {
+			return fail("expected %s, got %s", schema.Type, jsonTypeName(value))
+		}
+		f, err := n.Float64()
+		if err != nil {
+			return fail("invalid number %s", n)
+		}
+		if schema.Type == "integer" && f != math.Trunc(f) {
+			return fail("expected integer, got %s", n)
+		}
+		if schema.Minimum != nil && f < *schema.Minimum {
+			return fail("%s is less than minimum %v", n, *schema.Minimum)
+		}
+		if schema.Maximum != nil && f > *schema.Maximum {
+			return fail("%s is greater than maximum %v", n, *schema.Maximum)
+		}
+		if schema.MultipleOf != nil && *schema.MultipleOf != 0 {
+			if q := f / *schema.MultipleOf; q != math.Trunc(q) {
+				return fail("%s is not a multiple of %v", n, *schema.MultipleOf)
+			}
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fail("expected boolean, got %s", jsonTypeName(value))
+		}
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			return fail("expected array, got %s", jsonTypeName(value))
+		}
+		if schema.MinItems != nil && len(items) < *schema.MinItems {
+			return fail("has %d items, fewer than %d", len(items), *schema.MinItems)
+		}
+		if schema.MaxItems != nil && len(items) > *schema.MaxItems {
+			return fail("has %d items, more than %d", len(items), *schema.MaxItems)
+		}
+		seen := map[string]bool{}
+		for i, item := range items {
+			if err := validateValue(schema.Items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+			if schema.UniqueItems {
+				key := canonicalJSON(item)
+				if seen[key] {
+					return fail("item %d is not unique", i)
+				}
+				seen[key] = true
+			}
+		}
+	case "object":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return fail("expected object, got %s", jsonTypeName(value))
+		}
+		for _, name := range schema.Required {
+			if _, ok := obj[name]; !ok {
+				return &ToolArgumentError{Path: path + "." + name, Message: "required property is missing"}
+			}
+		}
+		for name, propValue := range obj {
+			prop, ok := schema.Properties[name]
+			if !ok {
+				if schema.AdditionalProperties != nil && !*schema.AdditionalProperties {
+					return &ToolArgumentError{Path: path + "." + name, Message: "unknown property"}
+				}
+				continue
+			}
+			if propValue == nil && !slices.Contains(schema.Required, name) {
+				// Optional fields, including Go pointers, accept null as "not set".
+				continue
+			}
+			if err := validateValue(prop, propValue, path+"."+name); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(schema.Enum) > 0 {
+		key := canonicalJSON(value)
+		for _, option := range schema.Enum {
+			if canonicalJSON(option) == key {
+				return nil
+			}
+		}
+		return fail("%s is not one of %s", key, canonicalJSON(schema.Enum))
+	}
+	if schema.Const != nil && canonicalJSON(schema.Const) != canonicalJSON(value) {
+		return fail("%s does not equal %s", canonicalJSON(value), canonicalJSON(schema.Const))
+	}
+	return nil
+}
+
+// canonicalJSON renders a value for equality checks; numbers are normalised so that
+// json.Number("1") and int64(1) compare equal.
+func canonicalJSON(value interface{}) string {
+	if n, ok := value.(json.Number); ok {
+		if f, err := n.Float64(); err == nil {
+			value = f
+		}
+	}
+	switch v := value.(type) {
+	case int64:
+		value = float64(v)
+	case int:
+		value = float64(v)
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+func jsonTypeName(value interface{}) string {
+	switch value.(type) {
+	case string:
+		return "string"
+	case json.Number, float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	case nil:
+		return "null"
+	}
+	return fmt.Sprintf("%T", value)
+}
@@ -0,0 +1,195 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ToolCallPrefix marks a GenerateText result that carries tool calls instead of text.
+// The remainder of the string is a JSON array of ToolCall values.
+const ToolCallPrefix = "TOOL_CALL::"
+
+// ToolCall is a single tool invocation requested by the model.
+type ToolCall struct {
+	ID    string          `json:"id"`
+	Name  string          `json:"name"`
+	Input json.RawMessage `json:"input"`
+}
+
+// ToolResult is the outcome of executing a ToolCall, sent back to the model in a follow-up turn.
+type ToolResult struct {
+	ToolCallID string `json:"tool_call_id"`
+	Name       string `json:"name"`
+	Content    string `json:"content"`
+	IsError    bool   `json:"is_error,omitempty"`
+}
+
+// FormatToolCalls encodes tool calls as a ToolCallPrefix-tagged GenerateText result.
+func FormatToolCalls(calls []ToolCall) (string, error) {
+	if len(calls) == 0 {
+		return "", errors.New("no tool calls to format")
+	}
+	normalized := make([]ToolCall, len(calls))
+	copy(normalized, calls)
+	for i := range normalized {
+		if len(normalized[i].Input) == 0 {
+			normalized[i].Input = json.RawMessage("{}")
+		}
+	}
+	data, err := json.Marshal(normalized)
+	if err != nil {
+		return "", err
+	}
+	return ToolCallPrefix + string(data), nil
+}
+
+// ParseToolCalls decodes a GenerateText result produced by FormatToolCalls.
+// The boolean is false when the text is an ordinary completion.
+func ParseToolCalls(text string) ([]ToolCall, bool, error) {
+	if !strings.HasPrefix(text, ToolCallPrefix) {
+		return nil, false, nil
+	}
+	var calls []ToolCall
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(text, ToolCallPrefix)), &calls); err != nil {
+		return nil, true, fmt.Errorf("failed to decode tool calls: %w", err)
+	}
+	return calls, true, nil
+}
+
+// toolHandler executes a tool against raw JSON arguments and returns the result payload.
+type toolHandler func(ctx context.Context, input json.RawMessage) (string, error)
+
+// NewFuncTool builds a self-describing, executable Tool from a Go function. The InputSchema
+// is reflected from Args, which must be a struct (or pointer to struct). Field names follow
+// `json` tags; fields tagged `omitempty` or declared as pointers are optional. Descriptions
+// come from the `description` tag and constraints from the `jsonschema` tag, for example:
+//
+//	type WeatherArgs struct {
+//	    City string `json:"city" description:"City name"`
+//	    Unit string `json:"unit,omitempty" jsonschema:"enum=celsius|fahrenheit"`
+//	}
+//
+// Incoming arguments are validated against the schema before fn is called, and the
+// result is marshalled to JSON (strings are passed through unchanged).
+func NewFuncTool[Args any, Result any](name, description string, fn func(ctx context.Context, args Args) (Result, error)) (*Tool, error) {
+	if name == "" {
+		return nil, errors.New("tool name cannot be empty")
+	}
+	if fn == nil {
+		return nil, fmt.Errorf("tool '%s' has no function", name)
+	}
+
+	var zero Args
+	schema, err := SchemaFor(zero)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reflect arguments for tool '%s': %w", name, err)
+	}
+	if schema.Type != "object" {
+		return nil, fmt.Errorf("arguments for tool '%s' must be a struct, got %s", name, schema.Type)
+	}
+
+	tool := &Tool{Name: name, Description: description, InputSchema: schema}
+	tool.handler = func(ctx context.Context, input json.RawMessage) (string, error) {
+		if len(input) == 0 || string(input) == "null" {
+			input = json.RawMessage("{}")
+		}
+		if err := ValidateJSON(schema, input); err != nil {
+			return "", err
+		}
+
+		var args Args
+		if err := json.Unmarshal(input, &args); err != nil {
+			return "", &ToolArgumentError{Message: err.Error()}
+		}
+
+		result, err := fn(ctx, args)
+		if err != nil {
+			return "", err
+		}
+		return marshalToolResult(result)
+	}
+	return tool, nil
+}
+
+// MustNewFuncTool is like NewFuncTool but panics if the tool cannot be built.
+func MustNewFuncTool[Args any, Result any](name, description string, fn func(ctx context.Context, args Args) (Result, error)) *Tool {
+	tool, err := NewFuncTool(name, description, fn)
+	if err != nil {
+		panic(err)
+	}
+	return tool
+}
+
+func marshalToolResult(result interface{}) (string, error) {
+	switch v := result.(type) {
+	case string:
+		return v, nil
+	case json.RawMessage:
+		return string(v), nil
+	case []byte:
+		return string(v), nil
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal tool result: %w", err)
+	}
+	return string(data), nil
+}
+
+// Executable reports whether the tool was built with NewFuncTool and can be executed locally.
+func (t *Tool) Executable() bool {
+	return t != nil && t.handler != nil
+}
+
+// Execute validates the raw JSON arguments, runs the tool and returns the result payload.
+func (t *Tool) Execute(ctx context.Context, input json.RawMessage) (string, error) {
+	if !t.Executable() {
+		return "", fmt.Errorf("tool '%s' is not executable", t.Name)
+	}
+	return t.handler(ctx, input)
+}
+
+// Call executes a tool call and packages the outcome as a ToolResult. Execution and
+// validation errors are reported in the result with IsError set, so they can be
+// returned to the model instead of aborting the conversation.
+func (t *Tool) Call(ctx context.Context, call ToolCall) ToolResult {
+	result := ToolResult{ToolCallID: call.ID, Name: call.Name}
+	content, err := t.Execute(ctx, call.Input)
+	if err != nil {
+		result.Content = err.Error()
+		result.IsError = true
+		return result
+	}
+	result.Content = content
+	return result
+}
+
+// ExecuteToolCalls runs each call against the matching tool by name.
+// Calls that reference an unknown tool produce an error result; nil tools are ignored.
+func ExecuteToolCalls(ctx context.Context, tools []*Tool, calls []ToolCall) []ToolResult {
+	byName := make(map[string]*Tool, len(tools))
+	for _, tool := range tools {
+		if tool != nil {
+			byName[tool.Name] = tool
+		}
+	}
+
+	results := make([]ToolResult, 0, len(calls))
+	for _, call := range calls {
+		tool, ok := byName[call.Name]
+		if !ok {
+			results = append(results, ToolResult{
+				ToolCallID: call.ID,
+				Name:       call.Name,
+				Content:    fmt.Sprintf("unknown tool '%s'", call.Name),
+				IsError:    true,
+			})
+			continue
+		}
+		results = append(results, tool.Call(ctx, call))
+	}
+	return results
+}
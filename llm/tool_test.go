@@ -0,0 +1,123 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type weatherArgs struct {
+	City  string   `json:"city" description:"City name"`
+	Unit  string   `json:"unit,omitempty" jsonschema:"enum=celsius|fahrenheit"`
+	Days  int      `json:"days" jsonschema:"minimum=1,maximum=7"`
+	Tags  []string `json:"tags,omitempty"`
+	Notes *string  `json:"notes"`
+}
+
+type weatherResult struct {
+	City string  `json:"city"`
+	Temp float64 `json:"temp"`
+}
+
+func TestNewFuncToolSchema(t *testing.T) {
+	tool, err := NewFuncTool("get_weather", "Get the forecast", func(ctx context.Context, args weatherArgs) (weatherResult, error) {
+		return weatherResult{}, nil
+	})
+	if err != nil {
+		t.Fatalf("NewFuncTool failed: %v", err)
+	}
+
+	schema := tool.InputSchema
+	if schema.Type != "object" {
+		t.Fatalf("expected object schema, got %q", schema.Type)
+	}
+	if !reflect.DeepEqual(schema.Required, []string{"city", "days"}) {
+		t.Errorf("unexpected required fields: %v", schema.Required)
+	}
+	if schema.Properties["city"].Description != "City name" {
+		t.Errorf("missing description: %+v", schema.Properties["city"])
+	}
+	if schema.Properties["days"].Type != "integer" || *schema.Properties["days"].Maximum != 7 {
+		t.Errorf("unexpected days schema: %+v", schema.Properties["days"])
+	}
+	if got := schema.Properties["unit"].Enum; !reflect.DeepEqual(got, []interface{}{"celsius", "fahrenheit"}) {
+		t.Errorf("unexpected enum: %v", got)
+	}
+	if schema.Properties["tags"].Items.Type != "string" {
+		t.Errorf("unexpected tags schema: %+v", schema.Properties["tags"])
+	}
+}
+
+func TestFuncToolCall(t *testing.T) {
+	tool := MustNewFuncTool("get_weather", "Get the forecast", func(ctx context.Context, args weatherArgs) (weatherResult, error) {
+		if args.City == "Atlantis" {
+			return weatherResult{}, errors.New("city not found")
+		}
+		return weatherResult{City: args.City, Temp: 21.5}, nil
+	})
+
+	result := tool.Call(context.Background(), ToolCall{ID: "call_1", Name: "get_weather", Input: json.RawMessage(`{"city":"Seoul","days":3}`)})
+	if result.IsError {
+		t.Fatalf("unexpected error result: %s", result.Content)
+	}
+	if result.ToolCallID != "call_1" || result.Content != `{"city":"Seoul","temp":21.5}` {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	optional := tool.Call(context.Background(), ToolCall{ID: "call_2", Input: json.RawMessage(`{"city":"Seoul","days":3,"unit":null,"notes":null}`)})
+	if optional.IsError {
+		t.Errorf("null optional fields should be accepted, got %+v", optional)
+	}
+
+	invalid := []struct {
+		input string
+		want  string
+	}{
+		{`{"days":3}`, "$.city"},
+		{`{"city":null,"days":3}`, "expected string, got null"},
+		{`{"city":"Seoul","days":9}`, "greater than maximum"},
+		{`{"city":"Seoul","days":2.5}`, "expected integer"},
+		{`{"city":"Seoul","days":1,"unit":"kelvin"}`, "not one of"},
+		{`{"city":"Seoul","days":1,"extra":true}`, "unknown property"},
+		{`{"city":`, "malformed JSON"},
+	}
+	for _, tt := range invalid {
+		result := tool.Call(context.Background(), ToolCall{ID: "x", Input: json.RawMessage(tt.input)})
+		if !result.IsError || !strings.Contains(result.Content, tt.want) {
+			t.Errorf("input %s: expected error containing %q, got %+v", tt.input, tt.want, result)
+		}
+	}
+
+	failed := tool.Call(context.Background(), ToolCall{ID: "y", Input: json.RawMessage(`{"city":"Atlantis","days":1}`)})
+	if !failed.IsError || failed.Content != "city not found" {
+		t.Errorf("expected function error in result, got %+v", failed)
+	}
+}
+
+func TestToolCallsRoundTrip(t *testing.T) {
+	original := []ToolCall{{ID: "1", Name: "a"}}
+	text, err := FormatToolCalls(original)
+	if err != nil {
+		t.Fatalf("FormatToolCalls failed: %v", err)
+	}
+	if original[0].Input != nil {
+		t.Errorf("FormatToolCalls modified its argument: %+v", original)
+	}
+	calls, ok, err := ParseToolCalls(text)
+	if err != nil || !ok || len(calls) != 1 || calls[0].Name != "a" || string(calls[0].Input) != "{}" {
+		t.Fatalf("unexpected parse result: %+v %v %v", calls, ok, err)
+	}
+	if _, ok, _ := ParseToolCalls("plain text"); ok {
+		t.Error("plain text should not parse as tool calls")
+	}
+}
+
+func TestExecuteToolCallsSkipsNilTools(t *testing.T) {
+	results := ExecuteToolCalls(context.Background(), []*Tool{nil}, []ToolCall{{ID: "1", Name: "a"}})
+	if len(results) != 1 || !results[0].IsError {
+		t.Fatalf("expected an unknown tool result, got %+v", results)
+	}
+}
@@ -0,0 +1,74 @@
+package logger
+
+import (
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+// ContentPolicy controls whether prompts and generated text may appear in logs.
+type ContentPolicy int32
+
+const (
+	// ContentOff logs only the length of content. It is the default.
+	ContentOff ContentPolicy = iota
+	// ContentTruncated logs content cut to the limit set with SetContentLimit.
+	ContentTruncated
+	// ContentFull logs content in full.
+	ContentFull
+)
+
+// DefaultContentLimit is the number of characters kept by ContentTruncated.
+const DefaultContentLimit = 200
+
+var (
+	contentPolicy atomic.Int32
+	contentLimit  atomic.Int32
+)
+
+func init() {
+	contentLimit.Store(DefaultContentLimit)
+}
+
+// SetContentPolicy sets the content policy that all providers follow.
+func SetContentPolicy(policy ContentPolicy) {
+	contentPolicy.Store(int32(policy))
+}
+
+// CurrentContentPolicy returns the content policy in effect.
+func CurrentContentPolicy() ContentPolicy {
+	return ContentPolicy(contentPolicy.Load())
+}
+
+// SetContentLimit sets how many characters ContentTruncated keeps.
+func SetContentLimit(limit int) {
+	if limit > 0 {
+		contentLimit.Store(int32(limit))
+	}
+}
+
+// Content returns text as the content policy allows it to be logged, and false when
+// the policy is ContentOff.
+func Content(text string) (string, bool) {
+	switch CurrentContentPolicy() {
+	case ContentFull:
+		return text, true
+	case ContentTruncated:
+		limit := int(contentLimit.Load())
+		if utf8.RuneCountInString(text) <= limit {
+			return text, true
+		}
+		runes := []rune(text)
+		return string(runes[:limit]) + "…", true
+	}
+	return "", false
+}
+
+// LogContent logs content such as a prompt or generated text at debug level, as the
+// content policy allows: only its length when content logging is off.
+func LogContent(log Logger, message, text string) {
+	if content, ok := Content(text); ok {
+		log.Debugf("%s: %s", message, content)
+		return
+	}
+	log.Debugf("%s (%d chars)", message, utf8.RuneCountInString(text))
+}
@@ -0,0 +1,17 @@
+package logger
+
+import "context"
+
+type requestIDKey struct{}
+
+// WithRequestID returns a context carrying a request ID, which the logging middleware
+// attaches to its entries.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request ID set with WithRequestID.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
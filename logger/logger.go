@@ -1,5 +1,14 @@
 package logger
 
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/ulgerang/llm-module/redact"
+)
+
 // Logger defines the minimal logging interface expected by llm-module providers.
 type Logger interface {
 	Debug(message string)
@@ -11,3 +20,144 @@ type Logger interface {
 	Error(message string, err error)
 	Errorf(format string, args ...interface{})
 }
+
+// FieldLogger is a Logger that can attach structured key/value fields to its entries.
+// The slog, zap and zerolog adapters implement it.
+type FieldLogger interface {
+	Logger
+	With(fields ...Field) Logger
+}
+
+// Field is a structured key/value pair attached to log entries.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// F creates a Field.
+func F(key string, value interface{}) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Provider is the field naming the LLM provider, such as "openai".
+func Provider(name string) Field { return F("provider", name) }
+
+// Model is the field naming the model that served a request.
+func Model(name string) Field { return F("model", name) }
+
+// RequestID is the field carrying a caller-supplied request ID.
+func RequestID(id string) Field { return F("request_id", id) }
+
+// Latency is the field carrying the duration of a call.
+func Latency(d time.Duration) Field { return F("latency", d) }
+
+// With returns a logger that adds fields to every entry. Loggers that do not implement
+// FieldLogger get the fields appended to each message as key=value pairs.
+func With(log Logger, fields ...Field) Logger {
+	if len(fields) == 0 {
+		return log
+	}
+	if fl, ok := log.(FieldLogger); ok {
+		return fl.With(fields...)
+	}
+	if sl, ok := log.(*suffixLogger); ok {
+		return &suffixLogger{base: sl.base, suffix: sl.suffix + formatFields(fields)}
+	}
+	return &suffixLogger{base: log, suffix: formatFields(fields)}
+}
+
+func formatFields(fields []Field) string {
+	var b strings.Builder
+	for _, field := range fields {
+		fmt.Fprintf(&b, " %s=%v", field.Key, field.Value)
+	}
+	return b.String()
+}
+
+// suffixLogger appends formatted fields to the messages of a plain Logger. Messages and
+// errors are redacted, since the base logger is not one of this module's adapters.
+type suffixLogger struct {
+	base   Logger
+	suffix string
+}
+
+func (l *suffixLogger) format(message string) string {
+	return redact.String(message + l.suffix)
+}
+
+func (l *suffixLogger) Debug(message string) { l.base.Debug(l.format(message)) }
+func (l *suffixLogger) Debugf(format string, args ...interface{}) {
+	l.base.Debug(l.format(fmt.Sprintf(format, args...)))
+}
+func (l *suffixLogger) Info(message string) { l.base.Info(l.format(message)) }
+func (l *suffixLogger) Infof(format string, args ...interface{}) {
+	l.base.Info(l.format(fmt.Sprintf(format, args...)))
+}
+func (l *suffixLogger) Warning(message string) { l.base.Warning(l.format(message)) }
+func (l *suffixLogger) Warningf(format string, args ...interface{}) {
+	l.base.Warning(l.format(fmt.Sprintf(format, args...)))
+}
+func (l *suffixLogger) Error(message string, err error) {
+	l.base.Error(l.format(message), redactError(err))
+}
+func (l *suffixLogger) Errorf(format string, args ...interface{}) {
+	l.base.Errorf("%s", l.format(fmt.Sprintf(format, args...)))
+}
+
+// Level is the severity of a log entry.
+type Level int32
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarning
+	LevelError
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarning:
+		return "warning"
+	case LevelError:
+		return "error"
+	}
+	return fmt.Sprintf("level(%d)", int32(l))
+}
+
+var minLevel atomic.Int32
+
+func init() {
+	minLevel.Store(int32(LevelInfo))
+}
+
+// SetLevel sets the minimum level written by the adapters in this module. The
+// default is LevelInfo. Custom Logger implementations apply their own filtering.
+func SetLevel(level Level) {
+	minLevel.Store(int32(level))
+}
+
+// Enabled reports whether entries at level are written under the current SetLevel.
+func Enabled(level Level) bool {
+	return level >= Level(minLevel.Load())
+}
+
+// Nop returns a Logger that discards everything.
+func Nop() Logger {
+	return nopLogger{}
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(string)                    {}
+func (nopLogger) Debugf(string, ...interface{})   {}
+func (nopLogger) Info(string)                     {}
+func (nopLogger) Infof(string, ...interface{})    {}
+func (nopLogger) Warning(string)                  {}
+func (nopLogger) Warningf(string, ...interface{}) {}
+func (nopLogger) Error(string, error)             {}
+func (nopLogger) Errorf(string, ...interface{})   {}
+func (nopLogger) With(...Field) Logger            { return nopLogger{} }
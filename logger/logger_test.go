@@ -0,0 +1,165 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/ulgerang/llm-module/llm"
+)
+
+// recordingLogger is a plain Logger without field support.
+type recordingLogger struct {
+	lines []string
+}
+
+func (l *recordingLogger) Debug(m string)                      { l.lines = append(l.lines, "DEBUG "+m) }
+func (l *recordingLogger) Debugf(f string, a ...interface{})   { l.Debug(fmt.Sprintf(f, a...)) }
+func (l *recordingLogger) Info(m string)                       { l.lines = append(l.lines, "INFO "+m) }
+func (l *recordingLogger) Infof(f string, a ...interface{})    { l.Info(fmt.Sprintf(f, a...)) }
+func (l *recordingLogger) Warning(m string)                    { l.lines = append(l.lines, "WARN "+m) }
+func (l *recordingLogger) Warningf(f string, a ...interface{}) { l.Warning(fmt.Sprintf(f, a...)) }
+func (l *recordingLogger) Error(m string, _ error)             { l.lines = append(l.lines, "ERROR "+m) }
+func (l *recordingLogger) Errorf(f string, a ...interface{})   { l.Error(fmt.Sprintf(f, a...), nil) }
+
+func newJSONSlog(buf *bytes.Buffer) *Slog {
+	return NewSlog(slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+}
+
+func decodeLines(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var entries []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("invalid JSON log line %q: %v", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestWithAppendsFieldsToPlainLoggers(t *testing.T) {
+	base := &recordingLogger{}
+	log := With(With(base, Provider("openai")), Model("gpt-4o"))
+	log.Infof("sent %d messages", 2)
+
+	if len(base.lines) != 1 || base.lines[0] != "INFO sent 2 messages provider=openai model=gpt-4o" {
+		t.Errorf("lines = %q", base.lines)
+	}
+}
+
+func TestSlogAdapterFieldsAndLevel(t *testing.T) {
+	defer SetLevel(LevelInfo)
+	var buf bytes.Buffer
+	log := With(newJSONSlog(&buf), Provider("claude"), RequestID("req-1"))
+
+	log.Debug("hidden")
+	log.Error("failed", errors.New("boom"))
+	SetLevel(LevelDebug)
+	log.Debug("shown")
+
+	entries := decodeLines(t, &buf)
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %v", entries)
+	}
+	if entries[0]["msg"] != "failed" || entries[0]["error"] != "boom" || entries[0]["provider"] != "claude" || entries[0]["request_id"] != "req-1" {
+		t.Errorf("unexpected entry %v", entries[0])
+	}
+	if entries[1]["msg"] != "shown" {
+		t.Errorf("unexpected entry %v", entries[1])
+	}
+}
+
+func TestAdaptersRedactSecrets(t *testing.T) {
+	const key = "sk-ant-REDACTED"
+	var buf bytes.Buffer
+	plain := &recordingLogger{}
+	for _, log := range []Logger{With(newJSONSlog(&buf), F("auth", "Bearer "+key)), With(plain, Provider("claude"))} {
+		log.Warningf("retrying with x-api-key %s", key)
+		log.Error("request failed", fmt.Errorf("claude API error (status 401): invalid key %s", key))
+	}
+
+	output := buf.String() + strings.Join(plain.lines, "\n")
+	if strings.Contains(output, key) {
+		t.Errorf("key material leaked into logs:\n%s", output)
+	}
+	if len(plain.lines) != 2 || len(decodeLines(t, &buf)) != 2 {
+		t.Errorf("expected 2 entries from each logger, got %q and %q", plain.lines, buf.String())
+	}
+}
+
+func TestContentPolicy(t *testing.T) {
+	defer SetContentPolicy(ContentOff)
+	defer SetContentLimit(DefaultContentLimit)
+	defer SetLevel(LevelInfo)
+	SetLevel(LevelDebug)
+
+	base := &recordingLogger{}
+	LogContent(base, "Generated text", "secret answer")
+	SetContentPolicy(ContentTruncated)
+	SetContentLimit(6)
+	LogContent(base, "Generated text", "secret answer")
+	SetContentPolicy(ContentFull)
+	LogContent(base, "Generated text", "secret answer")
+
+	want := []string{
+		"DEBUG Generated text (13 chars)",
+		"DEBUG Generated text: secret…",
+		"DEBUG Generated text: secret answer",
+	}
+	if strings.Join(base.lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("lines = %q", base.lines)
+	}
+}
+
+func TestNopDiscards(t *testing.T) {
+	log := With(Nop(), Provider("x"))
+	log.Error("ignored", errors.New("boom"))
+	if _, ok := log.(FieldLogger); !ok {
+		t.Error("Nop should keep field support")
+	}
+}
+
+type stubProvider struct{}
+
+func (stubProvider) GenerateText(context.Context, string, ...llm.GenerationOption) (string, *llm.UsageInfo, error) {
+	return "private reply", &llm.UsageInfo{InputTokens: 5, OutputTokens: 7}, nil
+}
+func (stubProvider) GenerateTextStream(_ context.Context, _ string, out chan<- llm.StreamChunk, _ ...llm.GenerationOption) (*llm.UsageInfo, error) {
+	close(out)
+	return nil, nil
+}
+func (stubProvider) GetModelName() string { return "stub-model" }
+func (stubProvider) Close() error         { return nil }
+
+func TestMiddlewareLogsStructuredCall(t *testing.T) {
+	var buf bytes.Buffer
+	provider := llm.Wrap(stubProvider{}, Middleware(newJSONSlog(&buf)))
+
+	ctx := WithRequestID(context.Background(), "req-42")
+	if _, _, err := provider.GenerateText(ctx, "private prompt"); err != nil {
+		t.Fatalf("GenerateText failed: %v", err)
+	}
+
+	entries := decodeLines(t, &buf)
+	if len(entries) != 1 {
+		t.Fatalf("expected only the completion entry at info level, got %v", entries)
+	}
+	entry := entries[0]
+	if entry["msg"] != "LLM call completed" || entry["provider"] != "logger" || entry["model"] != "stub-model" ||
+		entry["request_id"] != "req-42" || entry["output_tokens"] != float64(7) || entry["latency"] == nil {
+		t.Errorf("unexpected entry %v", entry)
+	}
+	if strings.Contains(buf.String(), "private") {
+		t.Error("content must not be logged by default")
+	}
+}
@@ -0,0 +1,45 @@
+package logger
+
+import (
+	"context"
+	"time"
+
+	"github.com/ulgerang/llm-module/llm"
+)
+
+// Middleware returns an llm.Middleware that logs every call with provider, model,
+// request ID and latency fields. Prompts and responses are logged at debug level as
+// the content policy allows.
+func Middleware(log Logger) llm.Middleware {
+	return func(next llm.Handler) llm.Handler {
+		return func(ctx context.Context, req *llm.Request, emit llm.EmitFunc) (*llm.Response, error) {
+			fields := []Field{Provider(req.Provider), Model(req.Model), F("stream", req.Stream)}
+			if id := RequestIDFromContext(ctx); id != "" {
+				fields = append(fields, RequestID(id))
+			}
+			callLog := With(log, fields...)
+			LogContent(callLog, "Prompt", req.Prompt)
+
+			start := time.Now()
+			resp, err := next(ctx, req, emit)
+			callLog = With(callLog, Latency(time.Since(start)))
+			if err != nil {
+				With(callLog, F("error_class", string(llm.ClassifyError(err)))).Error("LLM call failed", err)
+				return resp, err
+			}
+
+			if resp != nil && resp.Usage != nil {
+				callLog = With(callLog,
+					F("input_tokens", resp.Usage.InputTokens),
+					F("output_tokens", resp.Usage.OutputTokens),
+					F("response_cache_hit", resp.Usage.ResponseCacheHit),
+				)
+			}
+			callLog.Info("LLM call completed")
+			if resp != nil {
+				LogContent(callLog, "Response", resp.Text)
+			}
+			return resp, nil
+		}
+	}
+}
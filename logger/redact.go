@@ -0,0 +1,32 @@
+package logger
+
+import "github.com/ulgerang/llm-module/redact"
+
+// RedactValue returns value with secrets removed by the redact package when it is a
+// string or an error; other values are returned unchanged. The adapters in this module
+// apply it to every message and field, and custom FieldLogger implementations should too.
+func RedactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		return redact.String(v)
+	case error:
+		return redact.String(v.Error())
+	}
+	return value
+}
+
+// redactedError scrubs the message of an error passed on to a plain Logger while
+// keeping it unwrappable.
+type redactedError struct {
+	err error
+}
+
+func (e redactedError) Error() string { return redact.String(e.err.Error()) }
+func (e redactedError) Unwrap() error { return e.err }
+
+func redactError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return redactedError{err: err}
+}
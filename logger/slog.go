@@ -0,0 +1,74 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/ulgerang/llm-module/redact"
+)
+
+// Slog adapts a *slog.Logger. Entries below the level set with SetLevel are dropped
+// before reaching the slog handler, which applies its own level as well. Messages,
+// errors and string fields are redacted with the redact package.
+type Slog struct {
+	logger *slog.Logger
+}
+
+// NewSlog wraps logger; a nil logger uses slog.Default().
+func NewSlog(logger *slog.Logger) *Slog {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Slog{logger: logger}
+}
+
+// Default returns a Logger that writes to slog.Default().
+func Default() Logger {
+	return NewSlog(nil)
+}
+
+func (l *Slog) log(level Level, slogLevel slog.Level, message string, attrs ...slog.Attr) {
+	if !Enabled(level) {
+		return
+	}
+	l.logger.LogAttrs(context.Background(), slogLevel, redact.String(message), attrs...)
+}
+
+func (l *Slog) Debug(message string) { l.log(LevelDebug, slog.LevelDebug, message) }
+func (l *Slog) Debugf(format string, args ...interface{}) {
+	if Enabled(LevelDebug) {
+		l.log(LevelDebug, slog.LevelDebug, fmt.Sprintf(format, args...))
+	}
+}
+func (l *Slog) Info(message string) { l.log(LevelInfo, slog.LevelInfo, message) }
+func (l *Slog) Infof(format string, args ...interface{}) {
+	if Enabled(LevelInfo) {
+		l.log(LevelInfo, slog.LevelInfo, fmt.Sprintf(format, args...))
+	}
+}
+func (l *Slog) Warning(message string) { l.log(LevelWarning, slog.LevelWarn, message) }
+func (l *Slog) Warningf(format string, args ...interface{}) {
+	if Enabled(LevelWarning) {
+		l.log(LevelWarning, slog.LevelWarn, fmt.Sprintf(format, args...))
+	}
+}
+func (l *Slog) Error(message string, err error) {
+	if err == nil {
+		l.log(LevelError, slog.LevelError, message)
+		return
+	}
+	l.log(LevelError, slog.LevelError, message, slog.String("error", redact.String(err.Error())))
+}
+func (l *Slog) Errorf(format string, args ...interface{}) {
+	l.log(LevelError, slog.LevelError, fmt.Sprintf(format, args...))
+}
+
+// With returns a logger that adds fields to every entry.
+func (l *Slog) With(fields ...Field) Logger {
+	args := make([]interface{}, 0, len(fields))
+	for _, field := range fields {
+		args = append(args, slog.Any(field.Key, RedactValue(field.Value)))
+	}
+	return &Slog{logger: l.logger.With(args...)}
+}
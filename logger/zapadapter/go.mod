@@ -0,0 +1,12 @@
+module github.com/ulgerang/llm-module/logger/zapadapter
+
+go 1.23.4
+
+require (
+	github.com/ulgerang/llm-module v0.0.0-00010101000000-000000000000
+	go.uber.org/zap v1.27.0
+)
+
+require go.uber.org/multierr v1.10.0 // indirect
+
+replace github.com/ulgerang/llm-module => ../..
@@ -0,0 +1,85 @@
+// Package zapadapter adapts a *zap.Logger to logger.Logger.
+package zapadapter
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"github.com/ulgerang/llm-module/logger"
+	"github.com/ulgerang/llm-module/redact"
+)
+
+// Logger writes to a *zap.Logger. Entries below the level set with logger.SetLevel
+// are dropped before reaching zap, which applies its own level as well. Messages,
+// errors and string fields are redacted with the redact package.
+type Logger struct {
+	logger *zap.Logger
+}
+
+// New wraps l.
+func New(l *zap.Logger) *Logger {
+	return &Logger{logger: l}
+}
+
+func (l *Logger) Debug(message string) {
+	if logger.Enabled(logger.LevelDebug) {
+		l.logger.Debug(redact.String(message))
+	}
+}
+
+func (l *Logger) Debugf(format string, args ...interface{}) {
+	if logger.Enabled(logger.LevelDebug) {
+		l.logger.Debug(redact.String(fmt.Sprintf(format, args...)))
+	}
+}
+
+func (l *Logger) Info(message string) {
+	if logger.Enabled(logger.LevelInfo) {
+		l.logger.Info(redact.String(message))
+	}
+}
+
+func (l *Logger) Infof(format string, args ...interface{}) {
+	if logger.Enabled(logger.LevelInfo) {
+		l.logger.Info(redact.String(fmt.Sprintf(format, args...)))
+	}
+}
+
+func (l *Logger) Warning(message string) {
+	if logger.Enabled(logger.LevelWarning) {
+		l.logger.Warn(redact.String(message))
+	}
+}
+
+func (l *Logger) Warningf(format string, args ...interface{}) {
+	if logger.Enabled(logger.LevelWarning) {
+		l.logger.Warn(redact.String(fmt.Sprintf(format, args...)))
+	}
+}
+
+func (l *Logger) Error(message string, err error) {
+	if !logger.Enabled(logger.LevelError) {
+		return
+	}
+	if err == nil {
+		l.logger.Error(redact.String(message))
+		return
+	}
+	l.logger.Error(redact.String(message), zap.String("error", redact.String(err.Error())))
+}
+
+func (l *Logger) Errorf(format string, args ...interface{}) {
+	if logger.Enabled(logger.LevelError) {
+		l.logger.Error(redact.String(fmt.Sprintf(format, args...)))
+	}
+}
+
+// With returns a logger that adds fields to every entry.
+func (l *Logger) With(fields ...logger.Field) logger.Logger {
+	zapFields := make([]zap.Field, 0, len(fields))
+	for _, field := range fields {
+		zapFields = append(zapFields, zap.Any(field.Key, logger.RedactValue(field.Value)))
+	}
+	return New(l.logger.With(zapFields...))
+}
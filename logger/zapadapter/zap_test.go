@@ -0,0 +1,55 @@
+package zapadapter
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/ulgerang/llm-module/logger"
+)
+
+func TestAdapter(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	log := logger.With(New(zap.New(core)), logger.Provider("groq"), logger.Model("llama"))
+
+	log.Debug("dropped by SetLevel")
+	log.Infof("sent %d", 1)
+	log.Error("failed", errors.New("boom"))
+
+	entries := logs.All()
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	if entries[0].Message != "sent 1" || fields["provider"] != "groq" || fields["model"] != "llama" {
+		t.Errorf("unexpected entry %+v", entries[0])
+	}
+	if entries[1].Level != zapcore.ErrorLevel || entries[1].ContextMap()["error"] != "boom" {
+		t.Errorf("unexpected entry %+v", entries[1])
+	}
+}
+
+func TestAdapterRedactsSecrets(t *testing.T) {
+	const key = "gsk_abcdefghijklmnopqrstuvwxyz0123"
+	core, logs := observer.New(zapcore.DebugLevel)
+	log := New(zap.New(core)).With(logger.F("token", key))
+
+	log.Infof("using key %s", key)
+	log.Error("failed", errors.New("invalid api_key="+key))
+
+	for _, entry := range logs.All() {
+		if strings.Contains(entry.Message, key) {
+			t.Errorf("message leaks key: %q", entry.Message)
+		}
+		for name, value := range entry.ContextMap() {
+			if strings.Contains(fmt.Sprint(value), key) {
+				t.Errorf("field %s leaks key: %v", name, value)
+			}
+		}
+	}
+}
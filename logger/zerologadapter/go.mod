@@ -0,0 +1,16 @@
+module github.com/ulgerang/llm-module/logger/zerologadapter
+
+go 1.23.4
+
+require (
+	github.com/rs/zerolog v1.33.0
+	github.com/ulgerang/llm-module v0.0.0-00010101000000-000000000000
+)
+
+require (
+	github.com/mattn/go-colorable v0.1.13 // indirect
+	github.com/mattn/go-isatty v0.0.19 // indirect
+	golang.org/x/sys v0.30.0 // indirect
+)
+
+replace github.com/ulgerang/llm-module => ../..
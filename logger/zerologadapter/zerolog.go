@@ -0,0 +1,84 @@
+// Package zerologadapter adapts a zerolog.Logger to logger.Logger.
+package zerologadapter
+
+import (
+	"fmt"
+
+	"github.com/rs/zerolog"
+
+	"github.com/ulgerang/llm-module/logger"
+	"github.com/ulgerang/llm-module/redact"
+)
+
+// Logger writes to a zerolog.Logger. Entries below the level set with logger.SetLevel
+// are dropped before reaching zerolog, which applies its own level as well. Messages,
+// errors and string fields are redacted with the redact package.
+type Logger struct {
+	logger zerolog.Logger
+}
+
+// New wraps l.
+func New(l zerolog.Logger) *Logger {
+	return &Logger{logger: l}
+}
+
+func (l *Logger) event(level logger.Level) *zerolog.Event {
+	if !logger.Enabled(level) {
+		return nil
+	}
+	switch level {
+	case logger.LevelDebug:
+		return l.logger.Debug()
+	case logger.LevelInfo:
+		return l.logger.Info()
+	case logger.LevelWarning:
+		return l.logger.Warn()
+	}
+	return l.logger.Error()
+}
+
+// A nil *zerolog.Event discards everything, so disabled levels need no checks below.
+
+func (l *Logger) msg(level logger.Level, message string) {
+	if event := l.event(level); event != nil {
+		event.Msg(redact.String(message))
+	}
+}
+
+func (l *Logger) msgf(level logger.Level, format string, args ...interface{}) {
+	if event := l.event(level); event != nil {
+		event.Msg(redact.String(fmt.Sprintf(format, args...)))
+	}
+}
+
+func (l *Logger) Debug(message string) { l.msg(logger.LevelDebug, message) }
+func (l *Logger) Debugf(format string, args ...interface{}) {
+	l.msgf(logger.LevelDebug, format, args...)
+}
+func (l *Logger) Info(message string) { l.msg(logger.LevelInfo, message) }
+func (l *Logger) Infof(format string, args ...interface{}) {
+	l.msgf(logger.LevelInfo, format, args...)
+}
+func (l *Logger) Warning(message string) { l.msg(logger.LevelWarning, message) }
+func (l *Logger) Warningf(format string, args ...interface{}) {
+	l.msgf(logger.LevelWarning, format, args...)
+}
+func (l *Logger) Error(message string, err error) {
+	event := l.event(logger.LevelError)
+	if err != nil {
+		event = event.Str(zerolog.ErrorFieldName, redact.String(err.Error()))
+	}
+	event.Msg(redact.String(message))
+}
+func (l *Logger) Errorf(format string, args ...interface{}) {
+	l.msgf(logger.LevelError, format, args...)
+}
+
+// With returns a logger that adds fields to every entry.
+func (l *Logger) With(fields ...logger.Field) logger.Logger {
+	ctx := l.logger.With()
+	for _, field := range fields {
+		ctx = ctx.Fields([]interface{}{field.Key, logger.RedactValue(field.Value)})
+	}
+	return New(ctx.Logger())
+}
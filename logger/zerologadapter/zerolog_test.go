@@ -0,0 +1,49 @@
+package zerologadapter
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	"github.com/ulgerang/llm-module/logger"
+)
+
+func TestAdapter(t *testing.T) {
+	var buf bytes.Buffer
+	log := logger.With(New(zerolog.New(&buf)), logger.Provider("gemini"), logger.RequestID("req-7"))
+
+	log.Debug("dropped by SetLevel")
+	log.Warningf("retrying in %ds", 2)
+	log.Error("failed", errors.New("boom"))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %q", lines)
+	}
+	var first, second map[string]interface{}
+	json.Unmarshal([]byte(lines[0]), &first)
+	json.Unmarshal([]byte(lines[1]), &second)
+	if first["level"] != "warn" || first["message"] != "retrying in 2s" || first["provider"] != "gemini" || first["request_id"] != "req-7" {
+		t.Errorf("unexpected entry %v", first)
+	}
+	if second["level"] != "error" || second["error"] != "boom" {
+		t.Errorf("unexpected entry %v", second)
+	}
+}
+
+func TestAdapterRedactsSecrets(t *testing.T) {
+	const key = "xai-abcdefghijklmnopqrstuvwxyz0123"
+	var buf bytes.Buffer
+	log := New(zerolog.New(&buf)).With(logger.F("authorization", "Bearer "+key))
+
+	log.Infof("using key %s", key)
+	log.Error("failed", errors.New("rejected key "+key))
+
+	if buf.Len() == 0 || strings.Contains(buf.String(), key) {
+		t.Errorf("expected redacted output, got %s", buf.String())
+	}
+}
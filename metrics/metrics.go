@@ -0,0 +1,123 @@
+// Package metrics records request, latency, token and cost metrics for llm.Provider
+// calls through a small Metrics sink interface.
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/ulgerang/llm-module/llm"
+)
+
+// OutcomeSuccess and OutcomeCacheHit are the outcomes of successful calls. Failed calls
+// use the llm.ErrorClass of their error.
+const (
+	OutcomeSuccess  = "success"
+	OutcomeCacheHit = "cache_hit"
+)
+
+// Observation describes one completed provider call.
+type Observation struct {
+	Provider string
+	Model    string
+	Stream   bool
+	// Outcome is OutcomeSuccess, OutcomeCacheHit or an llm.ErrorClass.
+	Outcome  string
+	Duration time.Duration
+	// TimeToFirstChunk is the delay before the first content chunk of a stream, zero
+	// when no content arrived or the call was not a stream.
+	TimeToFirstChunk time.Duration
+	// OutputTokensPerSecond is measured over the generation time: after the first chunk
+	// for streams, the whole call otherwise. Zero when no output tokens were reported.
+	OutputTokensPerSecond float64
+	Usage                 llm.UsageInfo
+	// Cost is the estimated cost in USD; CostKnown is false when the model has no price.
+	Cost      float64
+	CostKnown bool
+}
+
+// Metrics receives an Observation for every call. Implementations must be safe for
+// concurrent use.
+type Metrics interface {
+	Record(ctx context.Context, observation Observation)
+}
+
+type config struct {
+	pricing Pricing
+	now     func() time.Time
+}
+
+// Option configures the metrics middleware.
+type Option func(*config)
+
+// WithPricing estimates the cost of every call from pricing.
+func WithPricing(pricing Pricing) Option {
+	return func(c *config) {
+		c.pricing = pricing
+	}
+}
+
+// Wrap records metrics for every call of provider into sink.
+func Wrap(provider llm.Provider, sink Metrics, opts ...Option) llm.Provider {
+	return llm.Wrap(provider, Middleware(sink, opts...))
+}
+
+// Middleware returns an llm.Middleware that records metrics for every call into sink.
+func Middleware(sink Metrics, opts ...Option) llm.Middleware {
+	c := &config{now: time.Now}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return func(next llm.Handler) llm.Handler {
+		return func(ctx context.Context, req *llm.Request, emit llm.EmitFunc) (*llm.Response, error) {
+			start := c.now()
+			var firstChunk time.Time
+			if req.Stream {
+				inner := emit
+				emit = func(chunk llm.StreamChunk) error {
+					if firstChunk.IsZero() && (chunk.Delta != "" || len(chunk.ToolCalls) > 0) {
+						firstChunk = c.now()
+					}
+					return inner(chunk)
+				}
+			}
+
+			resp, err := next(ctx, req, emit)
+			end := c.now()
+
+			observation := Observation{
+				Provider: req.Provider,
+				Model:    req.Model,
+				Stream:   req.Stream,
+				Outcome:  OutcomeSuccess,
+				Duration: end.Sub(start),
+			}
+			if resp != nil && resp.Usage != nil {
+				observation.Usage = *resp.Usage
+				if resp.Usage.ResponseCacheHit {
+					observation.Outcome = OutcomeCacheHit
+				}
+			}
+			if err != nil {
+				observation.Outcome = string(llm.ClassifyError(err))
+			}
+
+			generationStart := start
+			if !firstChunk.IsZero() {
+				observation.TimeToFirstChunk = firstChunk.Sub(start)
+				generationStart = firstChunk
+			}
+			if elapsed := end.Sub(generationStart).Seconds(); elapsed > 0 && observation.Usage.OutputTokens > 0 {
+				observation.OutputTokensPerSecond = float64(observation.Usage.OutputTokens) / elapsed
+			}
+
+			if c.pricing != nil {
+				observation.Cost, observation.CostKnown = c.pricing.Estimate(req.Model, observation.Usage)
+			}
+
+			sink.Record(ctx, observation)
+			return resp, err
+		}
+	}
+}
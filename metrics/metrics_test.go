@@ -0,0 +1,130 @@
+package metrics
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ulgerang/llm-module/llm"
+)
+
+type fakeProvider struct {
+	err error
+}
+
+func (p *fakeProvider) GenerateText(_ context.Context, _ string, _ ...llm.GenerationOption) (string, *llm.UsageInfo, error) {
+	if p.err != nil {
+		return "", nil, p.err
+	}
+	return "ok", &llm.UsageInfo{InputTokens: 1000, OutputTokens: 200, CacheHitTokens: 600}, nil
+}
+
+func (p *fakeProvider) GenerateTextStream(_ context.Context, _ string, outChan chan<- llm.StreamChunk, _ ...llm.GenerationOption) (*llm.UsageInfo, error) {
+	defer close(outChan)
+	outChan <- llm.StreamChunk{Delta: "o"}
+	outChan <- llm.StreamChunk{Delta: "k"}
+	outChan <- llm.StreamChunk{IsFinal: true}
+	return &llm.UsageInfo{InputTokens: 10, OutputTokens: 40}, nil
+}
+
+func (p *fakeProvider) GetModelName() string { return "fake-model" }
+func (p *fakeProvider) Close() error         { return nil }
+
+type recorder struct {
+	mu           sync.Mutex
+	observations []Observation
+}
+
+func (r *recorder) Record(_ context.Context, o Observation) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.observations = append(r.observations, o)
+}
+
+// steppingClock advances by step on every call.
+func steppingClock(step time.Duration) func() time.Time {
+	now := time.Unix(0, 0)
+	return func() time.Time {
+		now = now.Add(step)
+		return now
+	}
+}
+
+func withClock(clock func() time.Time) Option {
+	return func(c *config) {
+		c.now = clock
+	}
+}
+
+func TestMiddlewareRecordsTextCall(t *testing.T) {
+	sink := &recorder{}
+	pricing := Pricing{"fake-model": {Input: 2, Output: 10, CacheRead: 0.5}}
+	provider := Wrap(&fakeProvider{}, sink, WithPricing(pricing), withClock(steppingClock(time.Second)))
+
+	if _, _, err := provider.GenerateText(context.Background(), "hi"); err != nil {
+		t.Fatalf("GenerateText failed: %v", err)
+	}
+
+	o := sink.observations[0]
+	if o.Provider != "metrics" || o.Model != "fake-model" || o.Stream || o.Outcome != OutcomeSuccess {
+		t.Errorf("unexpected observation %+v", o)
+	}
+	if o.Duration != time.Second || o.OutputTokensPerSecond != 200 {
+		t.Errorf("duration = %v, tokens/s = %v", o.Duration, o.OutputTokensPerSecond)
+	}
+	// 400 uncached input at $2, 600 cached at $0.5, 200 output at $10 per million.
+	want := (400*2 + 600*0.5 + 200*10) / 1e6
+	if !o.CostKnown || math.Abs(o.Cost-want) > 1e-12 {
+		t.Errorf("cost = %v (known=%v), want %v", o.Cost, o.CostKnown, want)
+	}
+}
+
+func TestMiddlewareRecordsStreamTiming(t *testing.T) {
+	sink := &recorder{}
+	provider := Wrap(&fakeProvider{}, sink, withClock(steppingClock(time.Second)))
+
+	out := make(chan llm.StreamChunk)
+	go func() {
+		for range out {
+		}
+	}()
+	if _, err := provider.GenerateTextStream(context.Background(), "hi", out); err != nil {
+		t.Fatalf("stream failed: %v", err)
+	}
+
+	// Clock calls: start, first chunk, end.
+	o := sink.observations[0]
+	if !o.Stream || o.TimeToFirstChunk != time.Second || o.Duration != 2*time.Second {
+		t.Errorf("unexpected observation %+v", o)
+	}
+	if o.OutputTokensPerSecond != 40 {
+		t.Errorf("tokens/s = %v, want 40", o.OutputTokensPerSecond)
+	}
+	if o.CostKnown {
+		t.Error("cost should be unknown without pricing")
+	}
+}
+
+func TestMiddlewareRecordsErrorClass(t *testing.T) {
+	sink := &recorder{}
+	apiErr := &llm.APIError{Provider: "fake", StatusCode: http.StatusServiceUnavailable}
+	provider := Wrap(&fakeProvider{err: apiErr}, sink)
+
+	provider.GenerateText(context.Background(), "hi")
+	if got := sink.observations[0].Outcome; got != string(llm.ErrorClassOverloaded) {
+		t.Errorf("outcome = %q", got)
+	}
+}
+
+func TestPricingIgnoresResponseCacheHits(t *testing.T) {
+	pricing := Pricing{"m": {Input: 1, Output: 1}}
+	if cost, known := pricing.Estimate("m", llm.UsageInfo{ResponseCacheHit: true}); cost != 0 || !known {
+		t.Errorf("cache hit cost = %v, %v", cost, known)
+	}
+	if _, known := pricing.Estimate("unknown", llm.UsageInfo{InputTokens: 10}); known {
+		t.Error("unknown model should have no price")
+	}
+}
@@ -0,0 +1,59 @@
+package metrics
+
+import "github.com/ulgerang/llm-module/llm"
+
+// Price is the USD price per million tokens of a model. Zero cache prices fall back to
+// the input price.
+type Price struct {
+	Input      float64
+	Output     float64
+	CacheRead  float64
+	CacheWrite float64
+}
+
+// Pricing maps model names to prices. Prices change often, so the module ships no
+// defaults; fill the table from your vendors' current price lists.
+type Pricing map[string]Price
+
+// Estimate returns the cost of usage in USD, and false when the model has no price.
+// Responses served from a local response cache cost nothing.
+func (p Pricing) Estimate(model string, usage llm.UsageInfo) (float64, bool) {
+	price, ok := p[model]
+	if !ok {
+		return 0, false
+	}
+	if usage.ResponseCacheHit {
+		return 0, true
+	}
+
+	cacheRead, cacheWrite := price.CacheRead, price.CacheWrite
+	if cacheRead == 0 {
+		cacheRead = price.Input
+	}
+	if cacheWrite == 0 {
+		cacheWrite = price.Input
+	}
+
+	// InputTokens includes the tokens read from and written to the prompt cache.
+	uncached := usage.InputTokens - usage.CacheHitTokens - usage.CacheCreateTokens
+	if uncached < 0 {
+		uncached = 0
+	}
+	cost := float64(uncached)*price.Input +
+		float64(usage.CacheHitTokens)*cacheRead +
+		float64(usage.CacheCreateTokens)*cacheWrite +
+		float64(usage.OutputTokens)*price.Output
+	return cost / 1e6, true
+}
+
+// PricingFromModels returns the prices of the listed models that report them, such as
+// the models of OpenRouter's catalog.
+func PricingFromModels(models []llm.Model) Pricing {
+	pricing := make(Pricing)
+	for _, model := range models {
+		if model.InputPrice > 0 || model.OutputPrice > 0 {
+			pricing[model.ID] = Price{Input: model.InputPrice, Output: model.OutputPrice}
+		}
+	}
+	return pricing
+}
@@ -0,0 +1,101 @@
+// Package prometheus exports the observations of the metrics middleware as Prometheus
+// collectors. It is a separate module, so that only programs that use it depend on the
+// Prometheus client:
+//
+//	sink, err := prom.New(prom.DefaultRegisterer)
+//	provider = metrics.Wrap(provider, sink, metrics.WithPricing(pricing))
+package prometheus
+
+import (
+	"context"
+	"strconv"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+
+	"github.com/ulgerang/llm-module/metrics"
+)
+
+// Sink is a metrics.Metrics sink that exports Prometheus collectors:
+//
+//	llm_requests_total{provider,model,stream,outcome}
+//	llm_request_duration_seconds{provider,model,stream,outcome}
+//	llm_time_to_first_chunk_seconds{provider,model}
+//	llm_output_tokens_per_second{provider,model}
+//	llm_tokens_total{provider,model,type}  type is input, output, cache_hit or cache_create
+//	llm_cost_usd_total{provider,model}
+type Sink struct {
+	requests         *prom.CounterVec
+	duration         *prom.HistogramVec
+	timeToFirstChunk *prom.HistogramVec
+	tokensPerSecond  *prom.HistogramVec
+	tokens           *prom.CounterVec
+	cost             *prom.CounterVec
+}
+
+// New creates the collectors and registers them with registerer.
+func New(registerer prom.Registerer) (*Sink, error) {
+	p := &Sink{
+		requests: prom.NewCounterVec(prom.CounterOpts{
+			Name: "llm_requests_total",
+			Help: "LLM provider calls by outcome.",
+		}, []string{"provider", "model", "stream", "outcome"}),
+		duration: prom.NewHistogramVec(prom.HistogramOpts{
+			Name:    "llm_request_duration_seconds",
+			Help:    "Duration of LLM provider calls.",
+			Buckets: []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 20, 40, 80, 160},
+		}, []string{"provider", "model", "stream", "outcome"}),
+		timeToFirstChunk: prom.NewHistogramVec(prom.HistogramOpts{
+			Name:    "llm_time_to_first_chunk_seconds",
+			Help:    "Delay before the first content chunk of streaming calls.",
+			Buckets: []float64{0.05, 0.1, 0.25, 0.5, 1, 2, 4, 8, 16},
+		}, []string{"provider", "model"}),
+		tokensPerSecond: prom.NewHistogramVec(prom.HistogramOpts{
+			Name:    "llm_output_tokens_per_second",
+			Help:    "Output token throughput of LLM provider calls.",
+			Buckets: []float64{5, 10, 20, 40, 80, 160, 320, 640},
+		}, []string{"provider", "model"}),
+		tokens: prom.NewCounterVec(prom.CounterOpts{
+			Name: "llm_tokens_total",
+			Help: "Tokens used by LLM provider calls, by token type.",
+		}, []string{"provider", "model", "type"}),
+		cost: prom.NewCounterVec(prom.CounterOpts{
+			Name: "llm_cost_usd_total",
+			Help: "Estimated cost of LLM provider calls in USD.",
+		}, []string{"provider", "model"}),
+	}
+
+	for _, collector := range []prom.Collector{p.requests, p.duration, p.timeToFirstChunk, p.tokensPerSecond, p.tokens, p.cost} {
+		if err := registerer.Register(collector); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// Record updates the collectors from an observation.
+func (p *Sink) Record(_ context.Context, o metrics.Observation) {
+	stream := strconv.FormatBool(o.Stream)
+	p.requests.WithLabelValues(o.Provider, o.Model, stream, o.Outcome).Inc()
+	p.duration.WithLabelValues(o.Provider, o.Model, stream, o.Outcome).Observe(o.Duration.Seconds())
+
+	if o.TimeToFirstChunk > 0 {
+		p.timeToFirstChunk.WithLabelValues(o.Provider, o.Model).Observe(o.TimeToFirstChunk.Seconds())
+	}
+	if o.OutputTokensPerSecond > 0 && !o.Usage.ResponseCacheHit {
+		p.tokensPerSecond.WithLabelValues(o.Provider, o.Model).Observe(o.OutputTokensPerSecond)
+	}
+
+	for tokenType, count := range map[string]int{
+		"input":        o.Usage.InputTokens,
+		"output":       o.Usage.OutputTokens,
+		"cache_hit":    o.Usage.CacheHitTokens,
+		"cache_create": o.Usage.CacheCreateTokens,
+	} {
+		if count > 0 {
+			p.tokens.WithLabelValues(o.Provider, o.Model, tokenType).Add(float64(count))
+		}
+	}
+	if o.CostKnown {
+		p.cost.WithLabelValues(o.Provider, o.Model).Add(o.Cost)
+	}
+}
@@ -0,0 +1,51 @@
+package prometheus
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/ulgerang/llm-module/llm"
+	"github.com/ulgerang/llm-module/metrics"
+)
+
+func TestSink(t *testing.T) {
+	registry := prom.NewRegistry()
+	sink, err := New(registry)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	sink.Record(context.Background(), metrics.Observation{
+		Provider: "openai", Model: "gpt", Stream: true, Outcome: metrics.OutcomeSuccess,
+		Duration: 2 * time.Second, TimeToFirstChunk: 300 * time.Millisecond, OutputTokensPerSecond: 50,
+		Usage: llm.UsageInfo{InputTokens: 100, OutputTokens: 80, CacheHitTokens: 60},
+		Cost:  0.25, CostKnown: true,
+	})
+	sink.Record(context.Background(), metrics.Observation{Provider: "openai", Model: "gpt", Outcome: "rate_limit", Duration: time.Second})
+
+	expected := `
+# HELP llm_requests_total LLM provider calls by outcome.
+# TYPE llm_requests_total counter
+llm_requests_total{model="gpt",outcome="rate_limit",provider="openai",stream="false"} 1
+llm_requests_total{model="gpt",outcome="success",provider="openai",stream="true"} 1
+# HELP llm_tokens_total Tokens used by LLM provider calls, by token type.
+# TYPE llm_tokens_total counter
+llm_tokens_total{model="gpt",provider="openai",type="cache_hit"} 60
+llm_tokens_total{model="gpt",provider="openai",type="input"} 100
+llm_tokens_total{model="gpt",provider="openai",type="output"} 80
+# HELP llm_cost_usd_total Estimated cost of LLM provider calls in USD.
+# TYPE llm_cost_usd_total counter
+llm_cost_usd_total{model="gpt",provider="openai"} 0.25
+`
+	if err := promtest.GatherAndCompare(registry, strings.NewReader(expected), "llm_requests_total", "llm_tokens_total", "llm_cost_usd_total"); err != nil {
+		t.Error(err)
+	}
+	if count := promtest.CollectAndCount(sink.timeToFirstChunk); count != 1 {
+		t.Errorf("time to first chunk series = %d", count)
+	}
+}
@@ -11,8 +11,10 @@ import (
 	sdk "github.com/openai/openai-go"
 	"github.com/openai/openai-go/option"
 
+	"github.com/ulgerang/llm-module/internal/openaicompat"
 	"github.com/ulgerang/llm-module/llm"
 	"github.com/ulgerang/llm-module/logger"
+	"github.com/ulgerang/llm-module/redact"
 	"github.com/ulgerang/llm-module/utils"
 )
 
@@ -26,16 +28,35 @@ type Provider struct {
 	client    sdk.Client
 	logger    logger.Logger
 	modelName string
+	defaults  []llm.GenerationOption
 }
 
+// sampling lists the sampling parameters the 302.AI API accepts.
+var sampling = openaicompat.Sampling{Seed: true, Penalties: true, LogitBias: true}
+
+// capabilities lists the features the 302.AI provider supports.
+var capabilities = llm.Capabilities{Streaming: true, Tools: true, StreamingTools: true, ParallelTools: true, StructuredOutput: llm.StructuredOutputPrompted}
+
 // New creates a new AI302 provider instance.
-func New(log logger.Logger, apiKey, modelName string) (*Provider, error) {
+func New(log logger.Logger, apiKey, modelName string, opts ...llm.ProviderOption) (*Provider, error) {
+	return newProvider(log, apiKey, modelName, "", opts...)
+}
+
+// NewWithBaseURL creates a new AI302 provider with a custom base URL.
+func NewWithBaseURL(log logger.Logger, apiKey, modelName, baseURL string, opts ...llm.ProviderOption) (*Provider, error) {
+	return newProvider(log, apiKey, modelName, baseURL, opts...)
+}
+
+func newProvider(log logger.Logger, apiKey, modelName, baseURL string, opts ...llm.ProviderOption) (*Provider, error) {
+	config := llm.ResolveProviderOptions(opts...)
+	apiKey, err := config.ResolveAPIKey(apiKey, "AI302_API_KEY")
+	if err != nil {
+		return nil, err
+	}
 	if apiKey == "" {
-		apiKey = os.Getenv("AI302_API_KEY")
-		if apiKey == "" {
-			return nil, errors.New("AI302_API_KEY not provided")
-		}
+		return nil, errors.New("AI302_API_KEY not provided")
 	}
+	redact.AddSecret(apiKey)
 
 	if modelName == "" {
 		modelName = os.Getenv("AI302_MODEL")
@@ -44,12 +65,35 @@ func New(log logger.Logger, apiKey, modelName string) (*Provider, error) {
 		}
 	}
 
-	client := sdk.NewClient(
+	if baseURL == "" {
+		baseURL = os.Getenv("AI302_BASE_URL")
+		if baseURL == "" {
+			baseURL = defaultBaseURL
+		}
+	}
+
+	clientOpts := []option.RequestOption{
 		option.WithAPIKey(apiKey),
-		option.WithBaseURL(defaultBaseURL),
-	)
+		option.WithBaseURL(baseURL),
+	}
+	httpOpts, err := openaicompat.ClientOptions(config, nil)
+	if err != nil {
+		return nil, err
+	}
+	clientOpts = append(clientOpts, httpOpts...)
+	client := sdk.NewClient(clientOpts...)
+
+	return &Provider{client: client, logger: logger.With(log, logger.Provider("ai302"), logger.Model(modelName)), modelName: modelName, defaults: config.RequestDefaults()}, nil
+}
 
-	return &Provider{client: client, logger: log, modelName: modelName}, nil
+// ListModels lists the models the API serves.
+func (p *Provider) ListModels(ctx context.Context) ([]llm.Model, error) {
+	return openaicompat.ListModels(ctx, p.client, "ai302")
+}
+
+// Capabilities reports the features of the provider and its model.
+func (p *Provider) Capabilities() llm.Capabilities {
+	return llm.ModelCapabilities(capabilities, p.modelName)
 }
 
 // GetModelName returns the configured model name.
@@ -64,17 +108,19 @@ func (p *Provider) GenerateText(ctx context.Context, prompt string, opts ...llm.
 		MaxTokens:   llm.ValuePtr(int32(4096)),
 		System:      "You are a helpful assistant.",
 	}
+	for _, opt := range p.defaults {
+		opt(options)
+	}
 	for _, opt := range opts {
 		opt(options)
 	}
 
 	systemPrompt := p.composeSystemPrompt(options)
 
-	messages := []sdk.ChatCompletionMessageParamUnion{}
-	if systemPrompt != "" {
-		messages = append(messages, sdk.SystemMessage(systemPrompt))
+	messages, err := openaicompat.Messages(systemPrompt, options, prompt)
+	if err != nil {
+		return "", nil, err
 	}
-	messages = append(messages, sdk.UserMessage(prompt))
 
 	req := sdk.ChatCompletionNewParams{
 		Model:    p.modelName,
@@ -91,12 +137,36 @@ func (p *Provider) GenerateText(ctx context.Context, prompt string, opts ...llm.
 		req.TopP = sdk.Float(float64(*options.TopP))
 	}
 
-	resp, err := p.client.Chat.Completions.New(ctx, req)
+	if len(options.Tools) > 0 {
+		if err := openaicompat.ApplyTools(&req, options); err != nil {
+			p.logger.Error("[AI302] Failed to apply tools", err)
+			return "", nil, err
+		}
+	}
+
+	if err := openaicompat.ApplySampling(&req, options, "ai302", sampling, p.logger.Warning); err != nil {
+		return "", nil, err
+	}
+
+	resp, err := p.client.Chat.Completions.New(ctx, req, openaicompat.RequestOptions(options)...)
 	if err != nil {
+		err = openaicompat.Error("ai302", err)
 		p.logger.Error("[AI302] Failed to generate content", err)
 		return "", nil, err
 	}
 
+	if len(resp.Choices) > 0 && len(resp.Choices[0].Message.ToolCalls) > 0 {
+		p.logger.Infof("[AI302] Received %d tool call(s)", len(resp.Choices[0].Message.ToolCalls))
+		toolCalls, err := llm.FormatToolCalls(openaicompat.ToolCalls(resp.Choices[0].Message.ToolCalls))
+		if err != nil {
+			return "", nil, err
+		}
+		return toolCalls, &llm.UsageInfo{
+			InputTokens:  int(resp.Usage.PromptTokens),
+			OutputTokens: int(resp.Usage.CompletionTokens),
+		}, nil
+	}
+
 	if len(resp.Choices) == 0 || resp.Choices[0].Message.Content == "" {
 		p.logger.Warning("[AI302] No content generated")
 		return "", nil, errors.New("no content generated")
@@ -116,7 +186,7 @@ func (p *Provider) GenerateText(ctx context.Context, prompt string, opts ...llm.
 		OutputTokens: int(resp.Usage.CompletionTokens),
 	}
 
-	p.logger.Info(fmt.Sprintf("Generated text (AI302): %s", generated))
+	logger.LogContent(p.logger, "[AI302] Generated text", generated)
 	return generated, usage, nil
 }
 
@@ -129,17 +199,20 @@ func (p *Provider) GenerateTextStream(ctx context.Context, prompt string, outCha
 		MaxTokens:   llm.ValuePtr(int32(4096)),
 		System:      "You are a helpful assistant.",
 	}
+	for _, opt := range p.defaults {
+		opt(options)
+	}
 	for _, opt := range opts {
 		opt(options)
 	}
 
 	systemPrompt := p.composeSystemPrompt(options)
 
-	messages := []sdk.ChatCompletionMessageParamUnion{}
-	if systemPrompt != "" {
-		messages = append(messages, sdk.SystemMessage(systemPrompt))
+	messages, err := openaicompat.Messages(systemPrompt, options, prompt)
+	if err != nil {
+		outChan <- llm.StreamChunk{Err: err}
+		return nil, err
 	}
-	messages = append(messages, sdk.UserMessage(prompt))
 
 	req := sdk.ChatCompletionNewParams{
 		Model:    p.modelName,
@@ -156,9 +229,23 @@ func (p *Provider) GenerateTextStream(ctx context.Context, prompt string, outCha
 		req.TopP = sdk.Float(float64(*options.TopP))
 	}
 
-	stream := p.client.Chat.Completions.NewStreaming(ctx, req)
+	if len(options.Tools) > 0 {
+		if err := openaicompat.ApplyTools(&req, options); err != nil {
+			p.logger.Error("[AI302] Failed to apply tools", err)
+			outChan <- llm.StreamChunk{Err: err}
+			return nil, err
+		}
+	}
+
+	if err := openaicompat.ApplySampling(&req, options, "ai302", sampling.Stream(), p.logger.Warning); err != nil {
+		outChan <- llm.StreamChunk{Err: err}
+		return nil, err
+	}
+
+	stream := p.client.Chat.Completions.NewStreaming(ctx, req, openaicompat.RequestOptions(options)...)
 	defer stream.Close()
 
+	var toolCalls openaicompat.ToolCallAccumulator
 	var lastChunk sdk.ChatCompletionChunk
 
 	for stream.Next() {
@@ -166,6 +253,7 @@ func (p *Provider) GenerateTextStream(ctx context.Context, prompt string, outCha
 		lastChunk = chunk
 
 		if len(chunk.Choices) > 0 {
+			toolCalls.Add(chunk.Choices[0].Delta.ToolCalls)
 			delta := chunk.Choices[0].Delta.Content
 			if delta != "" {
 				outChan <- llm.StreamChunk{Delta: delta}
@@ -174,11 +262,17 @@ func (p *Provider) GenerateTextStream(ctx context.Context, prompt string, outCha
 	}
 
 	if err := stream.Err(); err != nil {
+		err = openaicompat.Error("ai302", err)
 		p.logger.Error("[AI302] Stream error", err)
 		outChan <- llm.StreamChunk{Err: err}
 		return nil, err
 	}
 
+	if calls := toolCalls.ToolCalls(); len(calls) > 0 {
+		p.logger.Infof("[AI302] Received %d tool call(s)", len(calls))
+		outChan <- llm.StreamChunk{ToolCalls: calls}
+	}
+
 	outChan <- llm.StreamChunk{IsFinal: true}
 
 	usage := parseUsageFromChunk(lastChunk, p.logger)
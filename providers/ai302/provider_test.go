@@ -0,0 +1,36 @@
+package ai302_test
+
+import (
+	"testing"
+
+	"github.com/ulgerang/llm-module/llm"
+	"github.com/ulgerang/llm-module/logger"
+	"github.com/ulgerang/llm-module/providers/ai302"
+	"github.com/ulgerang/llm-module/testutil"
+)
+
+func newProvider(baseURL string) (llm.Provider, error) {
+	return ai302.NewWithBaseURL(logger.Nop(), "test-key", "test-model", baseURL)
+}
+
+func TestToolCalls(t *testing.T) {
+	testutil.RunToolCallTests(t, newProvider)
+}
+
+func TestDefaultBaseURL(t *testing.T) {
+	t.Setenv("AI302_BASE_URL", "")
+	url := testutil.RequestURL(t, func(opts ...llm.ProviderOption) (llm.Provider, error) {
+		return ai302.New(logger.Nop(), "test-key", "test-model", opts...)
+	})
+	if url != "https://api.302.ai/v1/chat/completions" {
+		t.Errorf("request sent to %s", url)
+	}
+}
+
+func TestSampling(t *testing.T) {
+	testutil.RunOptionTests(t, newProvider, []testutil.OptionCase{
+		{Name: "seed", Options: []llm.GenerationOption{llm.WithSeed(7)}, Want: `"seed":7`},
+		{Name: "penalties", Options: []llm.GenerationOption{llm.WithFrequencyPenalty(0.5)}, Want: `"frequency_penalty":0.5`},
+		{Name: "logit bias", Options: []llm.GenerationOption{llm.WithLogitBias(map[int]int{42: -100})}, Want: `"logit_bias":{"42":-100}`},
+	})
+}
@@ -10,8 +10,10 @@ import (
 	sdk "github.com/openai/openai-go"
 	"github.com/openai/openai-go/option"
 
+	"github.com/ulgerang/llm-module/internal/openaicompat"
 	"github.com/ulgerang/llm-module/llm"
 	"github.com/ulgerang/llm-module/logger"
+	"github.com/ulgerang/llm-module/redact"
 	"github.com/ulgerang/llm-module/utils"
 )
 
@@ -25,16 +27,35 @@ type Provider struct {
 	client    sdk.Client
 	logger    logger.Logger
 	modelName string
+	defaults  []llm.GenerationOption
 }
 
+// sampling lists the sampling parameters the Cerebras API accepts.
+var sampling = openaicompat.Sampling{Seed: true}
+
+// capabilities lists the features the Cerebras provider supports.
+var capabilities = llm.Capabilities{Streaming: true, Tools: true, StreamingTools: true, ParallelTools: true, StructuredOutput: llm.StructuredOutputPrompted}
+
 // New creates a new Cerebras provider instance.
-func New(log logger.Logger, apiKey, modelName string) (*Provider, error) {
+func New(log logger.Logger, apiKey, modelName string, opts ...llm.ProviderOption) (*Provider, error) {
+	return newProvider(log, apiKey, modelName, "", opts...)
+}
+
+// NewWithBaseURL creates a new Cerebras provider with a custom base URL.
+func NewWithBaseURL(log logger.Logger, apiKey, modelName, baseURL string, opts ...llm.ProviderOption) (*Provider, error) {
+	return newProvider(log, apiKey, modelName, baseURL, opts...)
+}
+
+func newProvider(log logger.Logger, apiKey, modelName, baseURL string, opts ...llm.ProviderOption) (*Provider, error) {
+	config := llm.ResolveProviderOptions(opts...)
+	apiKey, err := config.ResolveAPIKey(apiKey, "CEREBRAS_API_KEY")
+	if err != nil {
+		return nil, err
+	}
 	if apiKey == "" {
-		apiKey = os.Getenv("CEREBRAS_API_KEY")
-		if apiKey == "" {
-			return nil, errors.New("CEREBRAS_API_KEY not provided")
-		}
+		return nil, errors.New("CEREBRAS_API_KEY not provided")
 	}
+	redact.AddSecret(apiKey)
 
 	if modelName == "" {
 		modelName = os.Getenv("CEREBRAS_MODEL")
@@ -43,12 +64,35 @@ func New(log logger.Logger, apiKey, modelName string) (*Provider, error) {
 		}
 	}
 
-	client := sdk.NewClient(
+	if baseURL == "" {
+		baseURL = os.Getenv("CEREBRAS_BASE_URL")
+		if baseURL == "" {
+			baseURL = defaultBaseURL
+		}
+	}
+
+	clientOpts := []option.RequestOption{
 		option.WithAPIKey(apiKey),
-		option.WithBaseURL(defaultBaseURL),
-	)
+		option.WithBaseURL(baseURL),
+	}
+	httpOpts, err := openaicompat.ClientOptions(config, nil)
+	if err != nil {
+		return nil, err
+	}
+	clientOpts = append(clientOpts, httpOpts...)
+	client := sdk.NewClient(clientOpts...)
+
+	return &Provider{client: client, logger: logger.With(log, logger.Provider("cerebras"), logger.Model(modelName)), modelName: modelName, defaults: config.RequestDefaults()}, nil
+}
+
+// ListModels lists the models the API serves.
+func (p *Provider) ListModels(ctx context.Context) ([]llm.Model, error) {
+	return openaicompat.ListModels(ctx, p.client, "cerebras")
+}
 
-	return &Provider{client: client, logger: log, modelName: modelName}, nil
+// Capabilities reports the features of the provider and its model.
+func (p *Provider) Capabilities() llm.Capabilities {
+	return llm.ModelCapabilities(capabilities, p.modelName)
 }
 
 // GetModelName returns the configured model name.
@@ -62,17 +106,19 @@ func (p *Provider) GenerateText(ctx context.Context, prompt string, opts ...llm.
 		TopP:        llm.ValuePtr(float32(0.95)),
 		System:      "You are a helpful assistant.",
 	}
+	for _, opt := range p.defaults {
+		opt(options)
+	}
 	for _, opt := range opts {
 		opt(options)
 	}
 
 	systemPrompt := buildSystemPrompt(options)
 
-	messages := []sdk.ChatCompletionMessageParamUnion{}
-	if systemPrompt != "" {
-		messages = append(messages, sdk.SystemMessage(systemPrompt))
+	messages, err := openaicompat.Messages(systemPrompt, options, prompt)
+	if err != nil {
+		return "", nil, err
 	}
-	messages = append(messages, sdk.UserMessage(prompt))
 
 	req := sdk.ChatCompletionNewParams{Model: p.modelName, Messages: messages}
 
@@ -86,12 +132,36 @@ func (p *Provider) GenerateText(ctx context.Context, prompt string, opts ...llm.
 		req.TopP = sdk.Float(float64(*options.TopP))
 	}
 
-	resp, err := p.client.Chat.Completions.New(ctx, req)
+	if len(options.Tools) > 0 {
+		if err := openaicompat.ApplyTools(&req, options); err != nil {
+			p.logger.Error("[Cerebras] Failed to apply tools", err)
+			return "", nil, err
+		}
+	}
+
+	if err := openaicompat.ApplySampling(&req, options, "cerebras", sampling, p.logger.Warning); err != nil {
+		return "", nil, err
+	}
+
+	resp, err := p.client.Chat.Completions.New(ctx, req, openaicompat.RequestOptions(options)...)
 	if err != nil {
+		err = openaicompat.Error("cerebras", err)
 		p.logger.Error("[Cerebras] Failed to generate content", err)
 		return "", nil, err
 	}
 
+	if len(resp.Choices) > 0 && len(resp.Choices[0].Message.ToolCalls) > 0 {
+		p.logger.Infof("[Cerebras] Received %d tool call(s)", len(resp.Choices[0].Message.ToolCalls))
+		toolCalls, err := llm.FormatToolCalls(openaicompat.ToolCalls(resp.Choices[0].Message.ToolCalls))
+		if err != nil {
+			return "", nil, err
+		}
+		return toolCalls, &llm.UsageInfo{
+			InputTokens:  int(resp.Usage.PromptTokens),
+			OutputTokens: int(resp.Usage.CompletionTokens),
+		}, nil
+	}
+
 	if len(resp.Choices) == 0 || resp.Choices[0].Message.Content == "" {
 		p.logger.Warning("[Cerebras] No content generated")
 		return "", nil, errors.New("no content generated")
@@ -111,7 +181,7 @@ func (p *Provider) GenerateText(ctx context.Context, prompt string, opts ...llm.
 		OutputTokens: int(resp.Usage.CompletionTokens),
 	}
 
-	p.logger.Info(fmt.Sprintf("Generated text (Cerebras): %s", generated))
+	logger.LogContent(p.logger, "[Cerebras] Generated text", generated)
 	return generated, usage, nil
 }
 
@@ -125,17 +195,20 @@ func (p *Provider) GenerateTextStream(ctx context.Context, prompt string, outCha
 		TopP:        llm.ValuePtr(float32(0.95)),
 		System:      "You are a helpful assistant.",
 	}
+	for _, opt := range p.defaults {
+		opt(options)
+	}
 	for _, opt := range opts {
 		opt(options)
 	}
 
 	systemPrompt := buildSystemPrompt(options)
 
-	messages := []sdk.ChatCompletionMessageParamUnion{}
-	if systemPrompt != "" {
-		messages = append(messages, sdk.SystemMessage(systemPrompt))
+	messages, err := openaicompat.Messages(systemPrompt, options, prompt)
+	if err != nil {
+		outChan <- llm.StreamChunk{Err: err}
+		return nil, err
 	}
-	messages = append(messages, sdk.UserMessage(prompt))
 
 	req := sdk.ChatCompletionNewParams{Model: p.modelName, Messages: messages}
 
@@ -149,9 +222,23 @@ func (p *Provider) GenerateTextStream(ctx context.Context, prompt string, outCha
 		req.TopP = sdk.Float(float64(*options.TopP))
 	}
 
-	stream := p.client.Chat.Completions.NewStreaming(ctx, req)
+	if len(options.Tools) > 0 {
+		if err := openaicompat.ApplyTools(&req, options); err != nil {
+			p.logger.Error("[Cerebras] Failed to apply tools", err)
+			outChan <- llm.StreamChunk{Err: err}
+			return nil, err
+		}
+	}
+
+	if err := openaicompat.ApplySampling(&req, options, "cerebras", sampling.Stream(), p.logger.Warning); err != nil {
+		outChan <- llm.StreamChunk{Err: err}
+		return nil, err
+	}
+
+	stream := p.client.Chat.Completions.NewStreaming(ctx, req, openaicompat.RequestOptions(options)...)
 	defer stream.Close()
 
+	var toolCalls openaicompat.ToolCallAccumulator
 	var usage *llm.UsageInfo
 	var full strings.Builder
 
@@ -159,6 +246,7 @@ func (p *Provider) GenerateTextStream(ctx context.Context, prompt string, outCha
 		resp := stream.Current()
 
 		if len(resp.Choices) > 0 {
+			toolCalls.Add(resp.Choices[0].Delta.ToolCalls)
 			delta := resp.Choices[0].Delta.Content
 			if delta != "" {
 				full.WriteString(delta)
@@ -180,13 +268,19 @@ func (p *Provider) GenerateTextStream(ctx context.Context, prompt string, outCha
 	}
 
 	if err := stream.Err(); err != nil {
+		err = openaicompat.Error("cerebras", err)
 		p.logger.Error("[Cerebras Stream] Stream error", err)
 		return usage, err
 	}
 
+	if calls := toolCalls.ToolCalls(); len(calls) > 0 {
+		p.logger.Infof("[Cerebras Stream] Received %d tool call(s)", len(calls))
+		outChan <- llm.StreamChunk{ToolCalls: calls}
+	}
+
 	if options.ResponseSchema != nil {
 		if extracted, extractErr := utils.ExtractJSONFromString(full.String()); extractErr == nil {
-			p.logger.Info(fmt.Sprintf("[Cerebras Stream] Extracted JSON: %s", extracted))
+			logger.LogContent(p.logger, "[Cerebras Stream] Extracted JSON", extracted)
 		} else {
 			p.logger.Warningf("[Cerebras Stream] Failed to extract JSON: %v", extractErr)
 		}
@@ -0,0 +1,36 @@
+package cerebras_test
+
+import (
+	"testing"
+
+	"github.com/ulgerang/llm-module/llm"
+	"github.com/ulgerang/llm-module/logger"
+	"github.com/ulgerang/llm-module/providers/cerebras"
+	"github.com/ulgerang/llm-module/testutil"
+)
+
+func newProvider(baseURL string) (llm.Provider, error) {
+	return cerebras.NewWithBaseURL(logger.Nop(), "test-key", "test-model", baseURL)
+}
+
+func TestToolCalls(t *testing.T) {
+	testutil.RunToolCallTests(t, newProvider)
+}
+
+func TestDefaultBaseURL(t *testing.T) {
+	t.Setenv("CEREBRAS_BASE_URL", "")
+	url := testutil.RequestURL(t, func(opts ...llm.ProviderOption) (llm.Provider, error) {
+		return cerebras.New(logger.Nop(), "test-key", "test-model", opts...)
+	})
+	if url != "https://api.cerebras.ai/v1/chat/completions" {
+		t.Errorf("request sent to %s", url)
+	}
+}
+
+func TestSampling(t *testing.T) {
+	testutil.RunOptionTests(t, newProvider, []testutil.OptionCase{
+		{Name: "seed", Options: []llm.GenerationOption{llm.WithSeed(7)}, Want: `"seed":7`},
+		{Name: "stop", Options: []llm.GenerationOption{llm.WithStopSequences("END")}, Want: `"stop":["END"]`},
+		{Name: "penalties", Options: []llm.GenerationOption{llm.WithFrequencyPenalty(0.5)}, Unsupported: true},
+	})
+}
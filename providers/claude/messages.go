@@ -0,0 +1,183 @@
+package claude
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ulgerang/llm-module/llm"
+)
+
+const (
+	// maxCacheBreakpoints is the number of cache_control markers Claude accepts per request.
+	maxCacheBreakpoints = 4
+	// extendedCacheTTLBeta enables the one-hour cache TTL.
+	extendedCacheTTLBeta = "extended-cache-ttl-2025-04-11"
+)
+
+// unsupportedOptions lists the options the Messages API has no parameter for: sampling
+// options and the cached content names of Gemini.
+var unsupportedOptions = []string{"Seed", "PresencePenalty", "FrequencyPenalty", "LogitBias", "Logprobs", "CandidateCount", "CachedContent"}
+
+// buildMessages converts the conversation history and prompt into Claude messages.
+// Tool results are sent as tool_result blocks in a user turn, and consecutive turns
+// with the same role are merged because Claude requires roles to alternate.
+func buildMessages(options *llm.GenerationOptions, prompt string) ([]Message, error) {
+	if len(options.Messages) == 0 {
+		return []Message{{Role: "user", Content: prompt}}, nil
+	}
+
+	var messages []Message
+	for _, message := range llm.ConversationMessages(options, prompt) {
+		var role string
+		var blocks []MessageBlock
+		switch message.Role {
+		case llm.RoleUser:
+			role = "user"
+			blocks = append(blocks, MessageBlock{Type: "text", Text: message.Content})
+		case llm.RoleAssistant:
+			role = "assistant"
+			if message.Content != "" {
+				blocks = append(blocks, MessageBlock{Type: "text", Text: message.Content})
+			}
+			for _, call := range message.ToolCalls {
+				input := call.Input
+				if len(input) == 0 {
+					input = json.RawMessage("{}")
+				}
+				blocks = append(blocks, MessageBlock{Type: "tool_use", ID: call.ID, Name: call.Name, Input: input})
+			}
+		case llm.RoleTool:
+			role = "user"
+			blocks = append(blocks, MessageBlock{
+				Type:      "tool_result",
+				ToolUseID: message.ToolCallID,
+				Content:   message.Content,
+				IsError:   message.IsError,
+			})
+		default:
+			return nil, fmt.Errorf("unsupported message role '%s'", message.Role)
+		}
+		if len(blocks) == 0 {
+			continue
+		}
+		if message.Cache {
+			blocks[len(blocks)-1].CacheControl = &CacheControl{Type: "ephemeral"}
+		}
+
+		if last := len(messages) - 1; last >= 0 && messages[last].Role == role {
+			messages[last].Content = append(messages[last].Content.([]MessageBlock), blocks...)
+			continue
+		}
+		messages = append(messages, Message{Role: role, Content: blocks})
+	}
+	return messages, nil
+}
+
+// applyCacheControl places cache breakpoints for WithCache and applies the requested TTL
+// to every breakpoint in the request. It returns the beta header value needed for the
+// TTL, if any.
+func applyCacheControl(req *MessageRequest, options *llm.GenerationOptions) (string, error) {
+	if err := options.CacheTTL.Validate(); err != nil {
+		return "", err
+	}
+
+	if options.UseCache {
+		if len(req.Tools) > 0 {
+			req.Tools[len(req.Tools)-1].CacheControl = &CacheControl{Type: "ephemeral"}
+		}
+		if len(req.System) > 0 {
+			req.System[len(req.System)-1].CacheControl = &CacheControl{Type: "ephemeral"}
+		}
+		if len(options.Messages) > 0 && len(req.Messages) > 0 {
+			last := &req.Messages[len(req.Messages)-1]
+			if blocks, ok := last.Content.([]MessageBlock); ok && len(blocks) > 0 {
+				blocks[len(blocks)-1].CacheControl = &CacheControl{Type: "ephemeral"}
+			}
+		}
+	}
+
+	var breakpoints []*CacheControl
+	for i := range req.Tools {
+		if req.Tools[i].CacheControl != nil {
+			breakpoints = append(breakpoints, req.Tools[i].CacheControl)
+		}
+	}
+	for i := range req.System {
+		if req.System[i].CacheControl != nil {
+			breakpoints = append(breakpoints, req.System[i].CacheControl)
+		}
+	}
+	for _, message := range req.Messages {
+		blocks, _ := message.Content.([]MessageBlock)
+		for i := range blocks {
+			if blocks[i].CacheControl != nil {
+				breakpoints = append(breakpoints, blocks[i].CacheControl)
+			}
+		}
+	}
+
+	if len(breakpoints) > maxCacheBreakpoints {
+		return "", fmt.Errorf("Claude accepts at most %d cache breakpoints, request has %d", maxCacheBreakpoints, len(breakpoints))
+	}
+	if len(breakpoints) == 0 || options.CacheTTL == llm.CacheTTLDefault {
+		return "", nil
+	}
+	for _, breakpoint := range breakpoints {
+		breakpoint.TTL = string(options.CacheTTL)
+	}
+	if options.CacheTTL == llm.CacheTTL1Hour {
+		return extendedCacheTTLBeta, nil
+	}
+	return "", nil
+}
+
+// convertUsage maps Claude usage to llm.UsageInfo. Claude reports uncached input
+// separately from cache reads and writes, so InputTokens is the sum of all three.
+func convertUsage(usage Usage) *llm.UsageInfo {
+	info := &llm.UsageInfo{
+		InputTokens:       usage.InputTokens + usage.CacheCreationInputTokens + usage.CacheReadInputTokens,
+		OutputTokens:      usage.OutputTokens,
+		CacheCreateTokens: usage.CacheCreationInputTokens,
+		CacheHitTokens:    usage.CacheReadInputTokens,
+	}
+	if usage.CacheCreationInputTokens > 0 || usage.CacheReadInputTokens > 0 {
+		info.CacheMissTokens = usage.InputTokens + usage.CacheCreationInputTokens
+	}
+	return info
+}
+
+// requestMetadata returns the metadata of a call that Claude accepts, which is only the
+// end-user ID.
+func requestMetadata(options *llm.GenerationOptions) *RequestMetadata {
+	if userID := options.Metadata[llm.MetadataUserID]; userID != "" {
+		return &RequestMetadata{UserID: userID}
+	}
+	return nil
+}
+
+// marshalRequest encodes the request with the extra body fields of the call.
+func marshalRequest(req MessageRequest, options *llm.GenerationOptions) ([]byte, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	return llm.MergeExtraBody(body, options.ExtraBody)
+}
+
+// setHeaders sets the headers of a call on req. Betas in anthropic-beta are enabled in
+// addition to those the provider enables itself.
+func setHeaders(req *http.Request, headers http.Header) {
+	for name, values := range headers {
+		name = http.CanonicalHeaderKey(name)
+		if name == "Anthropic-Beta" {
+			if existing := req.Header.Get(name); existing != "" {
+				values = append([]string{existing}, values...)
+			}
+			req.Header.Set(name, strings.Join(values, ","))
+			continue
+		}
+		req.Header[name] = values
+	}
+}
@@ -0,0 +1,74 @@
+package claude
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/ulgerang/llm-module/llm"
+)
+
+// modelPage is a page of the Anthropic /v1/models response.
+type modelPage struct {
+	Data []struct {
+		ID          string    `json:"id"`
+		DisplayName string    `json:"display_name"`
+		CreatedAt   time.Time `json:"created_at"`
+	} `json:"data"`
+	HasMore bool   `json:"has_more"`
+	LastID  string `json:"last_id"`
+}
+
+// ListModels lists the models served by the Anthropic API, newest first. Token limits
+// come from the model registry.
+func (p *Provider) ListModels(ctx context.Context) ([]llm.Model, error) {
+	var models []llm.Model
+	query := url.Values{"limit": {"1000"}}
+	for {
+		page, err := p.listModelPage(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range page.Data {
+			models = append(models, llm.FillModelLimits(llm.Model{
+				ID:          entry.ID,
+				DisplayName: entry.DisplayName,
+				OwnedBy:     "anthropic",
+				Created:     entry.CreatedAt,
+			}))
+		}
+		if !page.HasMore || page.LastID == "" {
+			return models, nil
+		}
+		query.Set("after_id", page.LastID)
+	}
+}
+
+func (p *Provider) listModelPage(ctx context.Context, query url.Values) (*modelPage, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+"/models?"+query.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	req.Header.Set("x-api-key", p.apiKey)
+	req.Header.Set("anthropic-version", claudeAPIVersion)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call Claude API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, apiError(resp.StatusCode, body)
+	}
+	var page modelPage
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return nil, fmt.Errorf("failed to decode model list: %w", err)
+	}
+	return &page, nil
+}
@@ -15,6 +15,8 @@ import (
 
 	"github.com/ulgerang/llm-module/llm"
 	"github.com/ulgerang/llm-module/logger"
+	"github.com/ulgerang/llm-module/redact"
+	"github.com/ulgerang/llm-module/transport"
 	"github.com/ulgerang/llm-module/utils"
 )
 
@@ -25,6 +27,9 @@ const (
 	defaultClaudeTimeout = 60 * time.Second
 )
 
+// capabilities lists the features the Claude provider supports.
+var capabilities = llm.Capabilities{Streaming: true, Tools: true, ParallelTools: true, StructuredOutput: llm.StructuredOutputPrompted, Caching: true}
+
 // Provider implements llm.Provider for Anthropic Claude.
 type Provider struct {
 	client    *http.Client
@@ -32,17 +37,18 @@ type Provider struct {
 	apiKey    string
 	modelName string
 	baseURL   string
+	defaults  []llm.GenerationOption
 }
 
 // StreamEvent represents a single event in the Claude SSE stream.
 type StreamEvent struct {
-	Type         string             `json:"type"`
-	Index        *int               `json:"index,omitempty"`
-	Delta        *StreamDelta       `json:"delta,omitempty"`
-	Message      *MessageResponse   `json:"message,omitempty"`
-	Usage        *Usage             `json:"usage,omitempty"`
-	ContentBlock *ContentBlock      `json:"content_block,omitempty"`
-	Error        *ErrorDetail       `json:"error,omitempty"`
+	Type         string           `json:"type"`
+	Index        *int             `json:"index,omitempty"`
+	Delta        *StreamDelta     `json:"delta,omitempty"`
+	Message      *MessageResponse `json:"message,omitempty"`
+	Usage        *Usage           `json:"usage,omitempty"`
+	ContentBlock *ContentBlock    `json:"content_block,omitempty"`
+	Error        *ErrorDetail     `json:"error,omitempty"`
 }
 
 // ErrorDetail captures Claude stream error information.
@@ -51,10 +57,12 @@ type ErrorDetail struct {
 	Message string `json:"message"`
 }
 
-// StreamDelta represents incremental text payloads.
+// StreamDelta represents incremental text payloads. message_delta events carry the
+// stop reason instead.
 type StreamDelta struct {
-	Type string `json:"type"`
-	Text string `json:"text"`
+	Type       string `json:"type"`
+	Text       string `json:"text"`
+	StopReason string `json:"stop_reason,omitempty"`
 }
 
 // ToolInputSchema defines Claude tool schema payload.
@@ -67,20 +75,36 @@ type ToolInputSchema struct {
 
 // Tool definition for Claude requests.
 type Tool struct {
-	Name        string          `json:"name"`
-	Description string          `json:"description"`
-	InputSchema ToolInputSchema `json:"input_schema"`
+	Name         string          `json:"name"`
+	Description  string          `json:"description"`
+	InputSchema  ToolInputSchema `json:"input_schema"`
+	CacheControl *CacheControl   `json:"cache_control,omitempty"`
 }
 
-// Message represents a Claude conversation message.
+// Message represents a Claude conversation message. Content is either a string
+// or a []MessageBlock.
 type Message struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role    string      `json:"role"`
+	Content interface{} `json:"content"`
 }
 
-// CacheControl represents cache directive metadata.
+// MessageBlock is a content block of a request message: text, tool_use or tool_result.
+type MessageBlock struct {
+	Type         string          `json:"type"`
+	Text         string          `json:"text,omitempty"`
+	ID           string          `json:"id,omitempty"`
+	Name         string          `json:"name,omitempty"`
+	Input        json.RawMessage `json:"input,omitempty"`
+	ToolUseID    string          `json:"tool_use_id,omitempty"`
+	Content      string          `json:"content,omitempty"`
+	IsError      bool            `json:"is_error,omitempty"`
+	CacheControl *CacheControl   `json:"cache_control,omitempty"`
+}
+
+// CacheControl represents cache directive metadata. TTL is "5m" or "1h".
 type CacheControl struct {
 	Type string `json:"type"`
+	TTL  string `json:"ttl,omitempty"`
 }
 
 // RequestTextBlock is a text block with optional cache control.
@@ -92,15 +116,30 @@ type RequestTextBlock struct {
 
 // MessageRequest is the Claude messages API request payload.
 type MessageRequest struct {
-	Model     string            `json:"model"`
-	Messages  []Message         `json:"messages"`
-	System    []RequestTextBlock `json:"system,omitempty"`
-	MaxTokens int32             `json:"max_tokens"`
-	Temperature *float32        `json:"temperature,omitempty"`
-	TopP      *float32          `json:"top_p,omitempty"`
-	TopK      *float32          `json:"top_k,omitempty"`
-	Tools     []Tool            `json:"tools,omitempty"`
-	Stream    bool              `json:"stream,omitempty"`
+	Model         string             `json:"model"`
+	Messages      []Message          `json:"messages"`
+	System        []RequestTextBlock `json:"system,omitempty"`
+	MaxTokens     int32              `json:"max_tokens"`
+	Temperature   *float32           `json:"temperature,omitempty"`
+	TopP          *float32           `json:"top_p,omitempty"`
+	TopK          *float32           `json:"top_k,omitempty"`
+	Tools         []Tool             `json:"tools,omitempty"`
+	ToolChoice    *ToolChoice        `json:"tool_choice,omitempty"`
+	Stream        bool               `json:"stream,omitempty"`
+	Metadata      *RequestMetadata   `json:"metadata,omitempty"`
+	StopSequences []string           `json:"stop_sequences,omitempty"`
+}
+
+// RequestMetadata identifies the end user of a request.
+type RequestMetadata struct {
+	UserID string `json:"user_id,omitempty"`
+}
+
+// ToolChoice controls how Claude uses the provided tools.
+type ToolChoice struct {
+	Type                   string `json:"type"`
+	Name                   string `json:"name,omitempty"`
+	DisableParallelToolUse *bool  `json:"disable_parallel_tool_use,omitempty"`
 }
 
 // ContentBlock represents response content blocks.
@@ -127,11 +166,7 @@ type ToolUseContentBlock struct {
 }
 
 // ToolCall contains structured tool call data returned by Claude.
-type ToolCall struct {
-	ID    string          `json:"id"`
-	Name  string          `json:"name"`
-	Input json.RawMessage `json:"input"`
-}
+type ToolCall = llm.ToolCall
 
 // Usage captures token accounting information.
 type Usage struct {
@@ -154,13 +189,25 @@ type MessageResponse struct {
 }
 
 // New creates a new Claude provider instance.
-func New(log logger.Logger, apiKey, modelName string) (*Provider, error) {
+func New(log logger.Logger, apiKey, modelName string, opts ...llm.ProviderOption) (*Provider, error) {
+	return newProvider(log, apiKey, modelName, "", opts...)
+}
+
+// NewWithBaseURL creates a new Claude provider with a custom base URL.
+func NewWithBaseURL(log logger.Logger, apiKey, modelName, baseURL string, opts ...llm.ProviderOption) (*Provider, error) {
+	return newProvider(log, apiKey, modelName, baseURL, opts...)
+}
+
+func newProvider(log logger.Logger, apiKey, modelName, baseURL string, opts ...llm.ProviderOption) (*Provider, error) {
+	config := llm.ResolveProviderOptions(opts...)
+	apiKey, err := config.ResolveAPIKey(apiKey, "CLAUDE_API_KEY")
+	if err != nil {
+		return nil, err
+	}
 	if apiKey == "" {
-		apiKey = os.Getenv("CLAUDE_API_KEY")
-		if apiKey == "" {
-			return nil, errors.New("CLAUDE_API_KEY not provided")
-		}
+		return nil, errors.New("CLAUDE_API_KEY not provided")
 	}
+	redact.AddSecret(apiKey)
 
 	if modelName == "" {
 		modelName = os.Getenv("CLAUDE_MODEL")
@@ -169,22 +216,37 @@ func New(log logger.Logger, apiKey, modelName string) (*Provider, error) {
 		}
 	}
 
-	baseURL := os.Getenv("CLAUDE_BASE_URL")
 	if baseURL == "" {
-		baseURL = defaultClaudeBaseURL
+		baseURL = os.Getenv("CLAUDE_BASE_URL")
+		if baseURL == "" {
+			baseURL = defaultClaudeBaseURL
+		}
 	}
 
-	client := &http.Client{Timeout: defaultClaudeTimeout}
+	// Only the wait for response headers is bounded by default; a total timeout would
+	// cut off long streams, which llm.Timeouts.StreamIdle guards instead.
+	httpTransport := http.DefaultTransport.(*http.Transport).Clone()
+	httpTransport.ResponseHeaderTimeout = defaultClaudeTimeout
+	client, err := transport.NewClient(config, &http.Client{Transport: httpTransport})
+	if err != nil {
+		return nil, err
+	}
 
 	return &Provider{
 		client:    client,
-		logger:    log,
+		logger:    logger.With(log, logger.Provider("claude"), logger.Model(modelName)),
 		apiKey:    apiKey,
 		modelName: modelName,
 		baseURL:   baseURL,
+		defaults:  config.RequestDefaults(),
 	}, nil
 }
 
+// Capabilities reports the features of the provider and its model.
+func (p *Provider) Capabilities() llm.Capabilities {
+	return llm.ModelCapabilities(capabilities, p.modelName)
+}
+
 // GetModelName returns the current Claude model identifier.
 func (p *Provider) GetModelName() string {
 	return p.modelName
@@ -196,6 +258,9 @@ func (p *Provider) GenerateText(ctx context.Context, prompt string, opts ...llm.
 		Temperature: llm.ValuePtr(float32(0.7)),
 		MaxTokens:   llm.ValuePtr(int32(4096)),
 	}
+	for _, opt := range p.defaults {
+		opt(options)
+	}
 	for _, opt := range opts {
 		opt(options)
 	}
@@ -205,26 +270,37 @@ func (p *Provider) GenerateText(ctx context.Context, prompt string, opts ...llm.
 		systemInstruction += fmt.Sprintf(" Please respond in %s language.", utils.GetLangName(options.Language))
 	}
 
+	if err := llm.ValidateToolChoice(options); err != nil {
+		return "", nil, err
+	}
+
+	if err := options.CheckUnsupported("claude", p.logger.Warning, unsupportedOptions...); err != nil {
+		return "", nil, err
+	}
+
+	messages, err := buildMessages(options, prompt)
+	if err != nil {
+		return "", nil, err
+	}
+
 	reqPayload := MessageRequest{
-		Model: p.modelName,
-		Messages: []Message{
-			{Role: "user", Content: prompt},
-		},
-		MaxTokens:   *options.MaxTokens,
-		Temperature: options.Temperature,
-		TopP:        options.TopP,
-		TopK:        options.TopK,
+		Model:         p.modelName,
+		Messages:      messages,
+		MaxTokens:     *options.MaxTokens,
+		Temperature:   options.Temperature,
+		TopP:          options.TopP,
+		TopK:          options.TopK,
+		Metadata:      requestMetadata(options),
+		StopSequences: options.StopSequences,
 	}
 
 	var systemBlocks []RequestTextBlock
-	cacheUsed := false
 
 	if len(options.SystemBlocks) > 0 {
 		for _, block := range options.SystemBlocks {
 			textBlock := RequestTextBlock{Type: "text", Text: block.Text}
 			if block.UseCache {
 				textBlock.CacheControl = &CacheControl{Type: "ephemeral"}
-				cacheUsed = true
 			}
 			systemBlocks = append(systemBlocks, textBlock)
 		}
@@ -274,6 +350,7 @@ func (p *Provider) GenerateText(ctx context.Context, prompt string, opts ...llm.
 			})
 		}
 		reqPayload.Tools = claudeTools
+		reqPayload.ToolChoice = buildToolChoice(options)
 	} else if options.ResponseSchema != nil {
 		schemaJSON, err := llm.ConvertToJSONSchema(options.ResponseSchema)
 		if err != nil {
@@ -288,7 +365,12 @@ func (p *Provider) GenerateText(ctx context.Context, prompt string, opts ...llm.
 		}
 	}
 
-	body, err := json.Marshal(reqPayload)
+	cacheBeta, err := applyCacheControl(&reqPayload, options)
+	if err != nil {
+		return "", nil, err
+	}
+
+	body, err := marshalRequest(reqPayload, options)
 	if err != nil {
 		p.logger.Error("Failed to marshal Claude request payload", err)
 		return "", nil, fmt.Errorf("failed to marshal request payload: %w", err)
@@ -303,10 +385,10 @@ func (p *Provider) GenerateText(ctx context.Context, prompt string, opts ...llm.
 	req.Header.Set("x-api-key", p.apiKey)
 	req.Header.Set("anthropic-version", claudeAPIVersion)
 	req.Header.Set("Content-Type", "application/json")
-	if cacheUsed {
-		req.Header.Set("anthropic-beta", "prompt-caching-2024-07-31")
-		p.logger.Info("Claude prompt caching enabled for this request.")
+	if cacheBeta != "" {
+		req.Header.Set("anthropic-beta", cacheBeta)
 	}
+	setHeaders(req, options.Headers)
 
 	resp, err := p.client.Do(req)
 	if err != nil {
@@ -316,10 +398,10 @@ func (p *Provider) GenerateText(ctx context.Context, prompt string, opts ...llm.
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		var errorBody map[string]interface{}
-		_ = json.NewDecoder(resp.Body).Decode(&errorBody)
-		p.logger.Error(fmt.Sprintf("Claude API returned non-OK status: %d - Body: %v", resp.StatusCode, errorBody), nil)
-		return "", nil, fmt.Errorf("Claude API error: status code %d, details: %v", resp.StatusCode, errorBody)
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		err := apiError(resp.StatusCode, bodyBytes)
+		p.logger.Error("Claude API returned non-OK status", err)
+		return "", nil, err
 	}
 
 	var claudeResp MessageResponse
@@ -328,11 +410,11 @@ func (p *Provider) GenerateText(ctx context.Context, prompt string, opts ...llm.
 		return "", nil, fmt.Errorf("failed to decode API response: %w", err)
 	}
 
-	usage := &llm.UsageInfo{
-		InputTokens:     claudeResp.Usage.InputTokens,
-		OutputTokens:    claudeResp.Usage.OutputTokens,
-		CacheMissTokens: claudeResp.Usage.CacheCreationInputTokens,
-		CacheHitTokens:  claudeResp.Usage.CacheReadInputTokens,
+	usage := convertUsage(claudeResp.Usage)
+	usage.ResponseModel = claudeResp.Model
+	usage.FinishReason = claudeResp.StopReason
+	if usage.CacheHitTokens > 0 || usage.CacheCreateTokens > 0 {
+		p.logger.Infof("[Claude] Prompt cache: read=%d created=%d", usage.CacheHitTokens, usage.CacheCreateTokens)
 	}
 
 	if claudeResp.StopReason == "tool_use" {
@@ -341,7 +423,7 @@ func (p *Provider) GenerateText(ctx context.Context, prompt string, opts ...llm.
 			p.logger.Error("Failed to marshal tool calls to JSON", err)
 			return "", nil, fmt.Errorf("failed to marshal tool calls: %w", err)
 		}
-		return "TOOL_CALL::" + toolCallsJSON, usage, nil
+		return toolCallsJSON, usage, nil
 	}
 
 	var textBuilder strings.Builder
@@ -374,7 +456,7 @@ func (p *Provider) GenerateText(ctx context.Context, prompt string, opts ...llm.
 		}
 	}
 
-	p.logger.Info(fmt.Sprintf("Generated text (Claude): %s", generatedText))
+	logger.LogContent(p.logger, "[Claude] Generated text", generatedText)
 	return generatedText, usage, nil
 }
 
@@ -388,6 +470,9 @@ func (p *Provider) GenerateTextStream(ctx context.Context, prompt string, outCha
 		Temperature: llm.ValuePtr(float32(0.7)),
 		MaxTokens:   llm.ValuePtr(int32(4096)),
 	}
+	for _, opt := range p.defaults {
+		opt(options)
+	}
 	for _, opt := range opts {
 		opt(options)
 	}
@@ -401,13 +486,11 @@ func (p *Provider) GenerateTextStream(ctx context.Context, prompt string, outCha
 	}
 
 	var systemBlocks []RequestTextBlock
-	cacheUsed := false
 	if len(options.SystemBlocks) > 0 {
 		for _, block := range options.SystemBlocks {
 			textBlock := RequestTextBlock{Type: "text", Text: block.Text}
 			if block.UseCache {
 				textBlock.CacheControl = &CacheControl{Type: "ephemeral"}
-				cacheUsed = true
 			}
 			systemBlocks = append(systemBlocks, textBlock)
 		}
@@ -416,10 +499,27 @@ func (p *Provider) GenerateTextStream(ctx context.Context, prompt string, outCha
 		systemBlocks = append(systemBlocks, RequestTextBlock{Type: "text", Text: systemInstruction})
 	}
 
-	messages := []Message{{Role: "user", Content: prompt}}
+	if err := options.CheckUnsupported("claude", p.logger.Warning, unsupportedOptions...); err != nil {
+		outChan <- llm.StreamChunk{Err: err}
+		return nil, err
+	}
+
+	messages, err := buildMessages(options, prompt)
+	if err != nil {
+		outChan <- llm.StreamChunk{Err: err}
+		return nil, err
+	}
+
+	if options.ToolChoice.ForcesToolUse() {
+		err := llm.NewUnsupportedOptionError("claude", "ToolChoice", "tool_use blocks are not parsed from Claude streams")
+		outChan <- llm.StreamChunk{Err: err}
+		return nil, err
+	}
 
 	var claudeTools []Tool
+	var toolChoice *ToolChoice
 	if len(options.Tools) > 0 {
+		toolChoice = buildToolChoice(options)
 		p.logger.Warning("Claude streaming with tools may produce partial tool events; parsing is limited to text deltas.")
 		claudeTools = make([]Tool, 0, len(options.Tools))
 		for _, tool := range options.Tools {
@@ -473,18 +573,27 @@ func (p *Provider) GenerateTextStream(ctx context.Context, prompt string, outCha
 	}
 
 	reqPayload := MessageRequest{
-		Model:       p.modelName,
-		Messages:    messages,
-		System:      systemBlocks,
-		MaxTokens:   *options.MaxTokens,
-		Temperature: options.Temperature,
-		TopP:        options.TopP,
-		TopK:        options.TopK,
-		Tools:       claudeTools,
-		Stream:      true,
-	}
-
-	body, err := json.Marshal(reqPayload)
+		Model:         p.modelName,
+		Messages:      messages,
+		System:        systemBlocks,
+		MaxTokens:     *options.MaxTokens,
+		Temperature:   options.Temperature,
+		TopP:          options.TopP,
+		TopK:          options.TopK,
+		Tools:         claudeTools,
+		ToolChoice:    toolChoice,
+		Metadata:      requestMetadata(options),
+		StopSequences: options.StopSequences,
+		Stream:        true,
+	}
+
+	cacheBeta, err := applyCacheControl(&reqPayload, options)
+	if err != nil {
+		outChan <- llm.StreamChunk{Err: err}
+		return nil, err
+	}
+
+	body, err := marshalRequest(reqPayload, options)
 	if err != nil {
 		p.logger.Error("Failed to marshal Claude stream request payload", err)
 		outChan <- llm.StreamChunk{Err: fmt.Errorf("failed to marshal request payload: %w", err)}
@@ -504,10 +613,10 @@ func (p *Provider) GenerateTextStream(ctx context.Context, prompt string, outCha
 	req.Header.Set("Accept", "text/event-stream")
 	req.Header.Set("Cache-Control", "no-cache")
 	req.Header.Set("Connection", "keep-alive")
-	if cacheUsed {
-		req.Header.Set("anthropic-beta", "prompt-caching-2024-07-31")
-		p.logger.Info("Claude prompt caching enabled for this stream request.")
+	if cacheBeta != "" {
+		req.Header.Set("anthropic-beta", cacheBeta)
 	}
+	setHeaders(req, options.Headers)
 
 	resp, err := p.client.Do(req)
 	if err != nil {
@@ -522,42 +631,31 @@ func (p *Provider) GenerateTextStream(ctx context.Context, prompt string, outCha
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		bodyBytes, readErr := io.ReadAll(resp.Body)
+		bodyBytes, _ := io.ReadAll(resp.Body)
 		resp.Body.Close()
-		if readErr != nil {
-			p.logger.Error(fmt.Sprintf("Claude API stream error: status %d, failed to read body", resp.StatusCode), readErr)
-			err := fmt.Errorf("Claude API stream error: status code %d", resp.StatusCode)
-			outChan <- llm.StreamChunk{Err: err}
-			return nil, err
-		}
-
-		var errorResp struct {
-			Type  string `json:"type"`
-			Error struct {
-				Type    string `json:"type"`
-				Message string `json:"message"`
-			} `json:"error"`
-		}
-		msg := fmt.Sprintf("Claude API stream error: status code %d, raw body: %s", resp.StatusCode, string(bodyBytes))
-		if json.Unmarshal(bodyBytes, &errorResp) == nil && errorResp.Error.Message != "" {
-			msg = fmt.Sprintf("Claude API stream error: status code %d, type: %s, message: %s", resp.StatusCode, errorResp.Error.Type, errorResp.Error.Message)
-		}
-		p.logger.Error(msg, nil)
-		err := errors.New(msg)
+		err := apiError(resp.StatusCode, bodyBytes)
+		p.logger.Error("Claude API stream returned non-OK status", err)
 		outChan <- llm.StreamChunk{Err: err}
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	reader := bufio.NewReader(resp.Body)
-	usage := &llm.UsageInfo{}
+	var rawUsage Usage
+	var responseModel, stopReason string
 	var currentEvent []byte
+	finalUsage := func() *llm.UsageInfo {
+		usage := convertUsage(rawUsage)
+		usage.ResponseModel = responseModel
+		usage.FinishReason = stopReason
+		return usage
+	}
 
 	for {
 		select {
 		case <-ctx.Done():
 			p.logger.Info("Context cancelled during Claude stream processing")
-			return usage, ctx.Err()
+			return finalUsage(), ctx.Err()
 		default:
 		}
 
@@ -569,7 +667,7 @@ func (p *Provider) GenerateTextStream(ctx context.Context, prompt string, outCha
 			p.logger.Error("Error reading Claude stream", err)
 			readErr := fmt.Errorf("stream read error: %w", err)
 			outChan <- llm.StreamChunk{Err: readErr}
-			return usage, readErr
+			return finalUsage(), readErr
 		}
 
 		trimmed := bytes.TrimSpace(line)
@@ -604,28 +702,40 @@ func (p *Provider) GenerateTextStream(ctx context.Context, prompt string, outCha
 		switch streamEvent.Type {
 		case "message_start":
 			if streamEvent.Message != nil {
-				usage.InputTokens = streamEvent.Message.Usage.InputTokens
-				usage.OutputTokens = streamEvent.Message.Usage.OutputTokens
-				usage.CacheCreateTokens = streamEvent.Message.Usage.CacheCreationInputTokens
-				usage.CacheHitTokens = streamEvent.Message.Usage.CacheReadInputTokens
+				rawUsage = streamEvent.Message.Usage
+				responseModel = streamEvent.Message.Model
 			}
 		case "content_block_delta":
 			if streamEvent.Delta != nil && streamEvent.Delta.Type == "text_delta" {
 				outChan <- llm.StreamChunk{Delta: streamEvent.Delta.Text}
 			}
 		case "message_delta":
+			// message_delta usage is cumulative but may omit the input counts, so only
+			// non-zero values replace those from message_start.
 			if streamEvent.Usage != nil {
-				usage.InputTokens = streamEvent.Usage.InputTokens
-				usage.OutputTokens = streamEvent.Usage.OutputTokens
-				usage.CacheCreateTokens = streamEvent.Usage.CacheCreationInputTokens
-				usage.CacheHitTokens = streamEvent.Usage.CacheReadInputTokens
+				mergeUsage(&rawUsage, *streamEvent.Usage)
+			}
+			if streamEvent.Delta != nil && streamEvent.Delta.StopReason != "" {
+				stopReason = streamEvent.Delta.StopReason
 			}
 		case "message_stop":
 			outChan <- llm.StreamChunk{IsFinal: true}
 		}
 	}
 
-	return usage, nil
+	return finalUsage(), nil
+}
+
+// apiError builds an *llm.APIError from a non-OK response, falling back to the raw
+// body when it is not a Claude error object.
+func apiError(status int, body []byte) *llm.APIError {
+	var errorResp struct {
+		Error ErrorDetail `json:"error"`
+	}
+	if json.Unmarshal(body, &errorResp) == nil && errorResp.Error.Message != "" {
+		return &llm.APIError{Provider: "claude", StatusCode: status, Type: errorResp.Error.Type, Message: errorResp.Error.Message}
+	}
+	return &llm.APIError{Provider: "claude", StatusCode: status, Message: redact.String(strings.TrimSpace(string(body)))}
 }
 
 // Close releases resources.
@@ -634,6 +744,45 @@ func (p *Provider) Close() error {
 	return nil
 }
 
+// buildToolChoice maps llm tool choice and parallel tool call options to Claude's tool_choice.
+func buildToolChoice(options *llm.GenerationOptions) *ToolChoice {
+	if options.ToolChoice == nil && options.ParallelToolCalls == nil {
+		return nil
+	}
+
+	choice := &ToolChoice{Type: "auto"}
+	if options.ToolChoice != nil {
+		switch options.ToolChoice.Mode {
+		case llm.ToolChoiceModeNone:
+			return &ToolChoice{Type: "none"}
+		case llm.ToolChoiceModeRequired:
+			choice.Type = "any"
+		case llm.ToolChoiceModeSpecific:
+			choice.Type = "tool"
+			choice.Name = options.ToolChoice.Name
+		}
+	}
+	if options.ParallelToolCalls != nil {
+		choice.DisableParallelToolUse = llm.ValuePtr(!*options.ParallelToolCalls)
+	}
+	return choice
+}
+
+func mergeUsage(dst *Usage, src Usage) {
+	if src.InputTokens > 0 {
+		dst.InputTokens = src.InputTokens
+	}
+	if src.OutputTokens > 0 {
+		dst.OutputTokens = src.OutputTokens
+	}
+	if src.CacheCreationInputTokens > 0 {
+		dst.CacheCreationInputTokens = src.CacheCreationInputTokens
+	}
+	if src.CacheReadInputTokens > 0 {
+		dst.CacheReadInputTokens = src.CacheReadInputTokens
+	}
+}
+
 func convertInterfaceSliceToString(values []interface{}) []string {
 	result := make([]string, 0, len(values))
 	for _, v := range values {
@@ -672,9 +821,5 @@ func extractToolCallsJSON(blocks []ContentBlock) (string, error) {
 		return "", errors.New("no tool calls found in Claude response")
 	}
 
-	bytes, err := json.Marshal(toolCalls)
-	if err != nil {
-		return "", err
-	}
-	return string(bytes), nil
+	return llm.FormatToolCalls(toolCalls)
 }
@@ -0,0 +1,302 @@
+package claude
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ulgerang/llm-module/credential"
+	"github.com/ulgerang/llm-module/llm"
+	"github.com/ulgerang/llm-module/redact"
+	"github.com/ulgerang/llm-module/transport"
+)
+
+type silentLogger struct{}
+
+func (silentLogger) Debug(message string)                        {}
+func (silentLogger) Debugf(format string, args ...interface{})   {}
+func (silentLogger) Info(message string)                         {}
+func (silentLogger) Infof(format string, args ...interface{})    {}
+func (silentLogger) Warning(message string)                      {}
+func (silentLogger) Warningf(format string, args ...interface{}) {}
+func (silentLogger) Error(message string, err error)             {}
+func (silentLogger) Errorf(format string, args ...interface{})   {}
+
+func TestGenerateTextCacheBreakpoints(t *testing.T) {
+	var body string
+	var beta string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+		beta = r.Header.Get("anthropic-beta")
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id":"msg_1","type":"message","role":"assistant","model":"claude","stop_reason":"end_turn",
+			"content":[{"type":"text","text":"Sunny."}],
+			"usage":{"input_tokens":10,"output_tokens":3,"cache_creation_input_tokens":100,"cache_read_input_tokens":2000}}`)
+	}))
+	defer server.Close()
+	t.Setenv("CLAUDE_BASE_URL", server.URL)
+
+	provider, err := New(silentLogger{}, "test-key", "claude-test")
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+
+	tool := &llm.Tool{Name: "get_weather", InputSchema: &llm.SchemaProperty{Type: "object", Properties: map[string]*llm.SchemaProperty{"city": {Type: "string"}}}}
+	history := []llm.Message{
+		llm.UserMessage("Weather in Seoul?"),
+		llm.AssistantToolCallMessage([]llm.ToolCall{{ID: "toolu_1", Name: "get_weather", Input: json.RawMessage(`{"city":"Seoul"}`)}}),
+		llm.ToolResultMessage(llm.ToolResult{ToolCallID: "toolu_1", Name: "get_weather", Content: "sunny"}),
+	}
+	text, usage, err := provider.GenerateText(context.Background(), "Summarize.",
+		llm.WithSystem("You are a weather bot."),
+		llm.WithTools([]*llm.Tool{tool}),
+		llm.WithMessages(history),
+		llm.WithCache(true),
+		llm.WithCacheTTL(llm.CacheTTL1Hour),
+	)
+	if err != nil {
+		t.Fatalf("GenerateText failed: %v", err)
+	}
+	if text != "Sunny." {
+		t.Fatalf("unexpected text: %q", text)
+	}
+
+	cache := `"cache_control":{"type":"ephemeral","ttl":"1h"}`
+	if got := strings.Count(body, cache); got != 3 {
+		t.Errorf("expected 3 cache breakpoints (tools, system, messages), got %d\nbody: %s", got, body)
+	}
+	for _, want := range []string{
+		`{"type":"tool_use","id":"toolu_1","name":"get_weather","input":{"city":"Seoul"}}`,
+		`{"role":"user","content":[{"type":"tool_result","tool_use_id":"toolu_1","content":"sunny"},{"type":"text","text":"Summarize.",` + cache + `}]}`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("request body missing %s\nbody: %s", want, body)
+		}
+	}
+	if beta != extendedCacheTTLBeta {
+		t.Errorf("expected anthropic-beta %q, got %q", extendedCacheTTLBeta, beta)
+	}
+
+	want := llm.UsageInfo{InputTokens: 2110, OutputTokens: 3, CacheCreateTokens: 100, CacheHitTokens: 2000, CacheMissTokens: 110, ResponseModel: "claude", FinishReason: "end_turn"}
+	if usage == nil || *usage != want {
+		t.Fatalf("unexpected usage: %+v", usage)
+	}
+}
+
+func TestRequestPassthrough(t *testing.T) {
+	var body map[string]interface{}
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id":"msg_1","type":"message","role":"assistant","model":"claude","content":[{"type":"text","text":"Hi."}],"usage":{"input_tokens":1,"output_tokens":1}}`)
+	}))
+	defer server.Close()
+	t.Setenv("CLAUDE_BASE_URL", server.URL)
+
+	provider, err := New(silentLogger{}, "test-key", "claude-test",
+		llm.WithDefaultMetadata(map[string]string{llm.MetadataUserID: "default-user"}),
+		llm.WithDefaultExtraBody(map[string]interface{}{"service_tier": "standard_only", "top_k": 5}))
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+	_, _, err = provider.GenerateText(context.Background(), "Hello",
+		llm.WithSystem("Be brief."), llm.WithCache(true), llm.WithCacheTTL(llm.CacheTTL1Hour),
+		llm.WithHeaders(http.Header{"Anthropic-Beta": {"output-128k-2025-02-19"}}),
+		llm.WithMetadata(map[string]string{llm.MetadataUserID: "user-42"}),
+		llm.WithExtraBody(map[string]interface{}{"top_k": 7}))
+	if err != nil {
+		t.Fatalf("GenerateText failed: %v", err)
+	}
+
+	if got := header.Get("anthropic-beta"); got != extendedCacheTTLBeta+",output-128k-2025-02-19" {
+		t.Errorf("expected both betas, got %q", got)
+	}
+	metadata, _ := body["metadata"].(map[string]interface{})
+	if metadata["user_id"] != "user-42" || body["service_tier"] != "standard_only" || body["top_k"] != float64(7) {
+		t.Errorf("unexpected body %v", body)
+	}
+}
+
+func TestResponseSchemaIsPrompted(t *testing.T) {
+	provider, err := New(silentLogger{}, "test-key", "claude-test")
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	// The schema is only described in the system prompt, so callers must validate replies.
+	if caps := provider.Capabilities(); caps.StructuredOutput != llm.StructuredOutputPrompted {
+		t.Errorf("StructuredOutput = %v, want prompted", caps.StructuredOutput)
+	}
+}
+
+func TestStrictModeRejectsUnsupportedSampling(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id":"msg_1","type":"message","role":"assistant","model":"claude","content":[{"type":"text","text":"Hi."}],"usage":{"input_tokens":1,"output_tokens":1}}`)
+	}))
+	defer server.Close()
+	t.Setenv("CLAUDE_BASE_URL", server.URL)
+
+	provider, err := New(silentLogger{}, "test-key", "claude-test")
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+	if _, _, err := provider.GenerateText(context.Background(), "Hello", llm.WithSeed(1)); err != nil {
+		t.Fatalf("GenerateText without strict mode failed: %v", err)
+	}
+	_, _, err = provider.GenerateText(context.Background(), "Hello", llm.WithSeed(1), llm.WithStrict())
+	if !errors.Is(err, llm.ErrUnsupportedOption) {
+		t.Fatalf("expected ErrUnsupportedOption, got %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("expected the strict call to fail before sending, got %d requests", requests)
+	}
+}
+
+func TestRawErrorBodyIsRedacted(t *testing.T) {
+	const key = "sk-ant-REDACTED"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+		fmt.Fprintf(w, "upstream rejected request with x-api-key: %s", r.Header.Get("x-api-key"))
+	}))
+	defer server.Close()
+	t.Setenv("CLAUDE_BASE_URL", server.URL)
+
+	provider, err := New(silentLogger{}, key, "claude-test")
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+	_, _, err = provider.GenerateText(context.Background(), "Hi")
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	var apiErr *llm.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadGateway {
+		t.Fatalf("expected an APIError, got %v", err)
+	}
+	if strings.Contains(err.Error(), key) || strings.Contains(apiErr.Message, key) {
+		t.Errorf("error leaks the API key: %v", err)
+	}
+}
+
+func TestCredentialPoolRotatesAndBenchesKeys(t *testing.T) {
+	const limited, healthy = "pool-key-limited-0001", "pool-key-healthy-0002"
+	var seen []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("x-api-key")
+		seen = append(seen, key)
+		if key == limited {
+			w.WriteHeader(http.StatusTooManyRequests)
+			fmt.Fprint(w, `{"type":"error","error":{"type":"rate_limit_error","message":"slow down"}}`)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id":"msg_1","type":"message","role":"assistant","model":"claude","content":[{"type":"text","text":"Hi."}],"usage":{"input_tokens":1,"output_tokens":1}}`)
+	}))
+	defer server.Close()
+	t.Setenv("CLAUDE_BASE_URL", server.URL)
+	t.Setenv("CLAUDE_API_KEY", "")
+
+	provider, err := New(silentLogger{}, "", "claude-test", llm.WithCredentials(credential.NewPool([]string{limited, healthy})))
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+	for i := 0; i < 4; i++ {
+		provider.GenerateText(context.Background(), "Hello")
+	}
+
+	limitedCalls := 0
+	for _, key := range seen {
+		if key == limited {
+			limitedCalls++
+		}
+	}
+	if len(seen) != 4 || limitedCalls != 1 {
+		t.Errorf("expected the rate-limited key to be used once and then benched, got %v", seen)
+	}
+}
+
+func TestHTTPTraceCapturesExchange(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id":"msg_1","type":"message","role":"assistant","model":"claude","content":[{"type":"text","text":"Hi."}],"usage":{"input_tokens":1,"output_tokens":1}}`)
+	}))
+	defer server.Close()
+	t.Setenv("CLAUDE_BASE_URL", server.URL)
+
+	var exchanges []*transport.Exchange
+	provider, err := New(silentLogger{}, "test-key-123456", "claude-test", transport.WithTrace(transport.SinkFunc(func(e *transport.Exchange) {
+		exchanges = append(exchanges, e)
+	})))
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+	if _, _, err := provider.GenerateText(context.Background(), "Hello"); err != nil {
+		t.Fatalf("GenerateText failed: %v", err)
+	}
+
+	if len(exchanges) != 1 {
+		t.Fatalf("expected 1 traced exchange, got %d", len(exchanges))
+	}
+	e := exchanges[0]
+	if !strings.Contains(e.RequestBody, `"Hello"`) || !strings.Contains(e.ResponseBody, `"Hi."`) || e.StatusCode != http.StatusOK {
+		t.Errorf("unexpected exchange %+v", e)
+	}
+	if got := e.RequestHeader.Get("x-api-key"); got != redact.Placeholder {
+		t.Errorf("x-api-key header not redacted: %q", got)
+	}
+}
+
+func TestStreamIdleTimeoutReturnsStallError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "event: message_start\ndata: {\"type\":\"message_start\",\"message\":{\"model\":\"claude\",\"usage\":{\"input_tokens\":1}}}\n\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+	t.Setenv("CLAUDE_BASE_URL", server.URL)
+
+	provider, err := New(silentLogger{}, "test-key-123456", "claude-test", llm.WithTimeouts(llm.Timeouts{StreamIdle: 50 * time.Millisecond}))
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+	out := make(chan llm.StreamChunk, 10)
+	_, err = provider.GenerateTextStream(context.Background(), "Hello", out)
+	if !errors.Is(err, llm.ErrStreamStalled) {
+		t.Errorf("expected a stall error, got %v", err)
+	}
+}
+
+func TestApplyCacheControlLimitsBreakpoints(t *testing.T) {
+	options := &llm.GenerationOptions{UseCache: true}
+	req := &MessageRequest{
+		Tools:  []Tool{{Name: "a"}},
+		System: []RequestTextBlock{{Type: "text", Text: "a", CacheControl: &CacheControl{Type: "ephemeral"}}, {Type: "text", Text: "b"}},
+		Messages: []Message{{Role: "user", Content: []MessageBlock{
+			{Type: "text", Text: "x", CacheControl: &CacheControl{Type: "ephemeral"}},
+			{Type: "text", Text: "y"},
+		}}},
+	}
+	options.Messages = []llm.Message{llm.UserMessage("x")}
+
+	if _, err := applyCacheControl(req, options); err == nil {
+		t.Fatal("expected an error for five cache breakpoints")
+	}
+
+	options.CacheTTL = "2h"
+	if _, err := applyCacheControl(&MessageRequest{}, options); err == nil {
+		t.Fatal("expected an error for an unsupported TTL")
+	}
+}
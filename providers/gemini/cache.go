@@ -0,0 +1,203 @@
+package gemini
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ulgerang/llm-module/llm"
+
+	"google.golang.org/genai"
+)
+
+// CacheDocument is a document stored in a cached context. Set Text for plain text,
+// Data with MIMEType for inline bytes, or FileURI with MIMEType for an uploaded file.
+type CacheDocument struct {
+	Text     string
+	Data     []byte
+	FileURI  string
+	MIMEType string
+}
+
+// CacheConfig describes a cached context to create. Either TTL or ExpireTime sets its lifetime;
+// Gemini applies a default of one hour when both are zero.
+type CacheConfig struct {
+	DisplayName  string
+	SystemBlocks []llm.SystemBlock
+	Documents    []CacheDocument
+	Tools        []*llm.Tool
+	TTL          time.Duration
+	ExpireTime   time.Time
+}
+
+// CachedContent describes a Gemini cached context. Name is the resource name passed to
+// llm.WithCachedContent.
+type CachedContent struct {
+	Name        string
+	DisplayName string
+	Model       string
+	CreateTime  time.Time
+	UpdateTime  time.Time
+	ExpireTime  time.Time
+	TotalTokens int
+}
+
+// CreateCache stores system blocks, documents and tools as a cached context for the
+// provider's model, so later requests only pay for them at the cached token rate.
+func (p *Provider) CreateCache(ctx context.Context, config CacheConfig) (*CachedContent, error) {
+	createConfig := &genai.CreateCachedContentConfig{
+		DisplayName: config.DisplayName,
+		TTL:         config.TTL,
+		ExpireTime:  config.ExpireTime,
+	}
+
+	var system strings.Builder
+	for _, block := range config.SystemBlocks {
+		system.WriteString(block.Text)
+		system.WriteString("\n\n")
+	}
+	if instruction := strings.TrimSpace(system.String()); instruction != "" {
+		createConfig.SystemInstruction = &genai.Content{Role: genai.RoleUser, Parts: []*genai.Part{{Text: instruction}}}
+	}
+
+	if len(config.Documents) > 0 {
+		content := &genai.Content{Role: genai.RoleUser}
+		for i, document := range config.Documents {
+			part, err := documentPart(document)
+			if err != nil {
+				return nil, fmt.Errorf("invalid cache document %d: %w", i, err)
+			}
+			content.Parts = append(content.Parts, part)
+		}
+		createConfig.Contents = []*genai.Content{content}
+	}
+
+	tools, err := buildTools(config.Tools)
+	if err != nil {
+		return nil, err
+	}
+	createConfig.Tools = tools
+
+	cached, err := p.client.Caches.Create(ctx, p.modelName, createConfig)
+	if err != nil {
+		p.logger.Error("[Gemini] Failed to create cached content", err)
+		return nil, err
+	}
+	p.logger.Infof("[Gemini] Created cached content %s", cached.Name)
+	return convertCachedContent(cached), nil
+}
+
+// GetCache returns the cached context with the given resource name.
+func (p *Provider) GetCache(ctx context.Context, name string) (*CachedContent, error) {
+	cached, err := p.client.Caches.Get(ctx, name, nil)
+	if err != nil {
+		return nil, err
+	}
+	return convertCachedContent(cached), nil
+}
+
+// ListCaches returns every cached context visible to the API key.
+func (p *Provider) ListCaches(ctx context.Context) ([]*CachedContent, error) {
+	var caches []*CachedContent
+	for cached, err := range p.client.Caches.All(ctx) {
+		if err != nil {
+			return nil, err
+		}
+		caches = append(caches, convertCachedContent(cached))
+	}
+	return caches, nil
+}
+
+// ExtendCache sets a new TTL on a cached context, counted from now.
+func (p *Provider) ExtendCache(ctx context.Context, name string, ttl time.Duration) (*CachedContent, error) {
+	if ttl <= 0 {
+		return nil, errors.New("cache TTL must be positive")
+	}
+	cached, err := p.client.Caches.Update(ctx, name, &genai.UpdateCachedContentConfig{TTL: ttl})
+	if err != nil {
+		p.logger.Error("[Gemini] Failed to extend cached content", err)
+		return nil, err
+	}
+	return convertCachedContent(cached), nil
+}
+
+// DeleteCache removes a cached context before it expires.
+func (p *Provider) DeleteCache(ctx context.Context, name string) error {
+	if _, err := p.client.Caches.Delete(ctx, name, nil); err != nil {
+		p.logger.Error("[Gemini] Failed to delete cached content", err)
+		return err
+	}
+	p.logger.Infof("[Gemini] Deleted cached content %s", name)
+	return nil
+}
+
+func documentPart(document CacheDocument) (*genai.Part, error) {
+	switch {
+	case document.Text != "":
+		return &genai.Part{Text: document.Text}, nil
+	case len(document.Data) > 0:
+		if document.MIMEType == "" {
+			return nil, errors.New("inline data needs a MIME type")
+		}
+		return &genai.Part{InlineData: &genai.Blob{Data: document.Data, MIMEType: document.MIMEType}}, nil
+	case document.FileURI != "":
+		return &genai.Part{FileData: &genai.FileData{FileURI: document.FileURI, MIMEType: document.MIMEType}}, nil
+	}
+	return nil, errors.New("document has no text, data or file URI")
+}
+
+func convertCachedContent(cached *genai.CachedContent) *CachedContent {
+	result := &CachedContent{
+		Name:        cached.Name,
+		DisplayName: cached.DisplayName,
+		Model:       cached.Model,
+		CreateTime:  cached.CreateTime,
+		UpdateTime:  cached.UpdateTime,
+		ExpireTime:  cached.ExpireTime,
+	}
+	if cached.UsageMetadata != nil {
+		result.TotalTokens = int(cached.UsageMetadata.TotalTokenCount)
+	}
+	return result
+}
+
+// applyCachedContent points the request at a cached context. Gemini rejects requests that
+// combine cached content with a system instruction, tools or tool config, so those must
+// live in the cache instead; an explicit system instruction is reported as unsupported
+// and dropped. Gemini has no cache breakpoints, so WithCache without cached content is
+// reported as unsupported.
+func (p *Provider) applyCachedContent(config *genai.GenerateContentConfig, options *llm.GenerationOptions) error {
+	if options.CachedContent == "" {
+		if usesCacheBreakpoints(options) {
+			return options.Unsupported("gemini", "UseCache", "create a cache with CreateCache and use WithCachedContent", p.logger.Warning)
+		}
+		return nil
+	}
+	if config.Tools != nil || config.ToolConfig != nil {
+		return llm.NewUnsupportedOptionError("gemini", "Tools", "tools must be stored in the cached content when WithCachedContent is used")
+	}
+	if config.SystemInstruction != nil {
+		if options.System != defaultSystemPrompt || len(options.SystemBlocks) > 0 {
+			if err := options.Unsupported("gemini", "System", "the system instruction must be stored in the cached content when WithCachedContent is used", p.logger.Warning); err != nil {
+				return err
+			}
+		}
+		config.SystemInstruction = nil
+	}
+	config.CachedContent = options.CachedContent
+	return nil
+}
+
+func usesCacheBreakpoints(options *llm.GenerationOptions) bool {
+	if options.UseCache {
+		return true
+	}
+	for _, block := range options.SystemBlocks {
+		if block.UseCache {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,122 @@
+package gemini
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ulgerang/llm-module/llm"
+
+	"google.golang.org/genai"
+)
+
+type silentLogger struct{}
+
+func (silentLogger) Debug(message string)                        {}
+func (silentLogger) Debugf(format string, args ...interface{})   {}
+func (silentLogger) Info(message string)                         {}
+func (silentLogger) Infof(format string, args ...interface{})    {}
+func (silentLogger) Warning(message string)                      {}
+func (silentLogger) Warningf(format string, args ...interface{}) {}
+func (silentLogger) Error(message string, err error)             {}
+func (silentLogger) Errorf(format string, args ...interface{})   {}
+
+// newStubProvider returns a provider whose client talks to handler instead of the Gemini API.
+func newStubProvider(t *testing.T, handler http.HandlerFunc) *Provider {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	client, err := genai.NewClient(context.Background(), &genai.ClientConfig{
+		APIKey:      "test-key",
+		Backend:     genai.BackendGeminiAPI,
+		HTTPOptions: genai.HTTPOptions{BaseURL: server.URL},
+	})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	return &Provider{client: client, logger: silentLogger{}, modelName: "gemini-test"}
+}
+
+func TestCreateCacheWireFormat(t *testing.T) {
+	var path, body string
+	provider := newStubProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		path, body = r.URL.Path, string(data)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"name":"cachedContents/abc","model":"models/gemini-test","displayName":"policy",
+			"expireTime":"2030-01-01T00:00:00Z","usageMetadata":{"totalTokenCount":40000}}`)
+	})
+
+	cached, err := provider.CreateCache(context.Background(), CacheConfig{
+		DisplayName:  "policy",
+		SystemBlocks: []llm.SystemBlock{{Text: "Answer from the manual."}},
+		Documents:    []CacheDocument{{Text: "Section 1: ..."}},
+		TTL:          time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("CreateCache failed: %v", err)
+	}
+
+	if !strings.HasSuffix(path, "/cachedContents") {
+		t.Errorf("unexpected path %s", path)
+	}
+	for _, want := range []string{`"model":"models/gemini-test"`, `"ttl":"3600s"`, `"Answer from the manual."`, `"Section 1: ..."`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("request body missing %s\nbody: %s", want, body)
+		}
+	}
+	if cached.Name != "cachedContents/abc" || cached.TotalTokens != 40000 {
+		t.Fatalf("unexpected cached content: %+v", cached)
+	}
+}
+
+func TestGenerateTextWithCachedContent(t *testing.T) {
+	var body string
+	provider := newStubProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"candidates":[{"content":{"role":"model","parts":[{"text":"Refunds take 5 days."}]}}],
+			"usageMetadata":{"promptTokenCount":40020,"candidatesTokenCount":6,"cachedContentTokenCount":40000}}`)
+	})
+
+	text, usage, err := provider.GenerateText(context.Background(), "How long do refunds take?", llm.WithCachedContent("cachedContents/abc"))
+	if err != nil {
+		t.Fatalf("GenerateText failed: %v", err)
+	}
+	if text != "Refunds take 5 days." {
+		t.Fatalf("unexpected text %q", text)
+	}
+	if !strings.Contains(body, `"cachedContent":"cachedContents/abc"`) || strings.Contains(body, "systemInstruction") {
+		t.Errorf("unexpected request body: %s", body)
+	}
+
+	want := llm.UsageInfo{InputTokens: 40020, OutputTokens: 6, CacheHitTokens: 40000, CacheMissTokens: 20}
+	if *usage != want {
+		t.Fatalf("got usage %+v, want %+v", *usage, want)
+	}
+
+	_, _, err = provider.GenerateText(context.Background(), "hi",
+		llm.WithCachedContent("cachedContents/abc"),
+		llm.WithTools([]*llm.Tool{{Name: "lookup"}}),
+	)
+	if err == nil {
+		t.Fatal("expected an error when tools are combined with cached content")
+	}
+
+	_, _, err = provider.GenerateText(context.Background(), "hi",
+		llm.WithCachedContent("cachedContents/abc"),
+		llm.WithSystem("Answer in French."),
+		llm.WithStrict(),
+	)
+	if !errors.Is(err, llm.ErrUnsupportedOption) {
+		t.Fatalf("expected ErrUnsupportedOption for a system instruction with cached content, got %v", err)
+	}
+}
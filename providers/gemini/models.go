@@ -0,0 +1,30 @@
+package gemini
+
+import (
+	"context"
+	"strings"
+
+	"github.com/ulgerang/llm-module/llm"
+)
+
+// ListModels lists the models available to the client with their token limits.
+func (p *Provider) ListModels(ctx context.Context) ([]llm.Model, error) {
+	var models []llm.Model
+	for model, err := range p.client.Models.All(ctx) {
+		if err != nil {
+			return nil, apiError(err)
+		}
+		id := model.Name
+		if i := strings.LastIndex(id, "/"); i >= 0 {
+			id = id[i+1:]
+		}
+		models = append(models, llm.FillModelLimits(llm.Model{
+			ID:              id,
+			DisplayName:     model.DisplayName,
+			OwnedBy:         "google",
+			ContextWindow:   int(model.InputTokenLimit),
+			MaxOutputTokens: int(model.OutputTokenLimit),
+		}))
+	}
+	return models, nil
+}
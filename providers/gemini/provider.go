@@ -5,33 +5,52 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
+	"net/http"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/ulgerang/llm-module/llm"
 	"github.com/ulgerang/llm-module/logger"
+	"github.com/ulgerang/llm-module/redact"
+	"github.com/ulgerang/llm-module/transport"
 	"github.com/ulgerang/llm-module/utils"
 
 	"google.golang.org/genai"
 )
 
-const defaultGeminiModel = "gemini-pro"
+const defaultGeminiModel = "gemini-2.5-flash"
+
+// defaultSystemPrompt is the system instruction of calls that do not set one.
+const defaultSystemPrompt = "You are a helpful assistant."
+
+// responseSchemaWithTools explains why a response schema is dropped from calls with tools.
+const responseSchemaWithTools = "a response schema cannot be combined with function calling"
+
+// capabilities lists the features the Gemini provider supports.
+var capabilities = llm.Capabilities{Streaming: true, Tools: true, StreamingTools: true, ParallelTools: true, StructuredOutput: llm.StructuredOutputNative, Caching: true, Logprobs: true, Candidates: true}
 
 // Provider implements llm.Provider for Google's Gemini models.
 type Provider struct {
-	client    *genai.Client
-	logger    logger.Logger
-	modelName string
+	client     *genai.Client
+	logger     logger.Logger
+	modelName  string
+	streamIdle time.Duration
+	defaults   []llm.GenerationOption
 }
 
 // New creates a new Gemini provider instance.
-func New(log logger.Logger, apiKey, modelName string) (*Provider, error) {
+func New(log logger.Logger, apiKey, modelName string, opts ...llm.ProviderOption) (*Provider, error) {
+	config := llm.ResolveProviderOptions(opts...)
+	apiKey, err := config.ResolveAPIKey(apiKey, "GEMINI_API_KEY")
+	if err != nil {
+		return nil, err
+	}
 	if apiKey == "" {
-		apiKey = os.Getenv("GEMINI_API_KEY")
-		if apiKey == "" {
-			return nil, errors.New("GEMINI_API_KEY not provided")
-		}
+		return nil, errors.New("GEMINI_API_KEY not provided")
 	}
+	redact.AddSecret(apiKey)
 
 	if modelName == "" {
 		modelName = os.Getenv("GEMINI_MODEL")
@@ -42,26 +61,119 @@ func New(log logger.Logger, apiKey, modelName string) (*Provider, error) {
 
 	ctx := context.Background()
 
-	var (
-		client *genai.Client
-		err    error
-	)
+	// genai ends its stream iterator silently when a read fails, so the stream
+	// inactivity timeout is enforced on the iterator instead of the HTTP body.
+	var streamIdle time.Duration
+	if config.Timeouts != nil {
+		timeouts := *config.Timeouts
+		streamIdle, timeouts.StreamIdle = timeouts.StreamIdle, 0
+		config.Timeouts = &timeouts
+	}
 
+	var client *genai.Client
 	if os.Getenv("GEMINI_USING_VERTEXAI") == "true" {
-		client, err = genai.NewClient(ctx, &genai.ClientConfig{
-			Project:  os.Getenv("GEMINI_PROJECT"),
-			Location: os.Getenv("GEMINI_LOCATION"),
-			Backend:  genai.BackendVertexAI,
-		})
+		client, err = newVertexClient(ctx, config)
 	} else {
-		client, err = genai.NewClient(ctx, &genai.ClientConfig{APIKey: apiKey})
+		var httpClient *http.Client
+		if httpClient, err = transport.NewClient(config, nil); err != nil {
+			return nil, err
+		}
+		client, err = genai.NewClient(ctx, &genai.ClientConfig{APIKey: apiKey, HTTPClient: httpClient})
 	}
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Gemini client: %w", err)
 	}
 
-	return &Provider{client: client, logger: log, modelName: modelName}, nil
+	return &Provider{client: client, logger: logger.With(log, logger.Provider("gemini"), logger.Model(modelName)), modelName: modelName, streamIdle: streamIdle, defaults: config.RequestDefaults()}, nil
+}
+
+// newVertexClient creates a Vertex AI client. genai builds an authenticated HTTP client
+// unless given one, so without WithHTTPClient the transport settings are installed on
+// the client it created.
+func newVertexClient(ctx context.Context, config *llm.ProviderConfig) (*genai.Client, error) {
+	// Vertex AI authenticates with Google Cloud credentials, not API keys.
+	vertexConfig := *config
+	vertexConfig.Credentials = nil
+	config = &vertexConfig
+
+	clientConfig := &genai.ClientConfig{
+		Project:  os.Getenv("GEMINI_PROJECT"),
+		Location: os.Getenv("GEMINI_LOCATION"),
+		Backend:  genai.BackendVertexAI,
+	}
+	if config.HTTPClient != nil {
+		httpClient, err := transport.NewClient(config, nil)
+		if err != nil {
+			return nil, err
+		}
+		clientConfig.HTTPClient = httpClient
+		return genai.NewClient(ctx, clientConfig)
+	}
+
+	client, err := genai.NewClient(ctx, clientConfig)
+	if err != nil {
+		return nil, err
+	}
+	if httpClient := client.ClientConfig().HTTPClient; httpClient != nil {
+		configured, err := transport.NewClient(config, httpClient)
+		if err != nil {
+			return nil, err
+		}
+		*httpClient = *configured
+	}
+	return client, nil
+}
+
+// applyPassthrough sets the headers and metadata of a call. Metadata is sent as labels,
+// which only Vertex AI accepts. genai cannot add fields to the request body.
+func (p *Provider) applyPassthrough(config *genai.GenerateContentConfig, options *llm.GenerationOptions) error {
+	if len(options.ExtraBody) > 0 {
+		return llm.NewUnsupportedOptionError("gemini", "ExtraBody", "the genai client cannot add fields to the request body")
+	}
+	if len(options.Headers) > 0 {
+		config.HTTPOptions = &genai.HTTPOptions{Headers: options.Headers.Clone()}
+	}
+	if len(options.Metadata) > 0 && p.client.ClientConfig().Backend == genai.BackendVertexAI {
+		config.Labels = options.Metadata
+	}
+	return nil
+}
+
+// applySampling sets the stop sequences, seed, penalties, logprobs and candidate count
+// of a call. Gemini has no logit bias, and streams carry a single candidate without
+// logprobs.
+func (p *Provider) applySampling(config *genai.GenerateContentConfig, options *llm.GenerationOptions, stream bool) error {
+	config.StopSequences = options.StopSequences
+	if options.Seed != nil {
+		if *options.Seed < math.MinInt32 || *options.Seed > math.MaxInt32 {
+			if err := options.Unsupported("gemini", "Seed", "seeds must fit in 32 bits", p.logger.Warning); err != nil {
+				return err
+			}
+		} else {
+			config.Seed = llm.ValuePtr(int32(*options.Seed))
+		}
+	}
+	config.PresencePenalty = options.PresencePenalty
+	config.FrequencyPenalty = options.FrequencyPenalty
+	if stream {
+		return options.CheckUnsupported("gemini", p.logger.Warning, "LogitBias", "Logprobs", "CandidateCount")
+	}
+	if options.Logprobs != nil {
+		config.ResponseLogprobs = true
+		if *options.Logprobs > 0 {
+			config.Logprobs = options.Logprobs
+		}
+	}
+	if options.CandidateCount != nil {
+		config.CandidateCount = *options.CandidateCount
+	}
+	return options.CheckUnsupported("gemini", p.logger.Warning, "LogitBias")
+}
+
+// Capabilities reports the features of the provider and its model.
+func (p *Provider) Capabilities() llm.Capabilities {
+	return llm.ModelCapabilities(capabilities, p.modelName)
 }
 
 // GetModelName returns the configured Gemini model name.
@@ -76,7 +188,10 @@ func (p *Provider) GenerateText(ctx context.Context, prompt string, opts ...llm.
 		MaxTokens:   llm.ValuePtr(int32(4096)),
 		TopK:        llm.ValuePtr(float32(40)),
 		TopP:        llm.ValuePtr(float32(0.95)),
-		System:      "You are a helpful assistant.",
+		System:      defaultSystemPrompt,
+	}
+	for _, opt := range p.defaults {
+		opt(options)
 	}
 	for _, opt := range opts {
 		opt(options)
@@ -118,16 +233,27 @@ func (p *Provider) GenerateText(ctx context.Context, prompt string, opts ...llm.
 		}
 	}
 
-	contents := []*genai.Content{{
-		Role:  genai.RoleUser,
-		Parts: []*genai.Part{{Text: prompt}},
-	}}
+	contents, err := buildContents(options, prompt)
+	if err != nil {
+		return "", nil, err
+	}
 
 	if options.ResponseFormat != "" {
 		config.ResponseMIMEType = options.ResponseFormat
 	}
 
-	if options.ResponseSchema != nil {
+	if len(options.Tools) > 0 {
+		if options.ResponseSchema != nil {
+			if err := options.Unsupported("gemini", "ResponseSchema", responseSchemaWithTools, p.logger.Warning); err != nil {
+				return "", nil, err
+			}
+		}
+		tools, err := buildTools(options.Tools)
+		if err != nil {
+			return "", nil, err
+		}
+		config.Tools = tools
+	} else if options.ResponseSchema != nil {
 		config.ResponseMIMEType = "application/json"
 		schema, err := schemaToGenaiSchema(options.ResponseSchema)
 		if err != nil {
@@ -136,6 +262,22 @@ func (p *Provider) GenerateText(ctx context.Context, prompt string, opts ...llm.
 		config.ResponseSchema = schema
 	}
 
+	toolConfig, err := buildToolConfig(options)
+	if err != nil {
+		return "", nil, err
+	}
+	config.ToolConfig = toolConfig
+
+	if err := p.applyCachedContent(config, options); err != nil {
+		return "", nil, err
+	}
+	if err := p.applyPassthrough(config, options); err != nil {
+		return "", nil, err
+	}
+	if err := p.applySampling(config, options, false); err != nil {
+		return "", nil, err
+	}
+
 	if options.AllowSexualContent {
 		config.SafetySettings = []*genai.SafetySetting{
 			{Category: genai.HarmCategorySexuallyExplicit, Threshold: genai.HarmBlockThresholdOff},
@@ -152,6 +294,7 @@ func (p *Provider) GenerateText(ctx context.Context, prompt string, opts ...llm.
 
 	resp, err := p.client.Models.GenerateContent(ctx, p.modelName, contents, config)
 	if err != nil {
+		err = apiError(err)
 		p.logger.Error(fmt.Sprintf("Failed to generate Gemini content: %v", err), err)
 		return "", nil, err
 	}
@@ -161,6 +304,22 @@ func (p *Provider) GenerateText(ctx context.Context, prompt string, opts ...llm.
 		return "", nil, errors.New("no content generated")
 	}
 
+	usage := convertGeminiUsage(resp)
+	usage.Completion = completion(options, resp.Candidates)
+
+	toolCalls, err := extractToolCalls(resp.Candidates[0].Content.Parts, 0)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(toolCalls) > 0 {
+		result, err := llm.FormatToolCalls(toolCalls)
+		if err != nil {
+			return "", nil, err
+		}
+		p.logger.Info(fmt.Sprintf("Gemini requested %d function call(s)", len(toolCalls)))
+		return result, usage, nil
+	}
+
 	var generated strings.Builder
 	for _, part := range resp.Candidates[0].Content.Parts {
 		generated.WriteString(part.Text)
@@ -171,8 +330,7 @@ func (p *Provider) GenerateText(ctx context.Context, prompt string, opts ...llm.
 		return "", nil, errors.New("unexpected empty Gemini response")
 	}
 
-	usage := convertGeminiUsage(resp)
-	p.logger.Info(fmt.Sprintf("Generated text (Gemini): %s", text))
+	logger.LogContent(p.logger, "[Gemini] Generated text", text)
 	return text, usage, nil
 }
 
@@ -185,7 +343,10 @@ func (p *Provider) GenerateTextStream(ctx context.Context, prompt string, outCha
 		MaxTokens:   llm.ValuePtr(int32(4096)),
 		TopK:        llm.ValuePtr(float32(40)),
 		TopP:        llm.ValuePtr(float32(0.95)),
-		System:      "You are a helpful assistant.",
+		System:      defaultSystemPrompt,
+	}
+	for _, opt := range p.defaults {
+		opt(options)
 	}
 	for _, opt := range opts {
 		opt(options)
@@ -230,17 +391,56 @@ func (p *Provider) GenerateTextStream(ctx context.Context, prompt string, outCha
 		}
 	}
 
-	contents := []*genai.Content{{
-		Role:  genai.RoleUser,
-		Parts: []*genai.Part{{Text: prompt}},
-	}}
+	contents, err := buildContents(options, prompt)
+	if err != nil {
+		outChan <- llm.StreamChunk{Err: err}
+		return nil, err
+	}
 
 	if options.ResponseFormat != "" {
 		config.ResponseMIMEType = options.ResponseFormat
 	}
-	if options.ResponseSchema != nil {
-		p.logger.Warning("ResponseSchema requested with Gemini streaming; client-side parsing will be required.")
+	if len(options.Tools) > 0 {
+		if options.ResponseSchema != nil {
+			if err := options.Unsupported("gemini", "ResponseSchema", responseSchemaWithTools, p.logger.Warning); err != nil {
+				outChan <- llm.StreamChunk{Err: err}
+				return nil, err
+			}
+		}
+		tools, err := buildTools(options.Tools)
+		if err != nil {
+			outChan <- llm.StreamChunk{Err: err}
+			return nil, err
+		}
+		config.Tools = tools
+	} else if options.ResponseSchema != nil {
 		config.ResponseMIMEType = "application/json"
+		schema, err := schemaToGenaiSchema(options.ResponseSchema)
+		if err != nil {
+			outChan <- llm.StreamChunk{Err: err}
+			return nil, err
+		}
+		config.ResponseSchema = schema
+	}
+
+	toolConfig, err := buildToolConfig(options)
+	if err != nil {
+		outChan <- llm.StreamChunk{Err: err}
+		return nil, err
+	}
+	config.ToolConfig = toolConfig
+
+	if err := p.applyCachedContent(config, options); err != nil {
+		outChan <- llm.StreamChunk{Err: err}
+		return nil, err
+	}
+	if err := p.applyPassthrough(config, options); err != nil {
+		outChan <- llm.StreamChunk{Err: err}
+		return nil, err
+	}
+	if err := p.applySampling(config, options, true); err != nil {
+		outChan <- llm.StreamChunk{Err: err}
+		return nil, err
 	}
 
 	if options.AllowSexualContent {
@@ -254,12 +454,20 @@ func (p *Provider) GenerateTextStream(ctx context.Context, prompt string, outCha
 
 	p.logger.Info("Starting Gemini streaming generation")
 
-	iter := p.client.Models.GenerateContentStream(ctx, p.modelName, contents, config)
+	streamCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	watchdog := newIdleWatchdog(p.streamIdle, cancel)
+	defer watchdog.stop()
+
+	iter := p.client.Models.GenerateContentStream(streamCtx, p.modelName, contents, config)
 
 	var finalResp *genai.GenerateContentResponse
+	toolCallCount := 0
 
 	for resp, err := range iter {
+		watchdog.reset()
 		if err != nil {
+			err = apiError(err)
 			p.logger.Error(fmt.Sprintf("Error reading Gemini stream: %v", err), err)
 			outChan <- llm.StreamChunk{Err: fmt.Errorf("stream read error: %w", err)}
 			return &llm.UsageInfo{}, err
@@ -276,6 +484,17 @@ func (p *Provider) GenerateTextStream(ctx context.Context, prompt string, outCha
 			for _, part := range resp.Candidates[0].Content.Parts {
 				deltaBuilder.WriteString(part.Text)
 			}
+
+			// Gemini streams each function call whole, so calls are forwarded as soon as they arrive.
+			toolCalls, err := extractToolCalls(resp.Candidates[0].Content.Parts, toolCallCount)
+			if err != nil {
+				outChan <- llm.StreamChunk{Err: err}
+				return convertGeminiUsage(finalResp), err
+			}
+			if len(toolCalls) > 0 {
+				toolCallCount += len(toolCalls)
+				outChan <- llm.StreamChunk{ToolCalls: toolCalls}
+			}
 		}
 
 		delta := deltaBuilder.String()
@@ -284,6 +503,12 @@ func (p *Provider) GenerateTextStream(ctx context.Context, prompt string, outCha
 		}
 	}
 
+	if err := context.Cause(streamCtx); errors.Is(err, llm.ErrStreamStalled) {
+		p.logger.Error("Gemini stream stalled", err)
+		outChan <- llm.StreamChunk{Err: err}
+		return convertGeminiUsage(finalResp), err
+	}
+
 	usage := convertGeminiUsage(finalResp)
 	if usage.InputTokens == 0 && usage.OutputTokens == 0 {
 		p.logger.Warning("Gemini stream finished without usage metadata")
@@ -294,6 +519,35 @@ func (p *Provider) GenerateTextStream(ctx context.Context, prompt string, outCha
 	return usage, nil
 }
 
+// idleWatchdog cancels a stream with a *llm.StreamStallError when it is not reset
+// within timeout. A zero timeout disables it.
+type idleWatchdog struct {
+	timeout time.Duration
+	timer   *time.Timer
+}
+
+func newIdleWatchdog(timeout time.Duration, cancel context.CancelCauseFunc) *idleWatchdog {
+	w := &idleWatchdog{timeout: timeout}
+	if timeout > 0 {
+		w.timer = time.AfterFunc(timeout, func() {
+			cancel(&llm.StreamStallError{Timeout: timeout})
+		})
+	}
+	return w
+}
+
+func (w *idleWatchdog) reset() {
+	if w.timer != nil {
+		w.timer.Reset(w.timeout)
+	}
+}
+
+func (w *idleWatchdog) stop() {
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+}
+
 // Close releases the Gemini client resources.
 func (p *Provider) Close() error {
 	p.logger.Info("[Gemini] Provider closed.")
@@ -302,13 +556,98 @@ func (p *Provider) Close() error {
 
 func convertGeminiUsage(resp *genai.GenerateContentResponse) *llm.UsageInfo {
 	usage := &llm.UsageInfo{}
+	if resp != nil {
+		usage.ResponseModel = resp.ModelVersion
+		if len(resp.Candidates) > 0 {
+			usage.FinishReason = string(resp.Candidates[0].FinishReason)
+		}
+	}
 	if resp != nil && resp.UsageMetadata != nil {
 		usage.InputTokens = int(resp.UsageMetadata.PromptTokenCount)
 		usage.OutputTokens = int(resp.UsageMetadata.CandidatesTokenCount)
+		// PromptTokenCount already includes the cached tokens.
+		if cached := int(resp.UsageMetadata.CachedContentTokenCount); cached > 0 {
+			usage.CacheHitTokens = cached
+			usage.CacheMissTokens = usage.InputTokens - cached
+		}
 	}
 	return usage
 }
 
+// completion converts every response candidate into an llm.Candidate, or returns nil
+// unless the options ask for logprobs or several candidates.
+func completion(options *llm.GenerationOptions, responses []*genai.Candidate) *llm.Completion {
+	if options.Logprobs == nil && options.CandidateCount == nil {
+		return nil
+	}
+	result := make([]llm.Candidate, 0, len(responses))
+	for _, response := range responses {
+		candidate := llm.Candidate{FinishReason: string(response.FinishReason)}
+		if response.Content != nil {
+			var text strings.Builder
+			for _, part := range response.Content.Parts {
+				text.WriteString(part.Text)
+			}
+			candidate.Text = text.String()
+		}
+		if logprobs := response.LogprobsResult; logprobs != nil {
+			for i, chosen := range logprobs.ChosenCandidates {
+				token := llm.TokenLogprob{Token: chosen.Token, Logprob: float64(chosen.LogProbability)}
+				if i < len(logprobs.TopCandidates) && logprobs.TopCandidates[i] != nil {
+					for _, top := range logprobs.TopCandidates[i].Candidates {
+						token.TopLogprobs = append(token.TopLogprobs, llm.TopLogprob{Token: top.Token, Logprob: float64(top.LogProbability)})
+					}
+				}
+				candidate.Logprobs = append(candidate.Logprobs, token)
+			}
+		}
+		result = append(result, candidate)
+	}
+	return &llm.Completion{Candidates: result}
+}
+
+// apiError converts a Gemini error response into an *llm.APIError.
+func apiError(err error) error {
+	var genaiErr genai.APIError
+	if !errors.As(err, &genaiErr) {
+		return err
+	}
+	return &llm.APIError{
+		Provider:   "gemini",
+		StatusCode: genaiErr.Code,
+		Type:       genaiErr.Status,
+		Message:    genaiErr.Message,
+		Err:        err,
+	}
+}
+
+// buildToolConfig maps llm tool choice options to Gemini's function calling config.
+func buildToolConfig(options *llm.GenerationOptions) (*genai.ToolConfig, error) {
+	if err := llm.ValidateToolChoice(options); err != nil {
+		return nil, err
+	}
+	if options.ParallelToolCalls != nil && !*options.ParallelToolCalls {
+		return nil, llm.NewUnsupportedOptionError("gemini", "ParallelToolCalls", "Gemini cannot disable parallel function calls")
+	}
+	if options.ToolChoice == nil {
+		return nil, nil
+	}
+
+	callingConfig := &genai.FunctionCallingConfig{}
+	switch options.ToolChoice.Mode {
+	case llm.ToolChoiceModeAuto:
+		callingConfig.Mode = genai.FunctionCallingConfigModeAuto
+	case llm.ToolChoiceModeNone:
+		callingConfig.Mode = genai.FunctionCallingConfigModeNone
+	case llm.ToolChoiceModeRequired:
+		callingConfig.Mode = genai.FunctionCallingConfigModeAny
+	case llm.ToolChoiceModeSpecific:
+		callingConfig.Mode = genai.FunctionCallingConfigModeAny
+		callingConfig.AllowedFunctionNames = []string{options.ToolChoice.Name}
+	}
+	return &genai.ToolConfig{FunctionCallingConfig: callingConfig}, nil
+}
+
 func schemaToGenaiSchema(property *llm.SchemaProperty) (*genai.Schema, error) {
 	if property == nil {
 		return nil, errors.New("input SchemaProperty cannot be nil")
@@ -323,6 +662,7 @@ func schemaToGenaiSchema(property *llm.SchemaProperty) (*genai.Schema, error) {
 	if err := json.Unmarshal([]byte(schemaJSON), schema); err != nil {
 		return nil, err
 	}
+	normalizeSchemaTypes(schema)
 
 	return schema, nil
 }
@@ -0,0 +1,60 @@
+package gemini
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ulgerang/llm-module/llm"
+)
+
+func TestStreamIdleTimeoutReturnsStallError(t *testing.T) {
+	provider := newStubProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"candidates\":[{\"content\":{\"role\":\"model\",\"parts\":[{\"text\":\"Hel\"}]}}]}\n\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	})
+	provider.streamIdle = 50 * time.Millisecond
+
+	out := make(chan llm.StreamChunk, 10)
+	_, err := provider.GenerateTextStream(context.Background(), "Hello", out)
+	if !errors.Is(err, llm.ErrStreamStalled) {
+		t.Fatalf("expected a stall error, got %v", err)
+	}
+	if chunk := <-out; chunk.Delta != "Hel" {
+		t.Errorf("expected the delta before the stall, got %+v", chunk)
+	}
+}
+
+func TestSeedOutsideInt32IsUnsupported(t *testing.T) {
+	var body string
+	provider := newStubProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"candidates":[{"content":{"role":"model","parts":[{"text":"ok"}]}}]}`)
+	})
+
+	_, _, err := provider.GenerateText(context.Background(), "hi", llm.WithSeed(1<<40), llm.WithStrict())
+	if !errors.Is(err, llm.ErrUnsupportedOption) {
+		t.Errorf("GenerateText() error = %v, want ErrUnsupportedOption", err)
+	}
+	if _, _, err := provider.GenerateText(context.Background(), "hi", llm.WithSeed(1<<40)); err != nil {
+		t.Fatalf("GenerateText failed: %v", err)
+	}
+	if strings.Contains(body, "seed") {
+		t.Errorf("a seed outside the int32 range should not be sent: %s", body)
+	}
+	if _, _, err := provider.GenerateText(context.Background(), "hi", llm.WithSeed(7)); err != nil {
+		t.Fatalf("GenerateText failed: %v", err)
+	}
+	if !strings.Contains(body, `"seed":7`) {
+		t.Errorf("request body missing seed:7: %s", body)
+	}
+}
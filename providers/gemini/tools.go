@@ -0,0 +1,159 @@
+package gemini
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/ulgerang/llm-module/llm"
+
+	"google.golang.org/genai"
+)
+
+// buildTools converts llm tools into a single Gemini tool holding their function declarations.
+func buildTools(tools []*llm.Tool) ([]*genai.Tool, error) {
+	if len(tools) == 0 {
+		return nil, nil
+	}
+
+	declarations := make([]*genai.FunctionDeclaration, 0, len(tools))
+	for _, tool := range tools {
+		if tool == nil {
+			continue
+		}
+		declaration := &genai.FunctionDeclaration{
+			Name:        tool.Name,
+			Description: tool.Description,
+		}
+		// Gemini rejects object parameters without properties; parameterless functions leave it unset.
+		if tool.InputSchema != nil && (tool.InputSchema.Type != "object" || len(tool.InputSchema.Properties) > 0) {
+			schema, err := schemaToGenaiSchema(tool.InputSchema)
+			if err != nil {
+				return nil, fmt.Errorf("failed to convert schema for tool '%s': %w", tool.Name, err)
+			}
+			declaration.Parameters = schema
+		}
+		declarations = append(declarations, declaration)
+	}
+	return []*genai.Tool{{FunctionDeclarations: declarations}}, nil
+}
+
+// buildContents converts the conversation history and prompt into Gemini contents.
+// Consecutive tool results are grouped into one user turn, as Gemini expects all
+// function responses for a model turn to arrive together.
+func buildContents(options *llm.GenerationOptions, prompt string) ([]*genai.Content, error) {
+	if len(options.Messages) == 0 {
+		return []*genai.Content{{
+			Role:  genai.RoleUser,
+			Parts: []*genai.Part{{Text: prompt}},
+		}}, nil
+	}
+
+	var contents []*genai.Content
+	for _, message := range llm.ConversationMessages(options, prompt) {
+		switch message.Role {
+		case llm.RoleUser:
+			contents = append(contents, &genai.Content{
+				Role:  genai.RoleUser,
+				Parts: []*genai.Part{{Text: message.Content}},
+			})
+		case llm.RoleAssistant:
+			content := &genai.Content{Role: genai.RoleModel}
+			if message.Content != "" {
+				content.Parts = append(content.Parts, &genai.Part{Text: message.Content})
+			}
+			for _, call := range message.ToolCalls {
+				args := map[string]any{}
+				if len(call.Input) > 0 {
+					if err := json.Unmarshal(call.Input, &args); err != nil {
+						return nil, fmt.Errorf("invalid arguments for tool call '%s': %w", call.Name, err)
+					}
+				}
+				content.Parts = append(content.Parts, &genai.Part{
+					FunctionCall: &genai.FunctionCall{ID: call.ID, Name: call.Name, Args: args},
+				})
+			}
+			contents = append(contents, content)
+		case llm.RoleTool:
+			part := &genai.Part{FunctionResponse: &genai.FunctionResponse{
+				ID:       message.ToolCallID,
+				Name:     message.Name,
+				Response: functionResponse(message),
+			}}
+			if last := len(contents) - 1; last >= 0 && isFunctionResponseTurn(contents[last]) {
+				contents[last].Parts = append(contents[last].Parts, part)
+				continue
+			}
+			contents = append(contents, &genai.Content{Role: genai.RoleUser, Parts: []*genai.Part{part}})
+		default:
+			return nil, fmt.Errorf("unsupported message role '%s'", message.Role)
+		}
+	}
+	return contents, nil
+}
+
+// functionResponse wraps a tool result in the output/error envelope Gemini documents.
+// JSON results are passed as structured values, anything else as a string.
+func functionResponse(message llm.Message) map[string]any {
+	key := "output"
+	if message.IsError {
+		key = "error"
+	}
+	var value any = message.Content
+	var decoded any
+	if err := json.Unmarshal([]byte(message.Content), &decoded); err == nil {
+		value = decoded
+	}
+	return map[string]any{key: value}
+}
+
+func isFunctionResponseTurn(content *genai.Content) bool {
+	if content.Role != genai.RoleUser || len(content.Parts) == 0 {
+		return false
+	}
+	for _, part := range content.Parts {
+		if part.FunctionResponse == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// extractToolCalls collects FunctionCall parts as llm tool calls. Gemini does not always
+// assign call IDs, so missing ones are derived from the function name and position.
+func extractToolCalls(parts []*genai.Part, offset int) ([]llm.ToolCall, error) {
+	var calls []llm.ToolCall
+	for _, part := range parts {
+		if part == nil || part.FunctionCall == nil {
+			continue
+		}
+		input, err := json.Marshal(part.FunctionCall.Args)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode arguments for function call '%s': %w", part.FunctionCall.Name, err)
+		}
+		if part.FunctionCall.Args == nil {
+			input = json.RawMessage("{}")
+		}
+		id := part.FunctionCall.ID
+		if id == "" {
+			id = fmt.Sprintf("%s_%d", part.FunctionCall.Name, offset+len(calls))
+		}
+		calls = append(calls, llm.ToolCall{ID: id, Name: part.FunctionCall.Name, Input: input})
+	}
+	return calls, nil
+}
+
+// normalizeSchemaTypes upper-cases JSON Schema type names to Gemini's OpenAPI enum values.
+func normalizeSchemaTypes(schema *genai.Schema) {
+	if schema == nil {
+		return
+	}
+	schema.Type = genai.Type(strings.ToUpper(string(schema.Type)))
+	normalizeSchemaTypes(schema.Items)
+	for _, property := range schema.Properties {
+		normalizeSchemaTypes(property)
+	}
+	for _, option := range schema.AnyOf {
+		normalizeSchemaTypes(option)
+	}
+}
@@ -0,0 +1,118 @@
+package gemini
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/ulgerang/llm-module/llm"
+
+	"google.golang.org/genai"
+)
+
+func TestBuildToolsConvertsSchema(t *testing.T) {
+	type weatherArgs struct {
+		City string `json:"city" description:"City name"`
+	}
+	tool := llm.MustNewFuncTool("get_weather", "Look up the weather", func(ctx context.Context, args weatherArgs) (string, error) {
+		return "sunny", nil
+	})
+	empty := &llm.Tool{Name: "now", InputSchema: &llm.SchemaProperty{Type: "object"}}
+
+	tools, err := buildTools([]*llm.Tool{tool, empty})
+	if err != nil {
+		t.Fatalf("buildTools: %v", err)
+	}
+	if len(tools) != 1 || len(tools[0].FunctionDeclarations) != 2 {
+		t.Fatalf("unexpected tools: %+v", tools)
+	}
+
+	weather := tools[0].FunctionDeclarations[0]
+	if weather.Parameters == nil || weather.Parameters.Type != genai.TypeObject {
+		t.Fatalf("expected OBJECT parameters, got %+v", weather.Parameters)
+	}
+	if city := weather.Parameters.Properties["city"]; city == nil || city.Type != genai.TypeString {
+		t.Fatalf("expected STRING city property, got %+v", city)
+	}
+	if tools[0].FunctionDeclarations[1].Parameters != nil {
+		t.Fatal("expected parameterless function to omit parameters")
+	}
+}
+
+func TestBuildContentsGroupsFunctionResponses(t *testing.T) {
+	options := &llm.GenerationOptions{Messages: []llm.Message{
+		llm.UserMessage("weather in Seoul and Busan?"),
+		llm.AssistantToolCallMessage([]llm.ToolCall{
+			{ID: "a", Name: "get_weather", Input: json.RawMessage(`{"city":"Seoul"}`)},
+			{ID: "b", Name: "get_weather", Input: json.RawMessage(`{"city":"Busan"}`)},
+		}),
+		llm.ToolResultMessage(llm.ToolResult{ToolCallID: "a", Name: "get_weather", Content: `{"temp":21}`}),
+		llm.ToolResultMessage(llm.ToolResult{ToolCallID: "b", Name: "get_weather", Content: "offline", IsError: true}),
+	}}
+
+	contents, err := buildContents(options, "")
+	if err != nil {
+		t.Fatalf("buildContents: %v", err)
+	}
+	if len(contents) != 3 {
+		t.Fatalf("expected 3 contents, got %d", len(contents))
+	}
+
+	model := contents[1]
+	if model.Role != genai.RoleModel || len(model.Parts) != 2 || model.Parts[0].FunctionCall.Args["city"] != "Seoul" {
+		t.Fatalf("unexpected model turn: %+v", model)
+	}
+
+	responses := contents[2]
+	if responses.Role != genai.RoleUser || len(responses.Parts) != 2 {
+		t.Fatalf("expected grouped function responses, got %+v", responses)
+	}
+	output, ok := responses.Parts[0].FunctionResponse.Response["output"].(map[string]any)
+	if !ok || output["temp"] != float64(21) {
+		t.Fatalf("expected structured output, got %+v", responses.Parts[0].FunctionResponse.Response)
+	}
+	if responses.Parts[1].FunctionResponse.Response["error"] != "offline" {
+		t.Fatalf("expected error response, got %+v", responses.Parts[1].FunctionResponse.Response)
+	}
+}
+
+func TestExtractToolCalls(t *testing.T) {
+	parts := []*genai.Part{
+		{Text: "checking"},
+		{FunctionCall: &genai.FunctionCall{Name: "get_weather", Args: map[string]any{"city": "Seoul"}}},
+		{FunctionCall: &genai.FunctionCall{ID: "call-2", Name: "now"}},
+	}
+
+	calls, err := extractToolCalls(parts, 3)
+	if err != nil {
+		t.Fatalf("extractToolCalls: %v", err)
+	}
+	if len(calls) != 2 {
+		t.Fatalf("expected 2 calls, got %d", len(calls))
+	}
+	if calls[0].ID != "get_weather_3" || string(calls[0].Input) != `{"city":"Seoul"}` {
+		t.Fatalf("unexpected first call: %+v", calls[0])
+	}
+	if calls[1].ID != "call-2" || string(calls[1].Input) != "{}" {
+		t.Fatalf("unexpected second call: %+v", calls[1])
+	}
+}
+
+func TestResponseSchemaWithToolsIsUnsupported(t *testing.T) {
+	provider := newStubProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("no request should be sent")
+	})
+	tool := llm.MustNewFuncTool("lookup", "Look up a value", func(ctx context.Context, args struct{}) (string, error) { return "", nil })
+	schema := &llm.SchemaProperty{Type: "object", Properties: map[string]*llm.SchemaProperty{"answer": {Type: "string"}}}
+
+	_, _, err := provider.GenerateText(context.Background(), "hi", llm.WithTools([]*llm.Tool{tool}), llm.WithResponseSchema(schema), llm.WithStrict())
+	if !errors.Is(err, llm.ErrUnsupportedOption) {
+		t.Errorf("GenerateText() error = %v, want ErrUnsupportedOption", err)
+	}
+	_, err = provider.GenerateTextStream(context.Background(), "hi", make(chan llm.StreamChunk, 1), llm.WithTools([]*llm.Tool{tool}), llm.WithResponseSchema(schema), llm.WithStrict())
+	if !errors.Is(err, llm.ErrUnsupportedOption) {
+		t.Errorf("GenerateTextStream() error = %v, want ErrUnsupportedOption", err)
+	}
+}
@@ -11,8 +11,10 @@ import (
 	sdk "github.com/openai/openai-go"
 	"github.com/openai/openai-go/option"
 
+	"github.com/ulgerang/llm-module/internal/openaicompat"
 	"github.com/ulgerang/llm-module/llm"
 	"github.com/ulgerang/llm-module/logger"
+	"github.com/ulgerang/llm-module/redact"
 	"github.com/ulgerang/llm-module/utils"
 )
 
@@ -26,16 +28,35 @@ type Provider struct {
 	client    sdk.Client
 	logger    logger.Logger
 	modelName string
+	defaults  []llm.GenerationOption
 }
 
+// sampling lists the sampling parameters the xAI API accepts.
+var sampling = openaicompat.Sampling{Seed: true, Penalties: true}
+
+// capabilities lists the features the xAI provider supports.
+var capabilities = llm.Capabilities{Streaming: true, StructuredOutput: llm.StructuredOutputPrompted}
+
 // New creates a new Grok provider.
-func New(log logger.Logger, apiKey, modelName string) (*Provider, error) {
+func New(log logger.Logger, apiKey, modelName string, opts ...llm.ProviderOption) (*Provider, error) {
+	return newProvider(log, apiKey, modelName, "", opts...)
+}
+
+// NewWithBaseURL creates a new Grok provider with a custom base URL.
+func NewWithBaseURL(log logger.Logger, apiKey, modelName, baseURL string, opts ...llm.ProviderOption) (*Provider, error) {
+	return newProvider(log, apiKey, modelName, baseURL, opts...)
+}
+
+func newProvider(log logger.Logger, apiKey, modelName, baseURL string, opts ...llm.ProviderOption) (*Provider, error) {
+	config := llm.ResolveProviderOptions(opts...)
+	apiKey, err := config.ResolveAPIKey(apiKey, "GROK_API_KEY")
+	if err != nil {
+		return nil, err
+	}
 	if apiKey == "" {
-		apiKey = os.Getenv("GROK_API_KEY")
-		if apiKey == "" {
-			return nil, errors.New("GROK_API_KEY not provided")
-		}
+		return nil, errors.New("GROK_API_KEY not provided")
 	}
+	redact.AddSecret(apiKey)
 
 	if modelName == "" {
 		modelName = os.Getenv("GROK_MODEL")
@@ -44,12 +65,32 @@ func New(log logger.Logger, apiKey, modelName string) (*Provider, error) {
 		}
 	}
 
-	client := sdk.NewClient(
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+
+	clientOpts := []option.RequestOption{
 		option.WithAPIKey(apiKey),
-		option.WithBaseURL(defaultBaseURL),
-	)
+		option.WithBaseURL(baseURL),
+	}
+	httpOpts, err := openaicompat.ClientOptions(config, nil)
+	if err != nil {
+		return nil, err
+	}
+	clientOpts = append(clientOpts, httpOpts...)
+	client := sdk.NewClient(clientOpts...)
+
+	return &Provider{client: client, logger: logger.With(log, logger.Provider("grok"), logger.Model(modelName)), modelName: modelName, defaults: config.RequestDefaults()}, nil
+}
 
-	return &Provider{client: client, logger: log, modelName: modelName}, nil
+// ListModels lists the models the API serves.
+func (p *Provider) ListModels(ctx context.Context) ([]llm.Model, error) {
+	return openaicompat.ListModels(ctx, p.client, "grok")
+}
+
+// Capabilities reports the features of the provider and its model.
+func (p *Provider) Capabilities() llm.Capabilities {
+	return llm.ModelCapabilities(capabilities, p.modelName)
 }
 
 // GetModelName returns the configured model name.
@@ -64,17 +105,19 @@ func (p *Provider) GenerateText(ctx context.Context, prompt string, opts ...llm.
 		MaxTokens:   llm.ValuePtr(int32(4096)),
 		System:      "You are a helpful assistant.",
 	}
+	for _, opt := range p.defaults {
+		opt(options)
+	}
 	for _, opt := range opts {
 		opt(options)
 	}
 
 	systemPrompt := buildSystemPrompt(options)
 
-	messages := []sdk.ChatCompletionMessageParamUnion{}
-	if systemPrompt != "" {
-		messages = append(messages, sdk.SystemMessage(systemPrompt))
+	messages, err := openaicompat.Messages(systemPrompt, options, prompt)
+	if err != nil {
+		return "", nil, err
 	}
-	messages = append(messages, sdk.UserMessage(prompt))
 	if options.Language != "" {
 		messages = append(messages, sdk.UserMessage(languageReminder(options.Language)))
 	}
@@ -91,8 +134,17 @@ func (p *Provider) GenerateText(ctx context.Context, prompt string, opts ...llm.
 		req.TopP = sdk.Float(float64(*options.TopP))
 	}
 
-	resp, err := p.client.Chat.Completions.New(ctx, req)
+	if err := checkTools(options, p.logger.Warning); err != nil {
+		return "", nil, err
+	}
+
+	if err := openaicompat.ApplySampling(&req, options, "grok", sampling, p.logger.Warning); err != nil {
+		return "", nil, err
+	}
+
+	resp, err := p.client.Chat.Completions.New(ctx, req, openaicompat.RequestOptions(options)...)
 	if err != nil {
+		err = openaicompat.Error("grok", err)
 		p.logger.Error("[Grok] Failed to generate content", err)
 		return "", nil, err
 	}
@@ -116,7 +168,7 @@ func (p *Provider) GenerateText(ctx context.Context, prompt string, opts ...llm.
 		OutputTokens: int(resp.Usage.CompletionTokens),
 	}
 
-	p.logger.Info(fmt.Sprintf("Generated text (Grok): %s", generated))
+	logger.LogContent(p.logger, "[Grok] Generated text", generated)
 	return generated, usage, nil
 }
 
@@ -129,17 +181,20 @@ func (p *Provider) GenerateTextStream(ctx context.Context, prompt string, outCha
 		MaxTokens:   llm.ValuePtr(int32(4096)),
 		System:      "You are a helpful assistant.",
 	}
+	for _, opt := range p.defaults {
+		opt(options)
+	}
 	for _, opt := range opts {
 		opt(options)
 	}
 
 	systemPrompt := buildSystemPrompt(options)
 
-	messages := []sdk.ChatCompletionMessageParamUnion{}
-	if systemPrompt != "" {
-		messages = append(messages, sdk.SystemMessage(systemPrompt))
+	messages, err := openaicompat.Messages(systemPrompt, options, prompt)
+	if err != nil {
+		outChan <- llm.StreamChunk{Err: err}
+		return nil, err
 	}
-	messages = append(messages, sdk.UserMessage(prompt))
 
 	req := sdk.ChatCompletionNewParams{Model: p.modelName, Messages: messages}
 
@@ -153,7 +208,17 @@ func (p *Provider) GenerateTextStream(ctx context.Context, prompt string, outCha
 		req.TopP = sdk.Float(float64(*options.TopP))
 	}
 
-	stream := p.client.Chat.Completions.NewStreaming(ctx, req)
+	if err := checkTools(options, p.logger.Warning); err != nil {
+		outChan <- llm.StreamChunk{Err: err}
+		return nil, err
+	}
+
+	if err := openaicompat.ApplySampling(&req, options, "grok", sampling.Stream(), p.logger.Warning); err != nil {
+		outChan <- llm.StreamChunk{Err: err}
+		return nil, err
+	}
+
+	stream := p.client.Chat.Completions.NewStreaming(ctx, req, openaicompat.RequestOptions(options)...)
 	defer stream.Close()
 
 	var lastChunk sdk.ChatCompletionChunk
@@ -171,6 +236,7 @@ func (p *Provider) GenerateTextStream(ctx context.Context, prompt string, outCha
 	}
 
 	if err := stream.Err(); err != nil {
+		err = openaicompat.Error("grok", err)
 		p.logger.Error("[Grok] Stream error", err)
 		outChan <- llm.StreamChunk{Err: err}
 		return nil, err
@@ -179,6 +245,18 @@ func (p *Provider) GenerateTextStream(ctx context.Context, prompt string, outCha
 	return parseUsageFromChunk(lastChunk, p.logger), nil
 }
 
+// checkTools reports tools as unsupported, since this provider does not send them. A
+// forced tool choice is always an error.
+func checkTools(options *llm.GenerationOptions, warn func(string)) error {
+	if options.ToolChoice.ForcesToolUse() {
+		return llm.NewUnsupportedOptionError("grok", "ToolChoice", "tool calling is not implemented for Grok")
+	}
+	if len(options.Tools) > 0 {
+		return options.Unsupported("grok", "Tools", "tool calling is not implemented for Grok", warn)
+	}
+	return nil
+}
+
 // Close releases resources.
 func (p *Provider) Close() error {
 	p.logger.Info("[Grok] Provider closed.")
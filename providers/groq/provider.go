@@ -11,8 +11,10 @@ import (
 	sdk "github.com/openai/openai-go"
 	"github.com/openai/openai-go/option"
 
+	"github.com/ulgerang/llm-module/internal/openaicompat"
 	"github.com/ulgerang/llm-module/llm"
 	"github.com/ulgerang/llm-module/logger"
+	"github.com/ulgerang/llm-module/redact"
 	"github.com/ulgerang/llm-module/utils"
 )
 
@@ -26,16 +28,35 @@ type Provider struct {
 	client    sdk.Client
 	logger    logger.Logger
 	modelName string
+	defaults  []llm.GenerationOption
 }
 
+// sampling lists the sampling parameters the Groq API accepts.
+var sampling = openaicompat.Sampling{Seed: true, Logprobs: true, Candidates: true}
+
+// capabilities lists the features the Groq provider supports.
+var capabilities = llm.Capabilities{Streaming: true, Tools: true, ParallelTools: true, StructuredOutput: llm.StructuredOutputPrompted, Logprobs: true, Candidates: true}
+
 // New creates a new Groq provider.
-func New(log logger.Logger, apiKey, modelName string) (*Provider, error) {
+func New(log logger.Logger, apiKey, modelName string, opts ...llm.ProviderOption) (*Provider, error) {
+	return newProvider(log, apiKey, modelName, "", opts...)
+}
+
+// NewWithBaseURL creates a new Groq provider with a custom base URL.
+func NewWithBaseURL(log logger.Logger, apiKey, modelName, baseURL string, opts ...llm.ProviderOption) (*Provider, error) {
+	return newProvider(log, apiKey, modelName, baseURL, opts...)
+}
+
+func newProvider(log logger.Logger, apiKey, modelName, baseURL string, opts ...llm.ProviderOption) (*Provider, error) {
+	config := llm.ResolveProviderOptions(opts...)
+	apiKey, err := config.ResolveAPIKey(apiKey, "GROQ_API_KEY")
+	if err != nil {
+		return nil, err
+	}
 	if apiKey == "" {
-		apiKey = os.Getenv("GROQ_API_KEY")
-		if apiKey == "" {
-			return nil, errors.New("GROQ_API_KEY not provided")
-		}
+		return nil, errors.New("GROQ_API_KEY not provided")
 	}
+	redact.AddSecret(apiKey)
 
 	if modelName == "" {
 		modelName = os.Getenv("GROQ_MODEL")
@@ -44,12 +65,32 @@ func New(log logger.Logger, apiKey, modelName string) (*Provider, error) {
 		}
 	}
 
-	client := sdk.NewClient(
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+
+	clientOpts := []option.RequestOption{
 		option.WithAPIKey(apiKey),
-		option.WithBaseURL(defaultBaseURL),
-	)
+		option.WithBaseURL(baseURL),
+	}
+	httpOpts, err := openaicompat.ClientOptions(config, nil)
+	if err != nil {
+		return nil, err
+	}
+	clientOpts = append(clientOpts, httpOpts...)
+	client := sdk.NewClient(clientOpts...)
+
+	return &Provider{client: client, logger: logger.With(log, logger.Provider("groq"), logger.Model(modelName)), modelName: modelName, defaults: config.RequestDefaults()}, nil
+}
+
+// ListModels lists the models the API serves.
+func (p *Provider) ListModels(ctx context.Context) ([]llm.Model, error) {
+	return openaicompat.ListModels(ctx, p.client, "groq")
+}
 
-	return &Provider{client: client, logger: log, modelName: modelName}, nil
+// Capabilities reports the features of the provider and its model.
+func (p *Provider) Capabilities() llm.Capabilities {
+	return llm.ModelCapabilities(capabilities, p.modelName)
 }
 
 // GetModelName returns the active Groq model name.
@@ -64,17 +105,19 @@ func (p *Provider) GenerateText(ctx context.Context, prompt string, opts ...llm.
 		MaxTokens:   llm.ValuePtr(int32(4096)),
 		System:      "You are a helpful assistant.",
 	}
+	for _, opt := range p.defaults {
+		opt(options)
+	}
 	for _, opt := range opts {
 		opt(options)
 	}
 
 	systemPrompt := buildSystemPrompt(options)
 
-	messages := []sdk.ChatCompletionMessageParamUnion{}
-	if systemPrompt != "" {
-		messages = append(messages, sdk.SystemMessage(systemPrompt))
+	messages, err := openaicompat.Messages(systemPrompt, options, prompt)
+	if err != nil {
+		return "", nil, err
 	}
-	messages = append(messages, sdk.UserMessage(prompt))
 	if options.Language != "" {
 		messages = append(messages, sdk.UserMessage(languageReminder(options.Language)))
 	}
@@ -91,12 +134,40 @@ func (p *Provider) GenerateText(ctx context.Context, prompt string, opts ...llm.
 		req.TopP = sdk.Float(float64(*options.TopP))
 	}
 
-	resp, err := p.client.Chat.Completions.New(ctx, req)
+	if err := llm.ValidateToolChoice(options); err != nil {
+		return "", nil, err
+	}
+
+	if len(options.Tools) > 0 {
+		if err := openaicompat.ApplyTools(&req, options); err != nil {
+			p.logger.Error("[Groq] Failed to apply tools", err)
+			return "", nil, err
+		}
+	}
+
+	if err := openaicompat.ApplySampling(&req, options, "groq", sampling, p.logger.Warning); err != nil {
+		return "", nil, err
+	}
+
+	resp, err := p.client.Chat.Completions.New(ctx, req, openaicompat.RequestOptions(options)...)
 	if err != nil {
+		err = openaicompat.Error("groq", err)
 		p.logger.Error("[Groq] Failed to generate content", err)
 		return "", nil, err
 	}
 
+	if len(resp.Choices) > 0 && len(resp.Choices[0].Message.ToolCalls) > 0 {
+		p.logger.Infof("[Groq] Received %d tool call(s)", len(resp.Choices[0].Message.ToolCalls))
+		toolCalls, err := llm.FormatToolCalls(openaicompat.ToolCalls(resp.Choices[0].Message.ToolCalls))
+		if err != nil {
+			return "", nil, err
+		}
+		return toolCalls, &llm.UsageInfo{
+			InputTokens:  int(resp.Usage.PromptTokens),
+			OutputTokens: int(resp.Usage.CompletionTokens),
+		}, nil
+	}
+
 	if len(resp.Choices) == 0 || resp.Choices[0].Message.Content == "" {
 		p.logger.Warning("[Groq] No content generated")
 		return "", nil, errors.New("no content generated")
@@ -114,9 +185,10 @@ func (p *Provider) GenerateText(ctx context.Context, prompt string, opts ...llm.
 	usage := &llm.UsageInfo{
 		InputTokens:  int(resp.Usage.PromptTokens),
 		OutputTokens: int(resp.Usage.CompletionTokens),
+		Completion:   openaicompat.Completion(options, resp.Choices),
 	}
 
-	p.logger.Info(fmt.Sprintf("Generated text (Groq): %s", generated))
+	logger.LogContent(p.logger, "[Groq] Generated text", generated)
 	return generated, usage, nil
 }
 
@@ -129,17 +201,20 @@ func (p *Provider) GenerateTextStream(ctx context.Context, prompt string, outCha
 		MaxTokens:   llm.ValuePtr(int32(4096)),
 		System:      "You are a helpful assistant.",
 	}
+	for _, opt := range p.defaults {
+		opt(options)
+	}
 	for _, opt := range opts {
 		opt(options)
 	}
 
 	systemPrompt := buildSystemPrompt(options)
 
-	messages := []sdk.ChatCompletionMessageParamUnion{}
-	if systemPrompt != "" {
-		messages = append(messages, sdk.SystemMessage(systemPrompt))
+	messages, err := openaicompat.Messages(systemPrompt, options, prompt)
+	if err != nil {
+		outChan <- llm.StreamChunk{Err: err}
+		return nil, err
 	}
-	messages = append(messages, sdk.UserMessage(prompt))
 
 	req := sdk.ChatCompletionNewParams{Model: p.modelName, Messages: messages}
 
@@ -153,7 +228,24 @@ func (p *Provider) GenerateTextStream(ctx context.Context, prompt string, outCha
 		req.TopP = sdk.Float(float64(*options.TopP))
 	}
 
-	stream := p.client.Chat.Completions.NewStreaming(ctx, req)
+	if options.ToolChoice.ForcesToolUse() {
+		err := llm.NewUnsupportedOptionError("groq", "ToolChoice", "tool calling is not available for streaming")
+		outChan <- llm.StreamChunk{Err: err}
+		return nil, err
+	}
+	if len(options.Tools) > 0 {
+		if err := options.Unsupported("groq", "Tools", "tool calling is not available for streaming", p.logger.Warning); err != nil {
+			outChan <- llm.StreamChunk{Err: err}
+			return nil, err
+		}
+	}
+
+	if err := openaicompat.ApplySampling(&req, options, "groq", sampling.Stream(), p.logger.Warning); err != nil {
+		outChan <- llm.StreamChunk{Err: err}
+		return nil, err
+	}
+
+	stream := p.client.Chat.Completions.NewStreaming(ctx, req, openaicompat.RequestOptions(options)...)
 	defer stream.Close()
 
 	var lastChunk sdk.ChatCompletionChunk
@@ -171,6 +263,7 @@ func (p *Provider) GenerateTextStream(ctx context.Context, prompt string, outCha
 	}
 
 	if err := stream.Err(); err != nil {
+		err = openaicompat.Error("groq", err)
 		p.logger.Error("[Groq] Stream error", err)
 		outChan <- llm.StreamChunk{Err: err}
 		return nil, err
@@ -11,8 +11,10 @@ import (
 	sdk "github.com/openai/openai-go"
 	"github.com/openai/openai-go/option"
 
+	"github.com/ulgerang/llm-module/internal/openaicompat"
 	"github.com/ulgerang/llm-module/llm"
 	"github.com/ulgerang/llm-module/logger"
+	"github.com/ulgerang/llm-module/redact"
 	"github.com/ulgerang/llm-module/utils"
 )
 
@@ -26,20 +28,30 @@ type Provider struct {
 	client    sdk.Client
 	logger    logger.Logger
 	modelName string
+	defaults  []llm.GenerationOption
 }
 
+// sampling lists the sampling parameters the Inception API accepts.
+var sampling = openaicompat.Sampling{}
+
+// capabilities lists the features the Inception provider supports.
+var capabilities = llm.Capabilities{Streaming: true, Tools: true, StreamingTools: true, ParallelTools: true, StructuredOutput: llm.StructuredOutputPrompted}
+
 // NewWithBaseURL creates a new Inception provider with a custom base URL.
-func NewWithBaseURL(log logger.Logger, apiKey, modelName, baseURL string) (*Provider, error) {
-	return newProvider(log, apiKey, modelName, baseURL)
+func NewWithBaseURL(log logger.Logger, apiKey, modelName, baseURL string, opts ...llm.ProviderOption) (*Provider, error) {
+	return newProvider(log, apiKey, modelName, baseURL, opts...)
 }
 
-func newProvider(log logger.Logger, apiKey, modelName, baseURL string) (*Provider, error) {
+func newProvider(log logger.Logger, apiKey, modelName, baseURL string, opts ...llm.ProviderOption) (*Provider, error) {
+	config := llm.ResolveProviderOptions(opts...)
+	apiKey, err := config.ResolveAPIKey(apiKey, "INCEPTION_API_KEY")
+	if err != nil {
+		return nil, err
+	}
 	if apiKey == "" {
-		apiKey = os.Getenv("INCEPTION_API_KEY")
-		if apiKey == "" {
-			return nil, errors.New("INCEPTION_API_KEY not provided")
-		}
+		return nil, errors.New("INCEPTION_API_KEY not provided")
 	}
+	redact.AddSecret(apiKey)
 
 	if modelName == "" {
 		modelName = os.Getenv("INCEPTION_MODEL")
@@ -55,21 +67,36 @@ func newProvider(log logger.Logger, apiKey, modelName, baseURL string) (*Provide
 		}
 	}
 
-	opts := []option.RequestOption{
+	clientOpts := []option.RequestOption{
 		option.WithAPIKey(apiKey),
 	}
 	if baseURL != "" {
-		opts = append(opts, option.WithBaseURL(baseURL))
+		clientOpts = append(clientOpts, option.WithBaseURL(baseURL))
 	}
+	httpOpts, err := openaicompat.ClientOptions(config, nil)
+	if err != nil {
+		return nil, err
+	}
+	clientOpts = append(clientOpts, httpOpts...)
 
-	client := sdk.NewClient(opts...)
+	client := sdk.NewClient(clientOpts...)
 
-	return &Provider{client: client, logger: log, modelName: modelName}, nil
+	return &Provider{client: client, logger: logger.With(log, logger.Provider("inception"), logger.Model(modelName)), modelName: modelName, defaults: config.RequestDefaults()}, nil
 }
 
 // New creates a new Inception provider.
-func New(log logger.Logger, apiKey, modelName string) (*Provider, error) {
-	return newProvider(log, apiKey, modelName, "")
+func New(log logger.Logger, apiKey, modelName string, opts ...llm.ProviderOption) (*Provider, error) {
+	return newProvider(log, apiKey, modelName, "", opts...)
+}
+
+// ListModels lists the models the API serves.
+func (p *Provider) ListModels(ctx context.Context) ([]llm.Model, error) {
+	return openaicompat.ListModels(ctx, p.client, "inception")
+}
+
+// Capabilities reports the features of the provider and its model.
+func (p *Provider) Capabilities() llm.Capabilities {
+	return llm.ModelCapabilities(capabilities, p.modelName)
 }
 
 // GetModelName returns the active Inception model name.
@@ -83,6 +110,9 @@ func (p *Provider) GenerateText(ctx context.Context, prompt string, opts ...llm.
 		Temperature: llm.ValuePtr(float32(0.7)),
 		MaxTokens:   llm.ValuePtr(int32(4096)),
 	}
+	for _, opt := range p.defaults {
+		opt(options)
+	}
 	for _, opt := range opts {
 		opt(options)
 	}
@@ -91,11 +121,10 @@ func (p *Provider) GenerateText(ctx context.Context, prompt string, opts ...llm.
 
 	systemPrompt := buildSystemPrompt(options)
 
-	messages := []sdk.ChatCompletionMessageParamUnion{}
-	if systemPrompt != "" {
-		messages = append(messages, sdk.SystemMessage(systemPrompt))
+	messages, err := openaicompat.Messages(systemPrompt, options, prompt)
+	if err != nil {
+		return "", nil, err
 	}
-	messages = append(messages, sdk.UserMessage(prompt))
 	if options.Language != "" {
 		messages = append(messages, sdk.UserMessage(languageReminder(options.Language)))
 	}
@@ -112,12 +141,36 @@ func (p *Provider) GenerateText(ctx context.Context, prompt string, opts ...llm.
 		req.TopP = sdk.Float(float64(*options.TopP))
 	}
 
-	resp, err := p.client.Chat.Completions.New(ctx, req)
+	if len(options.Tools) > 0 {
+		if err := openaicompat.ApplyTools(&req, options); err != nil {
+			p.logger.Error("[Inception] Failed to apply tools", err)
+			return "", nil, err
+		}
+	}
+
+	if err := openaicompat.ApplySampling(&req, options, "inception", sampling, p.logger.Warning); err != nil {
+		return "", nil, err
+	}
+
+	resp, err := p.client.Chat.Completions.New(ctx, req, openaicompat.RequestOptions(options)...)
 	if err != nil {
+		err = openaicompat.Error("inception", err)
 		p.logger.Error("[Inception] Failed to generate content", err)
 		return "", nil, err
 	}
 
+	if len(resp.Choices) > 0 && len(resp.Choices[0].Message.ToolCalls) > 0 {
+		p.logger.Infof("[Inception] Received %d tool call(s)", len(resp.Choices[0].Message.ToolCalls))
+		toolCalls, err := llm.FormatToolCalls(openaicompat.ToolCalls(resp.Choices[0].Message.ToolCalls))
+		if err != nil {
+			return "", nil, err
+		}
+		return toolCalls, &llm.UsageInfo{
+			InputTokens:  int(resp.Usage.PromptTokens),
+			OutputTokens: int(resp.Usage.CompletionTokens),
+		}, nil
+	}
+
 	if len(resp.Choices) == 0 || resp.Choices[0].Message.Content == "" {
 		p.logger.Warning("[Inception] No content generated")
 		return "", nil, errors.New("no content generated")
@@ -137,7 +190,7 @@ func (p *Provider) GenerateText(ctx context.Context, prompt string, opts ...llm.
 		OutputTokens: int(resp.Usage.CompletionTokens),
 	}
 
-	p.logger.Info(fmt.Sprintf("Generated text (Inception/%s): %s", p.modelName, generated))
+	logger.LogContent(p.logger, "[Inception] Generated text", generated)
 	return generated, usage, nil
 }
 
@@ -149,17 +202,20 @@ func (p *Provider) GenerateTextStream(ctx context.Context, prompt string, outCha
 		Temperature: llm.ValuePtr(float32(0.7)),
 		MaxTokens:   llm.ValuePtr(int32(4096)),
 	}
+	for _, opt := range p.defaults {
+		opt(options)
+	}
 	for _, opt := range opts {
 		opt(options)
 	}
 
 	systemPrompt := buildSystemPrompt(options)
 
-	messages := []sdk.ChatCompletionMessageParamUnion{}
-	if systemPrompt != "" {
-		messages = append(messages, sdk.SystemMessage(systemPrompt))
+	messages, err := openaicompat.Messages(systemPrompt, options, prompt)
+	if err != nil {
+		outChan <- llm.StreamChunk{Err: err}
+		return nil, err
 	}
-	messages = append(messages, sdk.UserMessage(prompt))
 
 	req := sdk.ChatCompletionNewParams{Model: p.modelName, Messages: messages}
 
@@ -173,9 +229,23 @@ func (p *Provider) GenerateTextStream(ctx context.Context, prompt string, outCha
 		req.TopP = sdk.Float(float64(*options.TopP))
 	}
 
-	stream := p.client.Chat.Completions.NewStreaming(ctx, req)
+	if len(options.Tools) > 0 {
+		if err := openaicompat.ApplyTools(&req, options); err != nil {
+			p.logger.Error("[Inception] Failed to apply tools", err)
+			outChan <- llm.StreamChunk{Err: err}
+			return nil, err
+		}
+	}
+
+	if err := openaicompat.ApplySampling(&req, options, "inception", sampling.Stream(), p.logger.Warning); err != nil {
+		outChan <- llm.StreamChunk{Err: err}
+		return nil, err
+	}
+
+	stream := p.client.Chat.Completions.NewStreaming(ctx, req, openaicompat.RequestOptions(options)...)
 	defer stream.Close()
 
+	var toolCalls openaicompat.ToolCallAccumulator
 	var lastChunk sdk.ChatCompletionChunk
 
 	for stream.Next() {
@@ -183,6 +253,7 @@ func (p *Provider) GenerateTextStream(ctx context.Context, prompt string, outCha
 		lastChunk = chunk
 
 		if len(chunk.Choices) > 0 {
+			toolCalls.Add(chunk.Choices[0].Delta.ToolCalls)
 			delta := chunk.Choices[0].Delta.Content
 			if delta != "" {
 				outChan <- llm.StreamChunk{Delta: delta}
@@ -191,11 +262,17 @@ func (p *Provider) GenerateTextStream(ctx context.Context, prompt string, outCha
 	}
 
 	if err := stream.Err(); err != nil {
+		err = openaicompat.Error("inception", err)
 		p.logger.Error("[Inception] Stream error", err)
 		outChan <- llm.StreamChunk{Err: err}
 		return nil, err
 	}
 
+	if calls := toolCalls.ToolCalls(); len(calls) > 0 {
+		p.logger.Infof("[Inception] Received %d tool call(s)", len(calls))
+		outChan <- llm.StreamChunk{ToolCalls: calls}
+	}
+
 	return parseUsageFromChunk(lastChunk, p.logger), nil
 }
 
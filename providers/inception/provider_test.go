@@ -0,0 +1,35 @@
+package inception_test
+
+import (
+	"testing"
+
+	"github.com/ulgerang/llm-module/llm"
+	"github.com/ulgerang/llm-module/logger"
+	"github.com/ulgerang/llm-module/providers/inception"
+	"github.com/ulgerang/llm-module/testutil"
+)
+
+func newProvider(baseURL string) (llm.Provider, error) {
+	return inception.NewWithBaseURL(logger.Nop(), "test-key", "test-model", baseURL)
+}
+
+func TestToolCalls(t *testing.T) {
+	testutil.RunToolCallTests(t, newProvider)
+}
+
+func TestDefaultBaseURL(t *testing.T) {
+	t.Setenv("INCEPTION_BASE_URL", "")
+	url := testutil.RequestURL(t, func(opts ...llm.ProviderOption) (llm.Provider, error) {
+		return inception.New(logger.Nop(), "test-key", "test-model", opts...)
+	})
+	if url != "https://api.inceptionlabs.ai/v1/chat/completions" {
+		t.Errorf("request sent to %s", url)
+	}
+}
+
+func TestSampling(t *testing.T) {
+	testutil.RunOptionTests(t, newProvider, []testutil.OptionCase{
+		{Name: "stop", Options: []llm.GenerationOption{llm.WithStopSequences("END")}, Want: `"stop":["END"]`},
+		{Name: "seed", Options: []llm.GenerationOption{llm.WithSeed(7)}, Unsupported: true},
+	})
+}
@@ -0,0 +1,62 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	sdk "github.com/openai/openai-go"
+
+	"github.com/ulgerang/llm-module/llm"
+)
+
+const defaultEmbeddingModel = sdk.EmbeddingModelTextEmbedding3Small
+
+// Embedder creates embeddings with the provider's client.
+type Embedder struct {
+	provider *Provider
+	model    string
+}
+
+// Embedder returns an llm.Embedder that shares the provider's client and credentials.
+// An empty model selects text-embedding-3-small.
+func (p *Provider) Embedder(model string) *Embedder {
+	if model == "" {
+		model = defaultEmbeddingModel
+	}
+	return &Embedder{provider: p, model: model}
+}
+
+var _ llm.Embedder = (*Embedder)(nil)
+
+// Embed returns one embedding vector per text.
+func (e *Embedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, errors.New("no texts to embed")
+	}
+
+	resp, err := e.provider.client.Embeddings.New(ctx, sdk.EmbeddingNewParams{
+		Model: e.model,
+		Input: sdk.EmbeddingNewParamsInputUnion{OfArrayOfStrings: texts},
+	})
+	if err != nil {
+		e.provider.logger.Error("[OpenAI] Embedding request failed", err)
+		return nil, err
+	}
+	if len(resp.Data) != len(texts) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(resp.Data))
+	}
+
+	vectors := make([][]float32, len(texts))
+	for _, data := range resp.Data {
+		if data.Index < 0 || int(data.Index) >= len(texts) {
+			return nil, fmt.Errorf("embedding index %d out of range", data.Index)
+		}
+		vector := make([]float32, len(data.Embedding))
+		for i, value := range data.Embedding {
+			vector[i] = float32(value)
+		}
+		vectors[data.Index] = vector
+	}
+	return vectors, nil
+}
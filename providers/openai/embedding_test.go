@@ -0,0 +1,52 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type silentLogger struct{}
+
+func (silentLogger) Debug(string)                    {}
+func (silentLogger) Debugf(string, ...interface{})   {}
+func (silentLogger) Info(string)                     {}
+func (silentLogger) Infof(string, ...interface{})    {}
+func (silentLogger) Warning(string)                  {}
+func (silentLogger) Warningf(string, ...interface{}) {}
+func (silentLogger) Error(string, error)             {}
+func (silentLogger) Errorf(string, ...interface{})   {}
+
+func TestEmbedderOrdersVectorsByIndex(t *testing.T) {
+	var request map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/embeddings" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&request)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"object":"list","model":"text-embedding-3-small","data":[
+			{"object":"embedding","index":1,"embedding":[0,1]},
+			{"object":"embedding","index":0,"embedding":[1,0]}
+		],"usage":{"prompt_tokens":4,"total_tokens":4}}`))
+	}))
+	defer server.Close()
+
+	provider, err := NewWithBaseURL(silentLogger{}, "test-key", "", server.URL, 0)
+	if err != nil {
+		t.Fatalf("NewWithBaseURL failed: %v", err)
+	}
+
+	vectors, err := provider.Embedder("").Embed(context.Background(), []string{"first", "second"})
+	if err != nil {
+		t.Fatalf("Embed failed: %v", err)
+	}
+	if request["model"] != "text-embedding-3-small" {
+		t.Errorf("model = %v", request["model"])
+	}
+	if len(vectors) != 2 || vectors[0][0] != 1 || vectors[1][1] != 1 {
+		t.Errorf("vectors = %v", vectors)
+	}
+}
@@ -1,10 +1,9 @@
-﻿package openai
+package openai
 
 import (
 	"context"
 	"errors"
 	"io"
-	"os"
 	"strings"
 
 	"net/http"
@@ -13,8 +12,10 @@ import (
 	sdk "github.com/openai/openai-go"
 	"github.com/openai/openai-go/option"
 
+	"github.com/ulgerang/llm-module/internal/openaicompat"
 	"github.com/ulgerang/llm-module/llm"
 	"github.com/ulgerang/llm-module/logger"
+	"github.com/ulgerang/llm-module/redact"
 )
 
 const (
@@ -28,16 +29,24 @@ type Provider struct {
 	apiKey    string
 	modelName string
 	logger    logger.Logger
+	defaults  []llm.GenerationOption
 }
 
+// sampling lists the sampling parameters the OpenAI API accepts.
+var sampling = openaicompat.Sampling{Seed: true, Penalties: true, LogitBias: true, Logprobs: true, Candidates: true}
+
+// capabilities lists the features the OpenAI provider supports.
+var capabilities = llm.Capabilities{Streaming: true, Tools: true, ParallelTools: true, StructuredOutput: llm.StructuredOutputNative, Caching: true, Logprobs: true, Candidates: true}
+
 // New creates a new Provider instance using the official Go client.
-func New(log logger.Logger, apiKey, modelName string, timeout time.Duration) (*Provider, error) {
-	resolvedAPIKey := apiKey
+func New(log logger.Logger, apiKey, modelName string, timeout time.Duration, providerOpts ...llm.ProviderOption) (*Provider, error) {
+	config := llm.ResolveProviderOptions(providerOpts...)
+	resolvedAPIKey, err := config.ResolveAPIKey(apiKey, "OPENAI_API_KEY")
+	if err != nil {
+		return nil, err
+	}
 	if resolvedAPIKey == "" {
-		resolvedAPIKey = os.Getenv("OPENAI_API_KEY")
-		if resolvedAPIKey == "" {
-			log.Info("[OpenAI] API key not explicitly provided, relying on OPENAI_API_KEY environment variable.")
-		}
+		log.Info("[OpenAI] API key not explicitly provided, relying on OPENAI_API_KEY environment variable.")
 	}
 
 	if modelName == "" {
@@ -47,13 +56,19 @@ func New(log logger.Logger, apiKey, modelName string, timeout time.Duration) (*P
 	var client sdk.Client
 	opts := []option.RequestOption{}
 	if resolvedAPIKey != "" {
+		redact.AddSecret(resolvedAPIKey)
 		opts = append(opts, option.WithAPIKey(resolvedAPIKey))
 	}
 
+	var httpClient *http.Client
 	if timeout > 0 {
-		httpClient := &http.Client{Timeout: timeout}
-		opts = append(opts, option.WithHTTPClient(httpClient))
+		httpClient = &http.Client{Timeout: timeout}
+	}
+	httpOpts, err := openaicompat.ClientOptions(config, httpClient)
+	if err != nil {
+		return nil, err
 	}
+	opts = append(opts, httpOpts...)
 
 	client = sdk.NewClient(opts...)
 
@@ -61,15 +76,17 @@ func New(log logger.Logger, apiKey, modelName string, timeout time.Duration) (*P
 		client:    client,
 		apiKey:    resolvedAPIKey,
 		modelName: modelName,
-		logger:    log,
+		logger:    logger.With(log, logger.Provider("openai"), logger.Model(modelName)),
+		defaults:  config.RequestDefaults(),
 	}, nil
 }
 
 // NewWithBaseURL creates a new Provider instance with a custom Base URL.
-func NewWithBaseURL(log logger.Logger, apiKey, modelName, baseURL string, timeout time.Duration) (*Provider, error) {
-	resolvedAPIKey := apiKey
-	if resolvedAPIKey == "" {
-		resolvedAPIKey = os.Getenv("OPENAI_API_KEY")
+func NewWithBaseURL(log logger.Logger, apiKey, modelName, baseURL string, timeout time.Duration, providerOpts ...llm.ProviderOption) (*Provider, error) {
+	config := llm.ResolveProviderOptions(providerOpts...)
+	resolvedAPIKey, err := config.ResolveAPIKey(apiKey, "OPENAI_API_KEY")
+	if err != nil {
+		return nil, err
 	}
 
 	if modelName == "" {
@@ -79,15 +96,21 @@ func NewWithBaseURL(log logger.Logger, apiKey, modelName, baseURL string, timeou
 	var client sdk.Client
 	opts := []option.RequestOption{}
 	if resolvedAPIKey != "" {
+		redact.AddSecret(resolvedAPIKey)
 		opts = append(opts, option.WithAPIKey(resolvedAPIKey))
 	}
 	if baseURL != "" {
 		opts = append(opts, option.WithBaseURL(baseURL))
 	}
+	var httpClient *http.Client
 	if timeout > 0 {
-		httpClient := &http.Client{Timeout: timeout}
-		opts = append(opts, option.WithHTTPClient(httpClient))
+		httpClient = &http.Client{Timeout: timeout}
+	}
+	httpOpts, err := openaicompat.ClientOptions(config, httpClient)
+	if err != nil {
+		return nil, err
 	}
+	opts = append(opts, httpOpts...)
 
 	client = sdk.NewClient(opts...)
 
@@ -95,10 +118,21 @@ func NewWithBaseURL(log logger.Logger, apiKey, modelName, baseURL string, timeou
 		client:    client,
 		apiKey:    resolvedAPIKey,
 		modelName: modelName,
-		logger:    log,
+		logger:    logger.With(log, logger.Provider("openai"), logger.Model(modelName)),
+		defaults:  config.RequestDefaults(),
 	}, nil
 }
 
+// ListModels lists the models the API serves.
+func (p *Provider) ListModels(ctx context.Context) ([]llm.Model, error) {
+	return openaicompat.ListModels(ctx, p.client, "openai")
+}
+
+// Capabilities reports the features of the provider and its model.
+func (p *Provider) Capabilities() llm.Capabilities {
+	return llm.ModelCapabilities(capabilities, p.modelName)
+}
+
 // GetModelName returns the model name used by this provider.
 func (p *Provider) GetModelName() string {
 	return p.modelName
@@ -110,6 +144,9 @@ func (p *Provider) GenerateText(ctx context.Context, prompt string, opts ...llm.
 		Temperature: llm.ValuePtr(float32(0.7)),
 		MaxTokens:   llm.ValuePtr(int32(2048)),
 	}
+	for _, opt := range p.defaults {
+		opt(options)
+	}
 	for _, opt := range opts {
 		opt(options)
 	}
@@ -138,11 +175,10 @@ func (p *Provider) GenerateText(ctx context.Context, prompt string, opts ...llm.
 		}
 	}
 
-	messages := []sdk.ChatCompletionMessageParamUnion{}
-	if systemPrompt != "" {
-		messages = append(messages, sdk.SystemMessage(systemPrompt))
+	messages, err := openaicompat.Messages(systemPrompt, options, prompt)
+	if err != nil {
+		return "", nil, err
 	}
-	messages = append(messages, sdk.UserMessage(prompt))
 
 	params := sdk.ChatCompletionNewParams{
 		Messages: messages,
@@ -159,50 +195,30 @@ func (p *Provider) GenerateText(ctx context.Context, prompt string, opts ...llm.
 		params.TopP = sdk.Float(float64(*options.TopP))
 	}
 
+	if err := llm.ValidateToolChoice(options); err != nil {
+		return "", nil, err
+	}
+
 	if len(options.Tools) > 0 {
 		p.logger.Info("[OpenAI] Using Tool Calling mode.")
-		params.Tools = make([]sdk.ChatCompletionToolParam, 0, len(options.Tools))
-		for _, t := range options.Tools {
-			if t.InputSchema == nil {
-				p.logger.Warningf("[OpenAI] Tool '%s' has no InputSchema, skipping parameter definition.", t.Name)
-				continue
-			}
-			schemaMap, err := llm.ConvertSchemaToMap(t.InputSchema)
-			if err != nil {
-				p.logger.Errorf("[OpenAI] Failed to convert schema for tool '%s': %v", t.Name, err)
-				return "", nil, errors.New("failed to process tool schema for tool: " + t.Name)
-			}
-
-			toolParam := sdk.ChatCompletionToolParam{
-				Function: sdk.FunctionDefinitionParam{
-					Name:        t.Name,
-					Description: sdk.String(t.Description),
-					Parameters:  schemaMap,
-				},
-			}
-			params.Tools = append(params.Tools, toolParam)
+		if err := openaicompat.ApplyTools(&params, options); err != nil {
+			p.logger.Errorf("[OpenAI] Failed to apply tools: %v", err)
+			return "", nil, err
 		}
 	} else if options.ResponseSchema != nil {
 		p.logger.Info("[OpenAI] Using Structured Output (JSON Schema) mode.")
-		schemaMap, err := llm.ConvertSchemaToMap(options.ResponseSchema)
-		if err != nil {
-			p.logger.Error("[OpenAI] Failed to convert ResponseSchema to map: ", err)
-			return "", nil, errors.New("failed to process response schema")
+		if err := p.applyResponseSchema(&params, options); err != nil {
+			return "", nil, err
 		}
+	}
 
-		schemaParam := sdk.ResponseFormatJSONSchemaJSONSchemaParam{
-			Name:        structuredOutputSchemaName,
-			Description: sdk.String("Structured output based on the requested schema"),
-			Schema:      schemaMap,
-			Strict:      sdk.Bool(true),
-		}
-		params.ResponseFormat = sdk.ChatCompletionNewParamsResponseFormatUnion{
-			OfJSONSchema: &sdk.ResponseFormatJSONSchemaParam{JSONSchema: schemaParam},
-		}
+	if err := openaicompat.ApplySampling(&params, options, "openai", sampling, p.logger.Warning); err != nil {
+		return "", nil, err
 	}
 
-	resp, err := p.client.Chat.Completions.New(ctx, params)
+	resp, err := p.client.Chat.Completions.New(ctx, params, append(cacheKeyOptions(options), openaicompat.RequestOptions(options)...)...)
 	if err != nil {
+		err = openaicompat.Error("openai", err)
 		p.logger.Error("[OpenAI] API error: ", err)
 		return "", nil, err
 	}
@@ -214,14 +230,21 @@ func (p *Provider) GenerateText(ctx context.Context, prompt string, opts ...llm.
 
 	choice := resp.Choices[0]
 	responseText := ""
-	usage := &llm.UsageInfo{}
-
-	usage.InputTokens = int(resp.Usage.PromptTokens)
-	usage.OutputTokens = int(resp.Usage.CompletionTokens)
+	usage := openaicompat.Usage(resp.Usage)
+	usage.ResponseModel = resp.Model
+	usage.FinishReason = choice.FinishReason
+	usage.Completion = openaicompat.Completion(options, resp.Choices)
+	if usage.CacheHitTokens > 0 {
+		p.logger.Infof("[OpenAI] Cache hit: %d tokens", usage.CacheHitTokens)
+	}
 
 	if len(choice.Message.ToolCalls) > 0 {
-		p.logger.Infof("[OpenAI] Received %d Tool Call(s). Returning arguments of the first call.", len(choice.Message.ToolCalls))
-		responseText = choice.Message.ToolCalls[0].Function.Arguments
+		p.logger.Infof("[OpenAI] Received %d tool call(s)", len(choice.Message.ToolCalls))
+		toolCalls, err := llm.FormatToolCalls(openaicompat.ToolCalls(choice.Message.ToolCalls))
+		if err != nil {
+			return "", nil, err
+		}
+		responseText = toolCalls
 	} else {
 		responseText = choice.Message.Content
 	}
@@ -241,6 +264,9 @@ func (p *Provider) GenerateTextStream(ctx context.Context, prompt string, outCha
 		Temperature: llm.ValuePtr(float32(0.7)),
 		MaxTokens:   llm.ValuePtr(int32(1024)),
 	}
+	for _, opt := range p.defaults {
+		opt(options)
+	}
 	for _, opt := range opts {
 		opt(options)
 	}
@@ -269,11 +295,11 @@ func (p *Provider) GenerateTextStream(ctx context.Context, prompt string, outCha
 		}
 	}
 
-	messages := []sdk.ChatCompletionMessageParamUnion{}
-	if systemPrompt != "" {
-		messages = append(messages, sdk.SystemMessage(systemPrompt))
+	messages, err := openaicompat.Messages(systemPrompt, options, prompt)
+	if err != nil {
+		outChan <- llm.StreamChunk{Err: err}
+		return nil, err
 	}
-	messages = append(messages, sdk.UserMessage(prompt))
 
 	params := sdk.ChatCompletionNewParams{
 		Messages: messages,
@@ -290,22 +316,47 @@ func (p *Provider) GenerateTextStream(ctx context.Context, prompt string, outCha
 		params.TopP = sdk.Float(float64(*options.TopP))
 	}
 
+	if options.ToolChoice.ForcesToolUse() {
+		err := llm.NewUnsupportedOptionError("openai", "ToolChoice", "tool calling is not available for streaming")
+		outChan <- llm.StreamChunk{Err: err}
+		return nil, err
+	}
+
 	if len(options.Tools) > 0 {
-		p.logger.Warning("[OpenAI Stream] Tool Calling (WithTools) is disabled for streaming in this provider. Ignoring tools.")
+		if err := options.Unsupported("openai", "Tools", "tool calling is not available for streaming", p.logger.Warning); err != nil {
+			outChan <- llm.StreamChunk{Err: err}
+			return nil, err
+		}
 	} else if options.ResponseSchema != nil {
-		p.logger.Warning("[OpenAI Stream] Structured Output (WithResponseSchema) is not supported for streaming by OpenAI. Ignoring schema.")
+		if err := p.applyResponseSchema(&params, options); err != nil {
+			outChan <- llm.StreamChunk{Err: err}
+			return nil, err
+		}
+	}
+
+	params.StreamOptions = sdk.ChatCompletionStreamOptionsParam{IncludeUsage: sdk.Bool(true)}
+
+	if err := openaicompat.ApplySampling(&params, options, "openai", sampling.Stream(), p.logger.Warning); err != nil {
+		outChan <- llm.StreamChunk{Err: err}
+		return nil, err
 	}
 
-	stream := p.client.Chat.Completions.NewStreaming(ctx, params)
+	stream := p.client.Chat.Completions.NewStreaming(ctx, params, append(cacheKeyOptions(options), openaicompat.RequestOptions(options)...)...)
 	defer stream.Close()
 
 	var lastUsage *sdk.CompletionUsage
-	var systemFingerprint string
+	var systemFingerprint, responseModel, finishReason string
 
 	for stream.Next() {
 		resp := stream.Current()
+		if resp.Model != "" {
+			responseModel = resp.Model
+		}
 
 		if len(resp.Choices) > 0 {
+			if resp.Choices[0].FinishReason != "" {
+				finishReason = resp.Choices[0].FinishReason
+			}
 			deltaContent := resp.Choices[0].Delta.Content
 			if deltaContent != "" {
 				select {
@@ -326,6 +377,7 @@ func (p *Provider) GenerateTextStream(ctx context.Context, prompt string, outCha
 	}
 
 	streamErr := stream.Err()
+	streamErr = openaicompat.Error("openai", streamErr)
 	if streamErr != nil && !errors.Is(streamErr, io.EOF) {
 		p.logger.Error("[OpenAI Stream] Stream error: ", streamErr)
 		finalUsageInfo, procErr := processFinalUsage(lastUsage, p.logger)
@@ -339,6 +391,10 @@ func (p *Provider) GenerateTextStream(ctx context.Context, prompt string, outCha
 	if procErr != nil {
 		p.logger.Errorf("[OpenAI Stream] Error processing final usage data: %v", procErr)
 	}
+	if finalUsageInfo != nil {
+		finalUsageInfo.ResponseModel = responseModel
+		finalUsageInfo.FinishReason = finishReason
+	}
 
 	if systemFingerprint != "" {
 		p.logger.Info("[OpenAI Stream] System Fingerprint: " + systemFingerprint)
@@ -347,6 +403,27 @@ func (p *Provider) GenerateTextStream(ctx context.Context, prompt string, outCha
 	return finalUsageInfo, nil
 }
 
+// applyResponseSchema constrains the reply to options.ResponseSchema with a strict
+// JSON schema response format.
+func (p *Provider) applyResponseSchema(params *sdk.ChatCompletionNewParams, options *llm.GenerationOptions) error {
+	schemaMap, err := llm.ConvertSchemaToMap(options.ResponseSchema)
+	if err != nil {
+		p.logger.Error("[OpenAI] Failed to convert ResponseSchema to map: ", err)
+		return errors.New("failed to process response schema")
+	}
+
+	schemaParam := sdk.ResponseFormatJSONSchemaJSONSchemaParam{
+		Name:        structuredOutputSchemaName,
+		Description: sdk.String("Structured output based on the requested schema"),
+		Schema:      schemaMap,
+		Strict:      sdk.Bool(true),
+	}
+	params.ResponseFormat = sdk.ChatCompletionNewParamsResponseFormatUnion{
+		OfJSONSchema: &sdk.ResponseFormatJSONSchemaParam{JSONSchema: schemaParam},
+	}
+	return nil
+}
+
 func processFinalUsage(lastUsage *sdk.CompletionUsage, log logger.Logger) (*llm.UsageInfo, error) {
 	if lastUsage == nil {
 		log.Warning("[OpenAI Stream] No usage information received during stream.")
@@ -354,22 +431,22 @@ func processFinalUsage(lastUsage *sdk.CompletionUsage, log logger.Logger) (*llm.
 	}
 
 	log.Info("[OpenAI Stream] Processing final usage data.")
-	finalUsageInfo := &llm.UsageInfo{
-		InputTokens:    int(lastUsage.PromptTokens),
-		OutputTokens:   int(lastUsage.CompletionTokens),
-		CacheHitTokens: 0,
-	}
-
-	cacheHitTokens := int(lastUsage.PromptTokensDetails.CachedTokens)
-	if cacheHitTokens > 0 {
-		log.Infof("[OpenAI Stream] Final Cache hit: %d tokens", cacheHitTokens)
+	finalUsageInfo := openaicompat.Usage(*lastUsage)
+	if finalUsageInfo.CacheHitTokens > 0 {
+		log.Infof("[OpenAI Stream] Final Cache hit: %d tokens", finalUsageInfo.CacheHitTokens)
 	}
 
-	finalUsageInfo.CacheHitTokens = cacheHitTokens
-
 	return finalUsageInfo, nil
 }
 
+// cacheKeyOptions sends prompt_cache_key, which routes requests sharing a prefix to the same cache.
+func cacheKeyOptions(options *llm.GenerationOptions) []option.RequestOption {
+	if options.CacheKey == "" {
+		return nil
+	}
+	return []option.RequestOption{option.WithJSONSet("prompt_cache_key", options.CacheKey)}
+}
+
 // Close cleans up resources.
 func (p *Provider) Close() error {
 	p.logger.Info("[OpenAI] Provider closed.")
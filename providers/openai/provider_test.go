@@ -0,0 +1,314 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ulgerang/llm-module/llm"
+	"github.com/ulgerang/llm-module/redact"
+	"github.com/ulgerang/llm-module/transport"
+)
+
+func TestHTTPTraceCapturesStreamFrames(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, delta := range []string{"Hel", "lo"} {
+			fmt.Fprintf(w, "data: {\"id\":\"c1\",\"object\":\"chat.completion.chunk\",\"model\":\"gpt-test\",\"choices\":[{\"index\":0,\"delta\":{\"content\":%q}}]}\n\n", delta)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	var exchanges []*transport.Exchange
+	provider, err := NewWithBaseURL(silentLogger{}, "test-key-123456", "gpt-test", server.URL, 0, transport.WithTrace(transport.SinkFunc(func(e *transport.Exchange) {
+		exchanges = append(exchanges, e)
+	})))
+	if err != nil {
+		t.Fatalf("NewWithBaseURL failed: %v", err)
+	}
+
+	out := make(chan llm.StreamChunk)
+	go func() {
+		for range out {
+		}
+	}()
+	if _, err := provider.GenerateTextStream(context.Background(), "Hello", out); err != nil {
+		t.Fatalf("GenerateTextStream failed: %v", err)
+	}
+
+	if len(exchanges) != 1 {
+		t.Fatalf("expected 1 traced exchange, got %d", len(exchanges))
+	}
+	e := exchanges[0]
+	if !strings.Contains(e.RequestBody, `"stream":true`) || len(e.Frames) != 3 || e.Frames[2].Data != "data: [DONE]" {
+		t.Errorf("unexpected exchange %+v", e)
+	}
+	if got := e.RequestHeader.Get("Authorization"); got != redact.Placeholder {
+		t.Errorf("Authorization header not redacted: %q", got)
+	}
+}
+
+type cannedTransport struct {
+	hosts []string
+}
+
+func (t *cannedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.hosts = append(t.hosts, req.URL.Host)
+	body := `{"id":"c1","object":"chat.completion","model":"gpt-test","choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":"Hi."}}]}`
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+func TestInjectedHTTPClientIsUsed(t *testing.T) {
+	rt := &cannedTransport{}
+	provider, err := New(silentLogger{}, "test-key-123456", "gpt-test", 0, llm.WithHTTPClient(&http.Client{Transport: rt}))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	text, _, err := provider.GenerateText(context.Background(), "Hello")
+	if err != nil {
+		t.Fatalf("GenerateText failed: %v", err)
+	}
+	if text != "Hi." || len(rt.hosts) != 1 || rt.hosts[0] != "api.openai.com" {
+		t.Errorf("text = %q, hosts = %v", text, rt.hosts)
+	}
+}
+
+func TestStreamIdleTimeoutReturnsStallError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"id\":\"c1\",\"object\":\"chat.completion.chunk\",\"model\":\"gpt-test\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hel\"}}]}\n\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	provider, err := NewWithBaseURL(silentLogger{}, "test-key-123456", "gpt-test", server.URL, 0, llm.WithTimeouts(llm.Timeouts{StreamIdle: 50 * time.Millisecond}))
+	if err != nil {
+		t.Fatalf("NewWithBaseURL failed: %v", err)
+	}
+	out := make(chan llm.StreamChunk, 10)
+	_, err = provider.GenerateTextStream(context.Background(), "Hello", out)
+	if !errors.Is(err, llm.ErrStreamStalled) {
+		t.Errorf("expected a stall error, got %v", err)
+	}
+}
+
+func TestRequestPassthrough(t *testing.T) {
+	var body map[string]interface{}
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id":"c1","object":"chat.completion","model":"gpt-test","choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":"Hi."}}]}`)
+	}))
+	defer server.Close()
+
+	provider, err := NewWithBaseURL(silentLogger{}, "test-key-123456", "gpt-test", server.URL, 0,
+		llm.WithDefaultExtraBody(map[string]interface{}{"provider": map[string]interface{}{"order": []string{"a"}}}))
+	if err != nil {
+		t.Fatalf("NewWithBaseURL failed: %v", err)
+	}
+	_, _, err = provider.GenerateText(context.Background(), "Hello",
+		llm.WithHeaders(http.Header{"X-Request-Tag": {"batch"}}),
+		llm.WithMetadata(map[string]string{llm.MetadataUserID: "user-42"}),
+		llm.WithExtraBody(map[string]interface{}{"vendor.flag": true}))
+	if err != nil {
+		t.Fatalf("GenerateText failed: %v", err)
+	}
+
+	if header.Get("X-Request-Tag") != "batch" {
+		t.Errorf("header not sent: %v", header)
+	}
+	routing, _ := body["provider"].(map[string]interface{})
+	if body["user"] != "user-42" || body["vendor.flag"] != true || routing == nil {
+		t.Errorf("unexpected body %v", body)
+	}
+}
+
+func TestSamplingOptions(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id":"c1","object":"chat.completion","model":"gpt-test","choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":"Hi."}}]}`)
+	}))
+	defer server.Close()
+
+	provider, err := NewWithBaseURL(silentLogger{}, "test-key-123456", "gpt-test", server.URL, 0)
+	if err != nil {
+		t.Fatalf("NewWithBaseURL failed: %v", err)
+	}
+	_, _, err = provider.GenerateText(context.Background(), "Hello",
+		llm.WithStopSequences("END"),
+		llm.WithSeed(42),
+		llm.WithFrequencyPenalty(0.5),
+		llm.WithLogitBias(map[int]int{50256: -100}))
+	if err != nil {
+		t.Fatalf("GenerateText failed: %v", err)
+	}
+
+	stop, _ := body["stop"].([]interface{})
+	bias, _ := body["logit_bias"].(map[string]interface{})
+	if len(stop) != 1 || stop[0] != "END" || body["seed"] != float64(42) || body["frequency_penalty"] != 0.5 || bias["50256"] != float64(-100) {
+		t.Errorf("unexpected body %v", body)
+	}
+	if _, ok := body["presence_penalty"]; ok {
+		t.Errorf("unset presence_penalty was sent: %v", body)
+	}
+}
+
+func TestCandidatesWithLogprobs(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id":"c1","object":"chat.completion","model":"gpt-test","choices":[`+
+			`{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":"yes"},"logprobs":{"content":[{"token":"yes","logprob":-0.1,"bytes":null,"top_logprobs":[{"token":"yes","logprob":-0.1,"bytes":null},{"token":"no","logprob":-2.4,"bytes":null}]}],"refusal":null}},`+
+			`{"index":1,"finish_reason":"stop","message":{"role":"assistant","content":"no"},"logprobs":{"content":[{"token":"no","logprob":-2.4,"bytes":null,"top_logprobs":[]}],"refusal":null}}]}`)
+	}))
+	defer server.Close()
+
+	provider, err := NewWithBaseURL(silentLogger{}, "test-key-123456", "gpt-test", server.URL, 0)
+	if err != nil {
+		t.Fatalf("NewWithBaseURL failed: %v", err)
+	}
+	text, usage, err := provider.GenerateText(context.Background(), "Answer yes or no", llm.WithLogprobs(2), llm.WithCandidates(2))
+	if err != nil {
+		t.Fatalf("GenerateText failed: %v", err)
+	}
+
+	if body["logprobs"] != true || body["top_logprobs"] != float64(2) || body["n"] != float64(2) {
+		t.Errorf("unexpected body %v", body)
+	}
+	if text != "yes" || usage.Completion == nil || len(usage.Completion.Candidates) != 2 {
+		t.Fatalf("unexpected result %q, %+v", text, usage)
+	}
+	first := usage.Completion.Candidates[0]
+	if len(first.Logprobs) != 1 || len(first.Logprobs[0].TopLogprobs) != 2 || usage.Completion.Candidates[1].Text != "no" {
+		t.Errorf("unexpected candidates %+v", usage.Completion.Candidates)
+	}
+	probabilities, err := llm.ClassProbabilities(first.Logprobs, []string{"yes", "no"})
+	if err != nil || probabilities["yes"] < 0.9 {
+		t.Errorf("ClassProbabilities() = %v, %v", probabilities, err)
+	}
+}
+
+func TestStreamingAppliesResponseSchemaAndStrictRejectsTools(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"id\":\"c1\",\"object\":\"chat.completion.chunk\",\"model\":\"gpt-test\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"{}\"},\"finish_reason\":\"stop\"}]}\n\ndata: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	provider, err := NewWithBaseURL(silentLogger{}, "test-key-123456", "gpt-test", server.URL, 0)
+	if err != nil {
+		t.Fatalf("NewWithBaseURL failed: %v", err)
+	}
+	schema := &llm.SchemaProperty{Type: "object", Properties: map[string]*llm.SchemaProperty{"answer": {Type: "string"}}}
+	if _, err := provider.GenerateTextStream(context.Background(), "Hello", make(chan llm.StreamChunk, 10), llm.WithResponseSchema(schema)); err != nil {
+		t.Fatalf("GenerateTextStream failed: %v", err)
+	}
+	format, _ := body["response_format"].(map[string]interface{})
+	if format["type"] != "json_schema" {
+		t.Errorf("response schema not sent: %v", body)
+	}
+
+	tool := llm.MustNewFuncTool("lookup", "Look up a value", func(ctx context.Context, args struct{}) (string, error) { return "", nil })
+	_, err = provider.GenerateTextStream(context.Background(), "Hello", make(chan llm.StreamChunk, 10), llm.WithTools([]*llm.Tool{tool}), llm.WithStrict())
+	if !errors.Is(err, llm.ErrUnsupportedOption) {
+		t.Errorf("expected ErrUnsupportedOption, got %v", err)
+	}
+	if caps := provider.Capabilities(); !caps.Tools || caps.StreamingTools || caps.StructuredOutput != llm.StructuredOutputNative {
+		t.Errorf("Capabilities() = %+v", caps)
+	}
+}
+
+func TestToolCallsAreFormatted(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id":"c1","object":"chat.completion","model":"gpt-test","choices":[{"index":0,"finish_reason":"tool_calls","message":{"role":"assistant","content":"","tool_calls":[`+
+			`{"id":"call_1","type":"function","function":{"name":"lookup","arguments":"{\"key\":\"a\"}"}},`+
+			`{"id":"call_2","type":"function","function":{"name":"lookup","arguments":"{\"key\":\"b\"}"}}]}}]}`)
+	}))
+	defer server.Close()
+
+	provider, err := NewWithBaseURL(silentLogger{}, "test-key-123456", "gpt-test", server.URL, 0)
+	if err != nil {
+		t.Fatalf("NewWithBaseURL failed: %v", err)
+	}
+	tool := llm.MustNewFuncTool("lookup", "Look up a value", func(ctx context.Context, args struct {
+		Key string `json:"key"`
+	}) (string, error) {
+		return args.Key, nil
+	})
+	text, _, err := provider.GenerateText(context.Background(), "Hello", llm.WithTools([]*llm.Tool{tool}))
+	if err != nil {
+		t.Fatalf("GenerateText failed: %v", err)
+	}
+	calls, ok, err := llm.ParseToolCalls(text)
+	if err != nil || !ok || len(calls) != 2 || calls[1].ID != "call_2" || string(calls[1].Input) != `{"key":"b"}` {
+		t.Errorf("ParseToolCalls(%q) = %+v, %v, %v", text, calls, ok, err)
+	}
+}
+
+func TestConversationHistoryIsSent(t *testing.T) {
+	var body struct {
+		Messages []struct {
+			Role    string `json:"role"`
+			Content string `json:"content"`
+		} `json:"messages"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id":"c1","object":"chat.completion","model":"gpt-test","choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":"Blue."}}]}`)
+	}))
+	defer server.Close()
+
+	provider, err := NewWithBaseURL(silentLogger{}, "test-key-123456", "gpt-test", server.URL, 0)
+	if err != nil {
+		t.Fatalf("NewWithBaseURL failed: %v", err)
+	}
+	_, _, err = provider.GenerateText(context.Background(), "And now?", llm.WithSystem("Be brief."), llm.WithMessages([]llm.Message{
+		{Role: llm.RoleUser, Content: "What colour is the sky?"},
+		{Role: llm.RoleAssistant, Content: "Blue."},
+	}))
+	if err != nil {
+		t.Fatalf("GenerateText failed: %v", err)
+	}
+
+	var roles []string
+	for _, message := range body.Messages {
+		roles = append(roles, message.Role)
+	}
+	if strings.Join(roles, ",") != "system,user,assistant,user" || body.Messages[3].Content != "And now?" {
+		t.Errorf("unexpected messages %+v", body.Messages)
+	}
+}
+
+func TestCachedContentIsUnsupported(t *testing.T) {
+	provider, err := NewWithBaseURL(silentLogger{}, "test-key-123456", "gpt-test", "http://127.0.0.1:0", 0)
+	if err != nil {
+		t.Fatalf("NewWithBaseURL failed: %v", err)
+	}
+	_, _, err = provider.GenerateText(context.Background(), "Hello", llm.WithCachedContent("cachedContents/abc"), llm.WithStrict())
+	if !errors.Is(err, llm.ErrUnsupportedOption) {
+		t.Errorf("expected ErrUnsupportedOption, got %v", err)
+	}
+}
@@ -0,0 +1,62 @@
+package openrouter
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/ulgerang/llm-module/internal/openaicompat"
+	"github.com/ulgerang/llm-module/llm"
+)
+
+// catalogModel is an entry of the OpenRouter model catalog. Prices are USD per token.
+type catalogModel struct {
+	ID            string `json:"id"`
+	Name          string `json:"name"`
+	Created       int64  `json:"created"`
+	ContextLength int    `json:"context_length"`
+	Pricing       struct {
+		Prompt     string `json:"prompt"`
+		Completion string `json:"completion"`
+	} `json:"pricing"`
+	TopProvider struct {
+		MaxCompletionTokens int `json:"max_completion_tokens"`
+	} `json:"top_provider"`
+}
+
+// ListModels lists the OpenRouter model catalog with context lengths and prices.
+func (p *Provider) ListModels(ctx context.Context) ([]llm.Model, error) {
+	var catalog struct {
+		Data []catalogModel `json:"data"`
+	}
+	if err := p.client.Get(ctx, "models", nil, &catalog); err != nil {
+		return nil, openaicompat.Error("openrouter", err)
+	}
+
+	models := make([]llm.Model, 0, len(catalog.Data))
+	for _, entry := range catalog.Data {
+		model := llm.Model{
+			ID:              entry.ID,
+			DisplayName:     entry.Name,
+			ContextWindow:   entry.ContextLength,
+			MaxOutputTokens: entry.TopProvider.MaxCompletionTokens,
+			InputPrice:      perMillion(entry.Pricing.Prompt),
+			OutputPrice:     perMillion(entry.Pricing.Completion),
+		}
+		if entry.Created > 0 {
+			model.Created = time.Unix(entry.Created, 0)
+		}
+		models = append(models, llm.FillModelLimits(model))
+	}
+	return models, nil
+}
+
+// perMillion converts a per-token price to USD per million tokens. Negative prices
+// mark variable pricing and are reported as 0.
+func perMillion(price string) float64 {
+	value, err := strconv.ParseFloat(price, 64)
+	if err != nil || value < 0 {
+		return 0
+	}
+	return value * 1e6
+}
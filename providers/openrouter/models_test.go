@@ -0,0 +1,47 @@
+package openrouter
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ulgerang/llm-module/logger"
+	"github.com/ulgerang/llm-module/metrics"
+)
+
+func TestListModelsReadsCatalog(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/models" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"data":[`+
+			`{"id":"openai/gpt-4o","name":"OpenAI: GPT-4o","created":1715367049,"context_length":128000,"pricing":{"prompt":"0.0000025","completion":"0.00001"},"top_provider":{"max_completion_tokens":16384}},`+
+			`{"id":"openrouter/auto","name":"Auto Router","created":0,"context_length":2000000,"pricing":{"prompt":"-1","completion":"-1"},"top_provider":{}}]}`)
+	}))
+	defer server.Close()
+
+	provider, err := NewWithBaseURL(logger.Nop(), "test-key", "", server.URL)
+	if err != nil {
+		t.Fatalf("NewWithBaseURL failed: %v", err)
+	}
+	models, err := provider.ListModels(context.Background())
+	if err != nil {
+		t.Fatalf("ListModels failed: %v", err)
+	}
+	if len(models) != 2 {
+		t.Fatalf("expected 2 models, got %+v", models)
+	}
+	gpt := models[0]
+	if gpt.DisplayName != "OpenAI: GPT-4o" || gpt.ContextWindow != 128000 || gpt.MaxOutputTokens != 16384 || gpt.InputPrice != 2.5 || gpt.OutputPrice != 10 {
+		t.Errorf("unexpected model %+v", gpt)
+	}
+	if models[1].InputPrice != 0 || !models[1].Created.IsZero() {
+		t.Errorf("variable pricing should be reported as 0: %+v", models[1])
+	}
+	if pricing := metrics.PricingFromModels(models); len(pricing) != 1 || pricing["openai/gpt-4o"].Output != 10 {
+		t.Errorf("PricingFromModels() = %v", pricing)
+	}
+}
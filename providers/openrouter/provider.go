@@ -4,20 +4,21 @@ import (
 	"context"
 	"errors"
 	"io"
-	"os"
 	"strings"
 
 	sdk "github.com/openai/openai-go"
 	"github.com/openai/openai-go/option"
 
+	"github.com/ulgerang/llm-module/internal/openaicompat"
 	"github.com/ulgerang/llm-module/llm"
 	"github.com/ulgerang/llm-module/logger"
+	"github.com/ulgerang/llm-module/redact"
 )
 
 const (
-	apiBaseURL                  = "https://openrouter.ai/api/v1"
-	defaultModel                = "openai/gpt-4-turbo-preview"
-	structuredOutputSchemaName  = "structured_output"
+	apiBaseURL                 = "https://openrouter.ai/api/v1"
+	defaultModel               = "openai/gpt-4o"
+	structuredOutputSchemaName = "structured_output"
 )
 
 // Provider implements llm.Provider for OpenRouter using the OpenAI-compatible API.
@@ -26,30 +27,62 @@ type Provider struct {
 	apiKey    string
 	modelName string
 	logger    logger.Logger
+	defaults  []llm.GenerationOption
 }
 
-// New creates a new OpenRouter provider using the OpenAI Go SDK.
-func New(log logger.Logger, apiKey, modelName string) (*Provider, error) {
-	resolvedKey := apiKey
+// sampling lists the sampling parameters the OpenRouter API accepts.
+var sampling = openaicompat.Sampling{Seed: true, Penalties: true, LogitBias: true, Logprobs: true}
+
+// capabilities lists the features the OpenRouter provider supports.
+var capabilities = llm.Capabilities{Streaming: true, Tools: true, ParallelTools: true, StructuredOutput: llm.StructuredOutputNative, Logprobs: true}
+
+// New creates a new OpenRouter provider using the OpenAI Go SDK. OpenRouter's app
+// attribution headers, HTTP-Referer and X-Title, can be set with llm.WithDefaultHeaders.
+func New(log logger.Logger, apiKey, modelName string, opts ...llm.ProviderOption) (*Provider, error) {
+	return newProvider(log, apiKey, modelName, "", opts...)
+}
+
+// NewWithBaseURL creates a new OpenRouter provider with a custom base URL.
+func NewWithBaseURL(log logger.Logger, apiKey, modelName, baseURL string, opts ...llm.ProviderOption) (*Provider, error) {
+	return newProvider(log, apiKey, modelName, baseURL, opts...)
+}
+
+func newProvider(log logger.Logger, apiKey, modelName, baseURL string, opts ...llm.ProviderOption) (*Provider, error) {
+	config := llm.ResolveProviderOptions(opts...)
+	resolvedKey, err := config.ResolveAPIKey(apiKey, "OPENROUTER_API_KEY")
+	if err != nil {
+		return nil, err
+	}
 	if resolvedKey == "" {
-		resolvedKey = os.Getenv("OPENROUTER_API_KEY")
-		if resolvedKey == "" {
-			return nil, errors.New("OPENROUTER_API_KEY not provided")
-		}
+		return nil, errors.New("OPENROUTER_API_KEY not provided")
 	}
+	redact.AddSecret(resolvedKey)
 
 	if modelName == "" {
 		modelName = defaultModel
 	}
 
-	client := sdk.NewClient(
+	if baseURL == "" {
+		baseURL = apiBaseURL
+	}
+
+	clientOpts := []option.RequestOption{
 		option.WithAPIKey(resolvedKey),
-		option.WithBaseURL(apiBaseURL),
-		option.WithHeader("HTTP-Referer", "https://chatsite.ai"),
-		option.WithHeader("X-Title", "ChatSite AI"),
-	)
+		option.WithBaseURL(baseURL),
+	}
+	httpOpts, err := openaicompat.ClientOptions(config, nil)
+	if err != nil {
+		return nil, err
+	}
+	clientOpts = append(clientOpts, httpOpts...)
+	client := sdk.NewClient(clientOpts...)
+
+	return &Provider{client: client, apiKey: resolvedKey, modelName: modelName, logger: logger.With(log, logger.Provider("openrouter"), logger.Model(modelName)), defaults: config.RequestDefaults()}, nil
+}
 
-	return &Provider{client: client, apiKey: resolvedKey, modelName: modelName, logger: log}, nil
+// Capabilities reports the features of the provider and its model.
+func (p *Provider) Capabilities() llm.Capabilities {
+	return llm.ModelCapabilities(capabilities, p.modelName)
 }
 
 // GetModelName returns the configured OpenRouter model name.
@@ -63,17 +96,19 @@ func (p *Provider) GenerateText(ctx context.Context, prompt string, opts ...llm.
 		Temperature: llm.ValuePtr(float32(0.7)),
 		MaxTokens:   llm.ValuePtr(int32(2048)),
 	}
+	for _, opt := range p.defaults {
+		opt(options)
+	}
 	for _, opt := range opts {
 		opt(options)
 	}
 
 	systemPrompt := p.composeSystemPrompt(options)
 
-	messages := []sdk.ChatCompletionMessageParamUnion{}
-	if systemPrompt != "" {
-		messages = append(messages, sdk.SystemMessage(systemPrompt))
+	messages, err := openaicompat.Messages(systemPrompt, options, prompt)
+	if err != nil {
+		return "", nil, err
 	}
-	messages = append(messages, sdk.UserMessage(prompt))
 
 	params := sdk.ChatCompletionNewParams{Messages: messages, Model: p.modelName}
 
@@ -87,14 +122,25 @@ func (p *Provider) GenerateText(ctx context.Context, prompt string, opts ...llm.
 		params.TopP = sdk.Float(float64(*options.TopP))
 	}
 
+	if err := llm.ValidateToolChoice(options); err != nil {
+		return "", nil, err
+	}
+
 	if len(options.Tools) > 0 {
-		p.applyTools(&params, options)
+		if err := p.applyTools(&params, options); err != nil {
+			return "", nil, err
+		}
 	} else if options.ResponseSchema != nil {
 		p.applyStructuredOutput(&params, options)
 	}
 
-	resp, err := p.client.Chat.Completions.New(ctx, params)
+	if err := openaicompat.ApplySampling(&params, options, "openrouter", sampling, p.logger.Warning); err != nil {
+		return "", nil, err
+	}
+
+	resp, err := p.client.Chat.Completions.New(ctx, params, openaicompat.RequestOptions(options)...)
 	if err != nil {
+		err = openaicompat.Error("openrouter", err)
 		p.logger.Error("[OpenRouter] API error", err)
 		return "", nil, err
 	}
@@ -108,11 +154,16 @@ func (p *Provider) GenerateText(ctx context.Context, prompt string, opts ...llm.
 	usage := &llm.UsageInfo{
 		InputTokens:  int(resp.Usage.PromptTokens),
 		OutputTokens: int(resp.Usage.CompletionTokens),
+		Completion:   openaicompat.Completion(options, resp.Choices),
 	}
 
 	if len(choice.Message.ToolCalls) > 0 {
 		p.logger.Infof("[OpenRouter] Received %d tool call(s)", len(choice.Message.ToolCalls))
-		return choice.Message.ToolCalls[0].Function.Arguments, usage, nil
+		toolCalls, err := llm.FormatToolCalls(openaicompat.ToolCalls(choice.Message.ToolCalls))
+		if err != nil {
+			return "", nil, err
+		}
+		return toolCalls, usage, nil
 	}
 
 	if resp.SystemFingerprint != "" {
@@ -130,17 +181,20 @@ func (p *Provider) GenerateTextStream(ctx context.Context, prompt string, outCha
 		Temperature: llm.ValuePtr(float32(0.7)),
 		MaxTokens:   llm.ValuePtr(int32(1024)),
 	}
+	for _, opt := range p.defaults {
+		opt(options)
+	}
 	for _, opt := range opts {
 		opt(options)
 	}
 
 	systemPrompt := p.composeSystemPrompt(options)
 
-	messages := []sdk.ChatCompletionMessageParamUnion{}
-	if systemPrompt != "" {
-		messages = append(messages, sdk.SystemMessage(systemPrompt))
+	messages, err := openaicompat.Messages(systemPrompt, options, prompt)
+	if err != nil {
+		outChan <- llm.StreamChunk{Err: err}
+		return nil, err
 	}
-	messages = append(messages, sdk.UserMessage(prompt))
 
 	params := sdk.ChatCompletionNewParams{Messages: messages, Model: p.modelName}
 
@@ -154,13 +208,27 @@ func (p *Provider) GenerateTextStream(ctx context.Context, prompt string, outCha
 		params.TopP = sdk.Float(float64(*options.TopP))
 	}
 
+	if options.ToolChoice.ForcesToolUse() {
+		err := llm.NewUnsupportedOptionError("openrouter", "ToolChoice", "tool calling is not available for streaming")
+		outChan <- llm.StreamChunk{Err: err}
+		return nil, err
+	}
+
 	if len(options.Tools) > 0 {
-		p.logger.Warning("[OpenRouter Stream] Tool calling not supported, ignoring tools.")
+		if err := options.Unsupported("openrouter", "Tools", "tool calling is not available for streaming", p.logger.Warning); err != nil {
+			outChan <- llm.StreamChunk{Err: err}
+			return nil, err
+		}
 	} else if options.ResponseSchema != nil {
-		p.logger.Warning("[OpenRouter Stream] Structured output not supported for streaming, ignoring schema.")
+		p.applyStructuredOutput(&params, options)
+	}
+
+	if err := openaicompat.ApplySampling(&params, options, "openrouter", sampling.Stream(), p.logger.Warning); err != nil {
+		outChan <- llm.StreamChunk{Err: err}
+		return nil, err
 	}
 
-	stream := p.client.Chat.Completions.NewStreaming(ctx, params)
+	stream := p.client.Chat.Completions.NewStreaming(ctx, params, openaicompat.RequestOptions(options)...)
 	defer stream.Close()
 
 	var lastUsage *sdk.CompletionUsage
@@ -188,6 +256,7 @@ func (p *Provider) GenerateTextStream(ctx context.Context, prompt string, outCha
 	}
 
 	if err := stream.Err(); err != nil && !errors.Is(err, io.EOF) {
+		err = openaicompat.Error("openrouter", err)
 		p.logger.Error("[OpenRouter Stream] Stream error", err)
 		usage, _ := processFinalUsage(lastUsage, p.logger)
 		return usage, err
@@ -237,53 +306,16 @@ func (p *Provider) composeSystemPrompt(options *llm.GenerationOptions) string {
 	return systemPrompt
 }
 
-func (p *Provider) applyTools(params *sdk.ChatCompletionNewParams, options *llm.GenerationOptions) {
-	supported := []string{
-		"openai/gpt-4-turbo-preview",
-		"openai/gpt-4-turbo",
-		"openai/gpt-4",
-		"openai/gpt-3.5-turbo",
-		"anthropic/claude-3-opus",
-		"anthropic/claude-3-sonnet",
-		"anthropic/claude-3-haiku",
-	}
-
-	supportsTools := false
-	for _, model := range supported {
-		if strings.Contains(p.modelName, model) {
-			supportsTools = true
-			break
-		}
-	}
-
-	if !supportsTools {
-		p.logger.Warningf("[OpenRouter] Model '%s' does not support tool calling. Ignoring tools.", p.modelName)
-		return
-	}
-
-	params.Tools = make([]sdk.ChatCompletionToolParam, 0, len(options.Tools))
-	for _, tool := range options.Tools {
-		if tool.InputSchema == nil {
-			p.logger.Warningf("[OpenRouter] Tool '%s' missing schema, skipping", tool.Name)
-			continue
-		}
-
-		schemaMap, err := llm.ConvertSchemaToMap(tool.InputSchema)
-		if err != nil {
-			p.logger.Errorf("[OpenRouter] Failed to convert schema for tool '%s': %v", tool.Name, err)
-			continue
-		}
-
-		params.Tools = append(params.Tools, sdk.ChatCompletionToolParam{
-			Function: sdk.FunctionDefinitionParam{
-				Name:        tool.Name,
-				Description: sdk.String(tool.Description),
-				Parameters:  schemaMap,
-			},
-		})
+// applyTools sends the tools to every model. OpenRouter rejects them for models
+// without tool support.
+func (p *Provider) applyTools(params *sdk.ChatCompletionNewParams, options *llm.GenerationOptions) error {
+	if err := openaicompat.ApplyTools(params, options); err != nil {
+		p.logger.Errorf("[OpenRouter] Failed to apply tools: %v", err)
+		return err
 	}
 
 	p.logger.Info("[OpenRouter] Using tool calling mode")
+	return nil
 }
 
 func (p *Provider) applyStructuredOutput(params *sdk.ChatCompletionNewParams, options *llm.GenerationOptions) {
@@ -0,0 +1,57 @@
+package zai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/ulgerang/llm-module/llm"
+)
+
+// ListModels lists the models served by the Z.AI API from its OpenAI-compatible
+// /models endpoint. Token limits come from the model registry.
+func (p *Provider) ListModels(ctx context.Context) ([]llm.Model, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", p.baseURL+"/models", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+p.apiKey)
+
+	resp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, apiError(resp.StatusCode, respBody)
+	}
+
+	var list struct {
+		Data []struct {
+			ID      string `json:"id"`
+			Created int64  `json:"created"`
+			OwnedBy string `json:"owned_by"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(respBody, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	models := make([]llm.Model, 0, len(list.Data))
+	for _, entry := range list.Data {
+		model := llm.Model{ID: entry.ID, OwnedBy: entry.OwnedBy}
+		if entry.Created > 0 {
+			model.Created = time.Unix(entry.Created, 0)
+		}
+		models = append(models, llm.FillModelLimits(model))
+	}
+	return models, nil
+}
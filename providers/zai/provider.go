@@ -15,8 +15,11 @@ import (
 	"strings"
 	"time"
 
+	"github.com/ulgerang/llm-module/internal/openaicompat"
 	"github.com/ulgerang/llm-module/llm"
 	"github.com/ulgerang/llm-module/logger"
+	"github.com/ulgerang/llm-module/redact"
+	"github.com/ulgerang/llm-module/transport"
 	"github.com/ulgerang/llm-module/utils"
 )
 
@@ -25,6 +28,9 @@ const (
 	defaultBaseURL = "https://api.z.ai/api/coding/paas/v4"
 )
 
+// capabilities lists the features the Z.AI provider supports.
+var capabilities = llm.Capabilities{Streaming: true, Tools: true, StreamingTools: true, ParallelTools: true, StructuredOutput: llm.StructuredOutputPrompted}
+
 // Provider implements llm.Provider for Z.AI models using direct HTTP calls.
 type Provider struct {
 	httpClient *http.Client
@@ -32,6 +38,7 @@ type Provider struct {
 	baseURL    string
 	logger     logger.Logger
 	modelName  string
+	defaults   []llm.GenerationOption
 }
 
 // ChatRequest represents the Z.AI chat completion request.
@@ -45,6 +52,42 @@ type ChatRequest struct {
 	DoSample       *bool           `json:"do_sample,omitempty"`
 	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
 	Thinking       *ThinkingConfig `json:"thinking,omitempty"`
+	Tools          []ChatTool      `json:"tools,omitempty"`
+	ToolChoice     string          `json:"tool_choice,omitempty"`
+	UserID         string          `json:"user_id,omitempty"`
+	Stop           []string        `json:"stop,omitempty"`
+}
+
+// unsupportedOptions lists the options the Z.AI API has no parameter for: sampling
+// options and the cached content names of Gemini.
+var unsupportedOptions = []string{"Seed", "PresencePenalty", "FrequencyPenalty", "LogitBias", "Logprobs", "CandidateCount", "CachedContent"}
+
+// ChatTool represents a function tool definition.
+type ChatTool struct {
+	Type     string       `json:"type"`
+	Function ChatFunction `json:"function"`
+}
+
+// ChatFunction describes a function the model may call.
+type ChatFunction struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	Parameters  map[string]interface{} `json:"parameters"`
+}
+
+// ChatToolCall represents a tool call requested by the model. In streaming
+// responses Index identifies the call that a fragment belongs to.
+type ChatToolCall struct {
+	Index    *int                 `json:"index,omitempty"`
+	ID       string               `json:"id,omitempty"`
+	Type     string               `json:"type,omitempty"`
+	Function ChatToolCallFunction `json:"function"`
+}
+
+// ChatToolCallFunction holds the function name and JSON-encoded arguments of a tool call.
+type ChatToolCallFunction struct {
+	Name      string `json:"name,omitempty"`
+	Arguments string `json:"arguments"`
 }
 
 // ThinkingConfig specifies reasoning capabilities.
@@ -59,9 +102,11 @@ type ResponseFormat struct {
 
 // ChatMessage represents a message in the chat.
 type ChatMessage struct {
-	Role             string `json:"role"`
-	Content          string `json:"content"`
-	ReasoningContent string `json:"reasoning_content,omitempty"`
+	Role             string         `json:"role"`
+	Content          string         `json:"content"`
+	ReasoningContent string         `json:"reasoning_content,omitempty"`
+	ToolCalls        []ChatToolCall `json:"tool_calls,omitempty"`
+	ToolCallID       string         `json:"tool_call_id,omitempty"`
 }
 
 // ChatResponse represents the Z.AI chat completion response.
@@ -99,9 +144,10 @@ type StreamChunkChoice struct {
 
 // StreamDelta represents the delta content in streaming.
 type StreamDelta struct {
-	Role             string `json:"role,omitempty"`
-	Content          string `json:"content,omitempty"`
-	ReasoningContent string `json:"reasoning_content,omitempty"`
+	Role             string         `json:"role,omitempty"`
+	Content          string         `json:"content,omitempty"`
+	ReasoningContent string         `json:"reasoning_content,omitempty"`
+	ToolCalls        []ChatToolCall `json:"tool_calls,omitempty"`
 }
 
 // Usage represents token usage information.
@@ -117,23 +163,51 @@ type ErrorResponse struct {
 	Message string `json:"message"`
 }
 
+// apiError builds an *llm.APIError from a non-OK response. Z.AI returns either a flat
+// {code, message} object or an OpenAI-style nested error object.
+func apiError(status int, body []byte) *llm.APIError {
+	var errResp ErrorResponse
+	if json.Unmarshal(body, &errResp) == nil && (errResp.Code != "" || errResp.Message != "") {
+		return &llm.APIError{Provider: "zai", StatusCode: status, Type: errResp.Code, Message: errResp.Message}
+	}
+
+	var wrappedResp struct {
+		Error struct {
+			Code    interface{} `json:"code"`
+			Message string      `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(body, &wrappedResp) == nil && wrappedResp.Error.Message != "" {
+		apiErr := &llm.APIError{Provider: "zai", StatusCode: status, Message: wrappedResp.Error.Message}
+		if wrappedResp.Error.Code != nil {
+			apiErr.Type = fmt.Sprint(wrappedResp.Error.Code)
+		}
+		return apiErr
+	}
+
+	return &llm.APIError{Provider: "zai", StatusCode: status, Message: redact.String(strings.TrimSpace(string(body)))}
+}
+
 // New creates a new Z.AI provider instance using the default coding endpoint.
-func New(log logger.Logger, apiKey, modelName string) (*Provider, error) {
-	return newProvider(log, apiKey, modelName, defaultBaseURL)
+func New(log logger.Logger, apiKey, modelName string, opts ...llm.ProviderOption) (*Provider, error) {
+	return newProvider(log, apiKey, modelName, defaultBaseURL, opts...)
 }
 
 // NewWithBaseURL creates a new provider using a custom base URL.
-func NewWithBaseURL(log logger.Logger, apiKey, modelName, baseURL string) (*Provider, error) {
-	return newProvider(log, apiKey, modelName, baseURL)
+func NewWithBaseURL(log logger.Logger, apiKey, modelName, baseURL string, opts ...llm.ProviderOption) (*Provider, error) {
+	return newProvider(log, apiKey, modelName, baseURL, opts...)
 }
 
-func newProvider(log logger.Logger, apiKey, modelName, baseURL string) (*Provider, error) {
+func newProvider(log logger.Logger, apiKey, modelName, baseURL string, opts ...llm.ProviderOption) (*Provider, error) {
+	config := llm.ResolveProviderOptions(opts...)
+	apiKey, err := config.ResolveAPIKey(apiKey, "ZAI_API_KEY")
+	if err != nil {
+		return nil, err
+	}
 	if apiKey == "" {
-		apiKey = os.Getenv("ZAI_API_KEY")
-		if apiKey == "" {
-			return nil, errors.New("ZAI_API_KEY not provided")
-		}
+		return nil, errors.New("ZAI_API_KEY not provided")
 	}
+	redact.AddSecret(apiKey)
 
 	if modelName == "" {
 		modelName = os.Getenv("ZAI_MODEL")
@@ -148,7 +222,7 @@ func newProvider(log logger.Logger, apiKey, modelName, baseURL string) (*Provide
 
 	// Create HTTP client with generous timeouts for LLM API calls
 	// These APIs can be slow, especially for complex generation tasks
-	transport := &http.Transport{
+	httpTransport := &http.Transport{
 		DialContext: (&net.Dialer{
 			Timeout:   30 * time.Second, // Connection timeout
 			KeepAlive: 30 * time.Second,
@@ -164,20 +238,29 @@ func newProvider(log logger.Logger, apiKey, modelName, baseURL string) (*Provide
 		},
 	}
 
-	httpClient := &http.Client{
-		Transport: transport,
+	httpClient, err := transport.NewClient(config, &http.Client{
+		Transport: httpTransport,
 		Timeout:   600 * time.Second, // Overall request timeout (10 min for long generation)
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return &Provider{
 		httpClient: httpClient,
 		apiKey:     apiKey,
 		baseURL:    baseURL,
-		logger:     log,
+		logger:     logger.With(log, logger.Provider("zai"), logger.Model(modelName)),
 		modelName:  modelName,
+		defaults:   config.RequestDefaults(),
 	}, nil
 }
 
+// Capabilities reports the features of the provider and its model.
+func (p *Provider) Capabilities() llm.Capabilities {
+	return llm.ModelCapabilities(capabilities, p.modelName)
+}
+
 // GetModelName returns the configured model name.
 func (p *Provider) GetModelName() string {
 	return p.modelName
@@ -186,6 +269,9 @@ func (p *Provider) GetModelName() string {
 // GenerateText performs a non-streaming Z.AI request.
 func (p *Provider) GenerateText(ctx context.Context, prompt string, opts ...llm.GenerationOption) (string, *llm.UsageInfo, error) {
 	options := &llm.GenerationOptions{}
+	for _, opt := range p.defaults {
+		opt(options)
+	}
 	for _, opt := range opts {
 		opt(options)
 	}
@@ -194,16 +280,21 @@ func (p *Provider) GenerateText(ctx context.Context, prompt string, opts ...llm.
 
 	systemPrompt := p.composeSystemPrompt(options)
 
-	messages := []ChatMessage{}
-	if systemPrompt != "" {
-		messages = append(messages, ChatMessage{Role: "system", Content: systemPrompt})
+	if err := options.CheckUnsupported("zai", p.logger.Warning, unsupportedOptions...); err != nil {
+		return "", nil, err
+	}
+
+	messages, err := buildMessages(systemPrompt, options, prompt)
+	if err != nil {
+		return "", nil, err
 	}
-	messages = append(messages, ChatMessage{Role: "user", Content: prompt})
 
 	req := ChatRequest{
 		Model:    p.modelName,
 		Messages: messages,
 		// Stream:   false,  // Removed for ZAI API compatibility
+		UserID: options.Metadata[llm.MetadataUserID],
+		Stop:   options.StopSequences,
 	}
 
 	if options.ResponseSchema != nil || strings.Contains(strings.ToLower(options.ResponseFormat), "json") {
@@ -223,6 +314,11 @@ func (p *Provider) GenerateText(ctx context.Context, prompt string, opts ...llm.
 		req.TopP = &topP
 	}
 
+	if err := applyTools(&req, options); err != nil {
+		p.logger.Error("[ZAI] Failed to apply tools", err)
+		return "", nil, err
+	}
+
 	// Enable thinking for GLM-4.7 models by default
 	// Thinking mode uses reasoning tokens WITHIN max_tokens budget, so we must ensure
 	// sufficient tokens for BOTH reasoning (500-2000+) AND actual content output.
@@ -240,6 +336,9 @@ func (p *Provider) GenerateText(ctx context.Context, prompt string, opts ...llm.
 	if err != nil {
 		return "", nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
+	if body, err = llm.MergeExtraBody(body, options.ExtraBody); err != nil {
+		return "", nil, err
+	}
 
 	httpReq, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/chat/completions", bytes.NewReader(body))
 	if err != nil {
@@ -248,6 +347,9 @@ func (p *Provider) GenerateText(ctx context.Context, prompt string, opts ...llm.
 
 	httpReq.Header.Set("Content-Type", "application/json")
 	httpReq.Header.Set("Authorization", "Bearer "+p.apiKey)
+	for name, values := range options.Headers {
+		httpReq.Header[http.CanonicalHeaderKey(name)] = values
+	}
 
 	resp, err := p.httpClient.Do(httpReq)
 	if err != nil {
@@ -262,23 +364,7 @@ func (p *Provider) GenerateText(ctx context.Context, prompt string, opts ...llm.
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		var errResp ErrorResponse
-		if json.Unmarshal(respBody, &errResp) == nil && (errResp.Code != "" || errResp.Message != "") {
-			return "", nil, fmt.Errorf("Z.AI API error (code %s): %s", errResp.Code, errResp.Message)
-		}
-
-		// Check for nested error object (standard OpenAI format)
-		var wrappedResp struct {
-			Error struct {
-				Code    interface{} `json:"code"`
-				Message string      `json:"message"`
-			} `json:"error"`
-		}
-		if json.Unmarshal(respBody, &wrappedResp) == nil && wrappedResp.Error.Message != "" {
-			return "", nil, fmt.Errorf("Z.AI API error (code %v): %s", wrappedResp.Error.Code, wrappedResp.Error.Message)
-		}
-
-		return "", nil, fmt.Errorf("Z.AI API error: %s", string(respBody))
+		return "", nil, apiError(resp.StatusCode, respBody)
 	}
 
 	var chatResp ChatResponse
@@ -292,6 +378,18 @@ func (p *Provider) GenerateText(ctx context.Context, prompt string, opts ...llm.
 	}
 
 	message := chatResp.Choices[0].Message
+	if len(message.ToolCalls) > 0 {
+		p.logger.Infof("[ZAI] Received %d tool call(s)", len(message.ToolCalls))
+		toolCalls, err := llm.FormatToolCalls(convertToolCalls(message.ToolCalls))
+		if err != nil {
+			return "", nil, err
+		}
+		return toolCalls, &llm.UsageInfo{
+			InputTokens:  chatResp.Usage.PromptTokens,
+			OutputTokens: chatResp.Usage.CompletionTokens,
+		}, nil
+	}
+
 	generated := message.Content
 	// BUG FIX: Do NOT fall back to reasoning_content when content is empty.
 	// This was causing LLM "thinking" to be returned as actual output.
@@ -317,7 +415,7 @@ func (p *Provider) GenerateText(ctx context.Context, prompt string, opts ...llm.
 		OutputTokens: chatResp.Usage.CompletionTokens,
 	}
 
-	p.logger.Debug(fmt.Sprintf("Generated text (ZAI/%s): %s", p.modelName, generated))
+	logger.LogContent(p.logger, "[ZAI] Generated text", generated)
 	return generated, usage, nil
 }
 
@@ -330,22 +428,32 @@ func (p *Provider) GenerateTextStream(ctx context.Context, prompt string, outCha
 		MaxTokens:   llm.ValuePtr(int32(4096)),
 		System:      "You are a helpful assistant.",
 	}
+	for _, opt := range p.defaults {
+		opt(options)
+	}
 	for _, opt := range opts {
 		opt(options)
 	}
 
 	systemPrompt := p.composeSystemPrompt(options)
 
-	messages := []ChatMessage{}
-	if systemPrompt != "" {
-		messages = append(messages, ChatMessage{Role: "system", Content: systemPrompt})
+	if err := options.CheckUnsupported("zai", p.logger.Warning, unsupportedOptions...); err != nil {
+		outChan <- llm.StreamChunk{Err: err}
+		return nil, err
+	}
+
+	messages, err := buildMessages(systemPrompt, options, prompt)
+	if err != nil {
+		outChan <- llm.StreamChunk{Err: err}
+		return nil, err
 	}
-	messages = append(messages, ChatMessage{Role: "user", Content: prompt})
 
 	req := ChatRequest{
 		Model:    p.modelName,
 		Messages: messages,
 		Stream:   true, // Enable streaming for ZAI API
+		UserID:   options.Metadata[llm.MetadataUserID],
+		Stop:     options.StopSequences,
 	}
 
 	if options.ResponseSchema != nil || strings.Contains(strings.ToLower(options.ResponseFormat), "json") {
@@ -365,6 +473,12 @@ func (p *Provider) GenerateTextStream(ctx context.Context, prompt string, outCha
 		req.TopP = &topP
 	}
 
+	if err := applyTools(&req, options); err != nil {
+		p.logger.Error("[ZAI] Failed to apply tools", err)
+		outChan <- llm.StreamChunk{Err: err}
+		return nil, err
+	}
+
 	// Enable thinking for GLM-4.7 models by default
 	// Thinking mode uses reasoning tokens WITHIN max_tokens budget, so we must ensure
 	// sufficient tokens for BOTH reasoning (500-2000+) AND actual content output.
@@ -382,6 +496,9 @@ func (p *Provider) GenerateTextStream(ctx context.Context, prompt string, outCha
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
+	if body, err = llm.MergeExtraBody(body, options.ExtraBody); err != nil {
+		return nil, err
+	}
 
 	httpReq, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/chat/completions", bytes.NewReader(body))
 	if err != nil {
@@ -390,6 +507,9 @@ func (p *Provider) GenerateTextStream(ctx context.Context, prompt string, outCha
 
 	httpReq.Header.Set("Content-Type", "application/json")
 	httpReq.Header.Set("Authorization", "Bearer "+p.apiKey)
+	for name, values := range options.Headers {
+		httpReq.Header[http.CanonicalHeaderKey(name)] = values
+	}
 
 	resp, err := p.httpClient.Do(httpReq)
 	if err != nil {
@@ -401,31 +521,13 @@ func (p *Provider) GenerateTextStream(ctx context.Context, prompt string, outCha
 
 	if resp.StatusCode != http.StatusOK {
 		respBody, _ := io.ReadAll(resp.Body)
-		var errResp ErrorResponse
-		if json.Unmarshal(respBody, &errResp) == nil && (errResp.Code != "" || errResp.Message != "") {
-			err := fmt.Errorf("Z.AI API error (code %s): %s", errResp.Code, errResp.Message)
-			outChan <- llm.StreamChunk{Err: err}
-			return nil, err
-		}
-		// Check for nested error object
-		var wrappedResp struct {
-			Error struct {
-				Code    interface{} `json:"code"`
-				Message string      `json:"message"`
-			} `json:"error"`
-		}
-		if json.Unmarshal(respBody, &wrappedResp) == nil && wrappedResp.Error.Message != "" {
-			err := fmt.Errorf("Z.AI API error (code %v): %s", wrappedResp.Error.Code, wrappedResp.Error.Message)
-			outChan <- llm.StreamChunk{Err: err}
-			return nil, err
-		}
-
-		err := fmt.Errorf("Z.AI API error: %s", string(respBody))
+		err := apiError(resp.StatusCode, respBody)
 		outChan <- llm.StreamChunk{Err: err}
 		return nil, err
 	}
 
 	var usage *llm.UsageInfo
+	var toolCalls toolCallAccumulator
 	scanner := bufio.NewScanner(resp.Body)
 	// Increase buffer size to handle large responses (default 64K -> 10MB)
 	const maxTokenSize = 10 * 1024 * 1024 // 10MB
@@ -458,6 +560,7 @@ func (p *Provider) GenerateTextStream(ctx context.Context, prompt string, outCha
 
 		if len(chunk.Choices) > 0 {
 			delta := chunk.Choices[0].Delta
+			toolCalls.add(delta.ToolCalls)
 			// BUG FIX: Only output actual content, NOT reasoning_content.
 			// ZAI's GLM model sends reasoning_content first, then content.
 			// Previously we were outputting both, which leaked LLM "thinking" to output.
@@ -488,6 +591,11 @@ func (p *Provider) GenerateTextStream(ctx context.Context, prompt string, outCha
 		return nil, err
 	}
 
+	if calls := toolCalls.toolCalls(); len(calls) > 0 {
+		p.logger.Infof("[ZAI] Received %d tool call(s)", len(calls))
+		outChan <- llm.StreamChunk{ToolCalls: calls}
+	}
+
 	outChan <- llm.StreamChunk{IsFinal: true}
 	return usage, nil
 }
@@ -498,6 +606,132 @@ func (p *Provider) Close() error {
 	return nil
 }
 
+// buildMessages converts the system prompt, conversation history and prompt into chat messages.
+func buildMessages(systemPrompt string, options *llm.GenerationOptions, prompt string) ([]ChatMessage, error) {
+	messages := []ChatMessage{}
+	if systemPrompt != "" {
+		messages = append(messages, ChatMessage{Role: "system", Content: systemPrompt})
+	}
+
+	for _, message := range llm.ConversationMessages(options, prompt) {
+		switch message.Role {
+		case llm.RoleUser:
+			messages = append(messages, ChatMessage{Role: "user", Content: message.Content})
+		case llm.RoleAssistant:
+			chatMessage := ChatMessage{Role: "assistant", Content: message.Content}
+			for _, call := range message.ToolCalls {
+				arguments := string(call.Input)
+				if arguments == "" {
+					arguments = "{}"
+				}
+				chatMessage.ToolCalls = append(chatMessage.ToolCalls, ChatToolCall{
+					ID:       call.ID,
+					Type:     "function",
+					Function: ChatToolCallFunction{Name: call.Name, Arguments: arguments},
+				})
+			}
+			messages = append(messages, chatMessage)
+		case llm.RoleTool:
+			messages = append(messages, ChatMessage{Role: "tool", Content: message.Content, ToolCallID: message.ToolCallID})
+		default:
+			return nil, fmt.Errorf("unsupported message role '%s'", message.Role)
+		}
+	}
+	if len(options.Messages) == 0 && prompt == "" {
+		messages = append(messages, ChatMessage{Role: "user", Content: prompt})
+	}
+	return messages, nil
+}
+
+// applyTools sets the tool definitions on a request. Z.AI only supports automatic tool
+// choice, so forcing or naming a tool is rejected and "none" simply omits the tools.
+func applyTools(req *ChatRequest, options *llm.GenerationOptions) error {
+	if err := llm.ValidateToolChoice(options); err != nil {
+		return err
+	}
+	if options.ParallelToolCalls != nil && !*options.ParallelToolCalls {
+		return llm.NewUnsupportedOptionError("zai", "ParallelToolCalls", "Z.AI cannot disable parallel tool calls")
+	}
+	if len(options.Tools) == 0 {
+		return nil
+	}
+	if options.ToolChoice != nil {
+		switch options.ToolChoice.Mode {
+		case llm.ToolChoiceModeNone:
+			return nil
+		case llm.ToolChoiceModeRequired, llm.ToolChoiceModeSpecific:
+			return llm.NewUnsupportedOptionError("zai", "ToolChoice", "Z.AI only supports automatic tool choice")
+		}
+	}
+
+	for _, tool := range options.Tools {
+		parameters := map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+		if tool.InputSchema != nil {
+			converted, err := llm.ConvertSchemaToMap(tool.InputSchema)
+			if err != nil {
+				return fmt.Errorf("failed to convert schema for tool '%s': %w", tool.Name, err)
+			}
+			parameters = converted
+		}
+		req.Tools = append(req.Tools, ChatTool{
+			Type:     "function",
+			Function: ChatFunction{Name: tool.Name, Description: tool.Description, Parameters: parameters},
+		})
+	}
+	req.ToolChoice = "auto"
+	return nil
+}
+
+func convertToolCalls(calls []ChatToolCall) []llm.ToolCall {
+	result := make([]llm.ToolCall, 0, len(calls))
+	for _, call := range calls {
+		result = append(result, llm.ToolCall{ID: call.ID, Name: call.Function.Name, Input: openaicompat.ToolArguments(call.Function.Arguments)})
+	}
+	return result
+}
+
+// toolCallAccumulator assembles tool calls from streamed deltas, keyed by their index.
+type toolCallAccumulator struct {
+	calls   []*ChatToolCall
+	byIndex map[int]*ChatToolCall
+}
+
+func (a *toolCallAccumulator) add(deltas []ChatToolCall) {
+	for _, delta := range deltas {
+		index := len(a.calls)
+		if delta.Index != nil {
+			index = *delta.Index
+		}
+		if a.byIndex == nil {
+			a.byIndex = make(map[int]*ChatToolCall)
+		}
+		call, ok := a.byIndex[index]
+		if !ok {
+			call = &ChatToolCall{}
+			a.byIndex[index] = call
+			a.calls = append(a.calls, call)
+		}
+		if delta.ID != "" {
+			call.ID = delta.ID
+		}
+		if delta.Function.Name != "" {
+			call.Function.Name = delta.Function.Name
+		}
+		call.Function.Arguments += delta.Function.Arguments
+	}
+}
+
+func (a *toolCallAccumulator) toolCalls() []llm.ToolCall {
+	if len(a.calls) == 0 {
+		return nil
+	}
+	calls := make([]ChatToolCall, 0, len(a.calls))
+	for _, call := range a.calls {
+		calls = append(calls, *call)
+	}
+	return convertToolCalls(calls)
+}
+
 func (p *Provider) composeSystemPrompt(options *llm.GenerationOptions) string {
 	var builder strings.Builder
 
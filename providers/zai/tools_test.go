@@ -0,0 +1,151 @@
+package zai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ulgerang/llm-module/llm"
+)
+
+var weatherTool = &llm.Tool{
+	Name:        "get_weather",
+	Description: "Look up the weather",
+	InputSchema: &llm.SchemaProperty{
+		Type:       "object",
+		Properties: map[string]*llm.SchemaProperty{"city": {Type: "string"}},
+		Required:   []string{"city"},
+	},
+}
+
+// stubServer records the last request body and replies with the given payload.
+func stubServer(t *testing.T, contentType, payload string, body *string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		*body = string(data)
+		w.Header().Set("Content-Type", contentType)
+		fmt.Fprint(w, payload)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestGenerateTextToolCallWireFormat(t *testing.T) {
+	var body string
+	server := stubServer(t, "application/json", `{
+		"id": "1", "created": 1, "model": "glm-4.6",
+		"choices": [{"index": 0, "finish_reason": "tool_calls", "message": {"role": "assistant", "content": "",
+			"tool_calls": [{"id": "call_2", "type": "function", "function": {"name": "get_weather", "arguments": "{\"city\":\"Busan\"}"}}]}}],
+		"usage": {"prompt_tokens": 12, "completion_tokens": 5, "total_tokens": 17}
+	}`, &body)
+
+	provider, err := NewWithBaseURL(&testLogger{t: t}, "test-key", "glm-4.6", server.URL)
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+
+	history := []llm.Message{
+		llm.UserMessage("Weather in Seoul?"),
+		llm.AssistantToolCallMessage([]llm.ToolCall{{ID: "call_1", Name: "get_weather", Input: json.RawMessage(`{"city":"Seoul"}`)}}),
+		llm.ToolResultMessage(llm.ToolResult{ToolCallID: "call_1", Name: "get_weather", Content: "sunny"}),
+	}
+	result, usage, err := provider.GenerateText(context.Background(), "And in Busan?",
+		llm.WithTools([]*llm.Tool{weatherTool}),
+		llm.WithMessages(history),
+	)
+	if err != nil {
+		t.Fatalf("GenerateText failed: %v", err)
+	}
+
+	for _, want := range []string{
+		`"tools":[{"type":"function","function":{"name":"get_weather","description":"Look up the weather","parameters":{`,
+		`"tool_choice":"auto"`,
+		`{"role":"assistant","content":"","tool_calls":[{"id":"call_1","type":"function","function":{"name":"get_weather","arguments":"{\"city\":\"Seoul\"}"}}]}`,
+		`{"role":"tool","content":"sunny","tool_call_id":"call_1"}`,
+		`{"role":"user","content":"And in Busan?"}`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("request body missing %s\nbody: %s", want, body)
+		}
+	}
+
+	calls, ok, err := llm.ParseToolCalls(result)
+	if err != nil || !ok {
+		t.Fatalf("expected tool call result, got %q (err=%v)", result, err)
+	}
+	if len(calls) != 1 || calls[0].ID != "call_2" || string(calls[0].Input) != `{"city":"Busan"}` {
+		t.Fatalf("unexpected tool calls: %+v", calls)
+	}
+	if usage == nil || usage.InputTokens != 12 || usage.OutputTokens != 5 {
+		t.Fatalf("unexpected usage: %+v", usage)
+	}
+}
+
+func TestGenerateTextStreamToolCalls(t *testing.T) {
+	chunks := []string{
+		`{"id":"c","created":1,"model":"glm-4.6","choices":[{"index":0,"delta":{"role":"assistant","tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"get_weather","arguments":"{\"city\":"}}]}}]}`,
+		`{"id":"c","created":1,"model":"glm-4.6","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"Seoul\"}"}}]}}]}`,
+		`{"id":"c","created":1,"model":"glm-4.6","choices":[{"index":0,"delta":{},"finish_reason":"tool_calls"}],"usage":{"prompt_tokens":9,"completion_tokens":4,"total_tokens":13}}`,
+	}
+	var payload strings.Builder
+	for _, chunk := range chunks {
+		payload.WriteString("data: " + chunk + "\n\n")
+	}
+	payload.WriteString("data: [DONE]\n\n")
+
+	var body string
+	server := stubServer(t, "text/event-stream", payload.String(), &body)
+
+	provider, err := NewWithBaseURL(&testLogger{t: t}, "test-key", "glm-4.6", server.URL)
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+
+	out := make(chan llm.StreamChunk, 16)
+	usage, err := provider.GenerateTextStream(context.Background(), "Weather in Seoul?", out, llm.WithTools([]*llm.Tool{weatherTool}))
+	if err != nil {
+		t.Fatalf("GenerateTextStream failed: %v", err)
+	}
+
+	var calls []llm.ToolCall
+	for chunk := range out {
+		if chunk.Err != nil {
+			t.Fatalf("unexpected stream error: %v", chunk.Err)
+		}
+		calls = append(calls, chunk.ToolCalls...)
+	}
+
+	if !strings.Contains(body, `"tools":[{"type":"function"`) {
+		t.Errorf("streaming request missing tools: %s", body)
+	}
+	if len(calls) != 1 || calls[0].ID != "call_1" || string(calls[0].Input) != `{"city":"Seoul"}` {
+		t.Fatalf("unexpected streamed tool calls: %+v", calls)
+	}
+	if usage == nil || usage.InputTokens != 9 {
+		t.Fatalf("unexpected usage: %+v", usage)
+	}
+}
+
+func TestApplyToolsRejectsForcedChoice(t *testing.T) {
+	options := &llm.GenerationOptions{}
+	llm.WithTools([]*llm.Tool{weatherTool})(options)
+	llm.WithToolChoice(llm.ToolChoiceRequired)(options)
+
+	err := applyTools(&ChatRequest{}, options)
+	if !errors.Is(err, llm.ErrUnsupportedOption) {
+		t.Fatalf("expected unsupported option error, got %v", err)
+	}
+
+	req := &ChatRequest{}
+	llm.WithToolChoice(llm.ToolChoiceNone)(options)
+	if err := applyTools(req, options); err != nil || len(req.Tools) != 0 {
+		t.Fatalf("expected tools to be omitted for tool choice none, got %+v (err=%v)", req.Tools, err)
+	}
+}
@@ -0,0 +1,233 @@
+// Package redact scrubs API keys, bearer tokens and configurable PII patterns from
+// strings, headers and HTTP dumps before they reach logs or error messages.
+package redact
+
+import (
+	"net/http"
+	"net/http/httputil"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Placeholder replaces redacted values.
+const Placeholder = "[REDACTED]"
+
+// Built-in PII patterns that are not enabled by default; add them with AddPattern.
+var (
+	EmailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	PhonePattern = regexp.MustCompile(`\+?\d[\d ().-]{7,}\d`)
+)
+
+// sensitiveHeaders are replaced by Headers and DumpRequest, compared case-insensitively.
+var sensitiveHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"X-Api-Key",
+	"Api-Key",
+	"X-Goog-Api-Key",
+	"Cookie",
+	"Set-Cookie",
+}
+
+type rule struct {
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// defaultRules match credentials by shape, so keys never registered with AddSecret are
+// still caught.
+var defaultRules = []rule{
+	// Bearer and Basic credentials, e.g. in an echoed Authorization header.
+	{regexp.MustCompile(`(?i)\b(bearer|basic)\s+[A-Za-z0-9._~+/=-]{8,}`), "$1 " + Placeholder},
+	// key=value and "key": "value" pairs with a credential-like key.
+	// The key must start a word, so "invalid_api_key: Incorrect..." is left alone.
+	{regexp.MustCompile(`(?i)(^|[^A-Za-z0-9_-])("?(?:x-)?(?:api[_-]?key|x-goog-api-key|access[_-]?token|auth[_-]?token|client[_-]?secret|secret|password|authorization)"?\s*[:=]\s*"?(?:(?:bearer|basic)\s+)?)([^\s"'&,;}]+)`), "${1}${2}" + Placeholder},
+	// Vendor key formats: OpenAI and Anthropic (sk-), Google (AIza), Groq (gsk_),
+	// xAI (xai-), Cerebras (csk-) and OpenRouter (sk-or-).
+	{regexp.MustCompile(`\b(?:sk-[A-Za-z0-9_-]{16,}|AIza[0-9A-Za-z_-]{30,}|gsk_[A-Za-z0-9]{20,}|xai-[A-Za-z0-9]{20,}|csk-[A-Za-z0-9]{20,})`), Placeholder},
+}
+
+// Redactor scrubs secrets from text. It is safe for concurrent use.
+type Redactor struct {
+	mu      sync.RWMutex
+	rules   []rule
+	secrets []string
+	// counts holds how many times each secret was added and not yet removed.
+	counts map[string]int
+}
+
+// New creates a Redactor with the default credential rules.
+func New() *Redactor {
+	return &Redactor{rules: append([]rule(nil), defaultRules...)}
+}
+
+// Default is the Redactor used by the package-level functions, the logger adapters
+// and llm.APIError.
+var Default = New()
+
+// AddPattern redacts every match of pattern, replaced by replacement, which may refer
+// to submatches as in regexp.ReplaceAllString. An empty replacement uses Placeholder.
+func (r *Redactor) AddPattern(pattern *regexp.Regexp, replacement string) {
+	if replacement == "" {
+		replacement = Placeholder
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rules = append(r.rules, rule{pattern: pattern, replacement: replacement})
+}
+
+// AddSecret redacts every occurrence of secret, such as a configured API key.
+// Values shorter than 8 characters are ignored to avoid scrubbing ordinary words.
+func (r *Redactor) AddSecret(secret string) {
+	if len(secret) < 8 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.counts == nil {
+		r.counts = make(map[string]int)
+	}
+	r.counts[secret]++
+	if r.counts[secret] > 1 {
+		return
+	}
+	r.secrets = append(r.secrets, secret)
+	// Longer secrets first, so a secret containing another is replaced whole.
+	sort.Slice(r.secrets, func(i, j int) bool { return len(r.secrets[i]) > len(r.secrets[j]) })
+}
+
+// RemoveSecret undoes one AddSecret call for secret, such as a key that was rotated
+// out. The secret is no longer redacted once every AddSecret call has been undone.
+func (r *Redactor) RemoveSecret(secret string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	count, ok := r.counts[secret]
+	if !ok {
+		return
+	}
+	if count > 1 {
+		r.counts[secret] = count - 1
+		return
+	}
+	delete(r.counts, secret)
+	for i, existing := range r.secrets {
+		if existing == secret {
+			r.secrets = append(r.secrets[:i], r.secrets[i+1:]...)
+			break
+		}
+	}
+}
+
+// String returns s with secrets and pattern matches replaced.
+func (r *Redactor) String(s string) string {
+	if s == "" {
+		return s
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, secret := range r.secrets {
+		s = strings.ReplaceAll(s, secret, Placeholder)
+	}
+	for _, rule := range r.rules {
+		s = rule.pattern.ReplaceAllString(s, rule.replacement)
+	}
+	return s
+}
+
+// Headers returns a copy of h with sensitive header values replaced and the remaining
+// values scrubbed.
+func (r *Redactor) Headers(h http.Header) http.Header {
+	redacted := make(http.Header, len(h))
+	for name, values := range h {
+		copied := make([]string, len(values))
+		for i, value := range values {
+			if isSensitiveHeader(name) {
+				copied[i] = Placeholder
+			} else {
+				copied[i] = r.String(value)
+			}
+		}
+		redacted[name] = copied
+	}
+	return redacted
+}
+
+// DumpRequest dumps an outgoing request like httputil.DumpRequestOut, with sensitive
+// headers replaced and the dump scrubbed.
+func (r *Redactor) DumpRequest(req *http.Request, body bool) (string, error) {
+	clone := req.Clone(req.Context())
+	clone.Header = r.Headers(req.Header)
+	if body && req.GetBody != nil {
+		b, err := req.GetBody()
+		if err != nil {
+			return "", err
+		}
+		clone.Body = b
+	} else {
+		body = false
+	}
+	dump, err := httputil.DumpRequestOut(clone, body)
+	if err != nil {
+		return "", err
+	}
+	return r.String(string(dump)), nil
+}
+
+// DumpResponse dumps a response like httputil.DumpResponse, with sensitive headers
+// replaced and the dump scrubbed. The body is restored so it can still be read.
+func (r *Redactor) DumpResponse(resp *http.Response, body bool) (string, error) {
+	header := resp.Header
+	resp.Header = r.Headers(header)
+	dump, err := httputil.DumpResponse(resp, body)
+	resp.Header = header
+	if err != nil {
+		return "", err
+	}
+	return r.String(string(dump)), nil
+}
+
+func isSensitiveHeader(name string) bool {
+	for _, sensitive := range sensitiveHeaders {
+		if strings.EqualFold(name, sensitive) {
+			return true
+		}
+	}
+	return false
+}
+
+// String scrubs s with the Default redactor.
+func String(s string) string {
+	return Default.String(s)
+}
+
+// AddSecret registers a secret with the Default redactor.
+func AddSecret(secret string) {
+	Default.AddSecret(secret)
+}
+
+// RemoveSecret unregisters a secret from the Default redactor.
+func RemoveSecret(secret string) {
+	Default.RemoveSecret(secret)
+}
+
+// AddPattern registers a pattern with the Default redactor.
+func AddPattern(pattern *regexp.Regexp, replacement string) {
+	Default.AddPattern(pattern, replacement)
+}
+
+// Headers scrubs h with the Default redactor.
+func Headers(h http.Header) http.Header {
+	return Default.Headers(h)
+}
+
+// DumpRequest dumps req with the Default redactor.
+func DumpRequest(req *http.Request, body bool) (string, error) {
+	return Default.DumpRequest(req, body)
+}
+
+// DumpResponse dumps resp with the Default redactor.
+func DumpResponse(resp *http.Response, body bool) (string, error) {
+	return Default.DumpResponse(resp, body)
+}
@@ -0,0 +1,278 @@
+// Package routing maps logical model names such as "fast", "smart" or "cheap-json" to
+// concrete providers and models. A Router is an llm.Provider: the model named with
+// llm.WithModel is looked up in its table, and a target of the route is picked by
+// weight. Tables are built from the routes of a providers.yaml file and can be swapped
+// while the router is in use, so remapping an alias needs no code change or restart:
+//
+//	router, err := routing.FromConfig(log, cfg)
+//	text, _, err := router.GenerateText(ctx, prompt, llm.WithModel("smart"))
+package routing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sort"
+	"sync"
+
+	"github.com/ulgerang/llm-module/config"
+	"github.com/ulgerang/llm-module/llm"
+	"github.com/ulgerang/llm-module/logger"
+)
+
+// ErrUnknownRoute is matched by errors.Is when a call names a model without a route.
+var ErrUnknownRoute = errors.New("unknown route")
+
+// Target is a provider a route sends calls to, with its share of the route's traffic.
+type Target struct {
+	Provider llm.Provider
+	// Model is the model of Provider. Providers serve the model they were created
+	// with, so NewTable fills an empty Model from Provider.GetModelName and rejects a
+	// different one.
+	Model  string
+	Weight int
+}
+
+// Table maps route names to their targets.
+type Table struct {
+	routes       map[string][]Target
+	defaultRoute string
+	// calls counts the calls routers have in progress on the table's providers.
+	calls sync.WaitGroup
+}
+
+// NewTable returns a table of routes. defaultRoute, which may be empty, serves calls
+// that do not name a model.
+func NewTable(routes map[string][]Target, defaultRoute string) (*Table, error) {
+	checked := make(map[string][]Target, len(routes))
+	for name, targets := range routes {
+		total := 0
+		for _, target := range targets {
+			if target.Provider == nil {
+				return nil, fmt.Errorf("route %q has a target without a provider", name)
+			}
+			if target.Weight < 0 {
+				return nil, fmt.Errorf("route %q has a negative weight", name)
+			}
+			model := target.Provider.GetModelName()
+			if target.Model == "" {
+				target.Model = model
+			} else if target.Model != model {
+				return nil, fmt.Errorf("route %q targets model %q on a provider that serves %q", name, target.Model, model)
+			}
+			total += target.Weight
+			checked[name] = append(checked[name], target)
+		}
+		if total == 0 {
+			return nil, fmt.Errorf("route %q needs a target with a positive weight", name)
+		}
+	}
+	if _, ok := routes[defaultRoute]; defaultRoute != "" && !ok {
+		return nil, fmt.Errorf("default route %q is not defined", defaultRoute)
+	}
+	return &Table{routes: checked, defaultRoute: defaultRoute}, nil
+}
+
+// TableFromConfig creates the providers of the routes in cfg and returns their table.
+// opts are applied to every provider.
+func TableFromConfig(log logger.Logger, cfg *config.Config, opts ...llm.ProviderOption) (*Table, error) {
+	routes := make(map[string][]Target, len(cfg.Routes))
+	for name, targets := range cfg.Routes {
+		for i, route := range targets {
+			provider, err := cfg.NewProfileProvider(log, &route.Profile, opts...)
+			if err != nil {
+				closeRoutes(routes)
+				return nil, fmt.Errorf("routes.%s[%d]: %w", name, i, err)
+			}
+			weight := 1
+			if route.Weight != nil {
+				weight = *route.Weight
+			}
+			routes[name] = append(routes[name], Target{Provider: provider, Weight: weight})
+		}
+	}
+	table, err := NewTable(routes, cfg.DefaultRoute)
+	if err != nil {
+		closeRoutes(routes)
+		return nil, err
+	}
+	return table, nil
+}
+
+// Routes returns the route names, sorted.
+func (t *Table) Routes() []string {
+	names := make([]string, 0, len(t.routes))
+	for name := range t.routes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Pick returns a target of the route, chosen with a probability proportional to its
+// weight. An empty name picks from the default route.
+func (t *Table) Pick(name string) (Target, error) {
+	if name == "" {
+		name = t.defaultRoute
+		if name == "" {
+			return Target{}, fmt.Errorf("%w: no model given and no default route", ErrUnknownRoute)
+		}
+	}
+	targets, ok := t.routes[name]
+	if !ok {
+		return Target{}, fmt.Errorf("%w %q", ErrUnknownRoute, name)
+	}
+	total := 0
+	for _, target := range targets {
+		total += target.Weight
+	}
+	n := rand.IntN(total)
+	for _, target := range targets {
+		if n < target.Weight {
+			return target, nil
+		}
+		n -= target.Weight
+	}
+	return targets[len(targets)-1], nil
+}
+
+// Close closes the providers of every route.
+func (t *Table) Close() error {
+	return closeRoutes(t.routes)
+}
+
+// CloseWhenIdle waits for the calls routers have in progress on the table to finish,
+// then closes it. Call it on a table that Swap replaced, once no router uses it.
+func (t *Table) CloseWhenIdle() error {
+	t.calls.Wait()
+	return t.Close()
+}
+
+func closeRoutes(routes map[string][]Target) error {
+	var errs []error
+	for _, targets := range routes {
+		for _, target := range targets {
+			errs = append(errs, target.Provider.Close())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Router is an llm.Provider that sends each call to a target of the route named by
+// the call's model.
+type Router struct {
+	mu    sync.RWMutex
+	table *Table
+}
+
+// New returns a router over table.
+func New(table *Table) *Router {
+	return &Router{table: table}
+}
+
+// FromConfig returns a router over the routes in cfg.
+func FromConfig(log logger.Logger, cfg *config.Config, opts ...llm.ProviderOption) (*Router, error) {
+	table, err := TableFromConfig(log, cfg, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return New(table), nil
+}
+
+// Swap replaces the routing table and returns the previous one. Calls in progress
+// finish on the previous table's providers, so close it with CloseWhenIdle.
+func (r *Router) Swap(table *Table) *Table {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	previous := r.table
+	r.table = table
+	return previous
+}
+
+// Watch rebuilds the routing table whenever w loads a new configuration. A table that
+// cannot be built is reported to log and the current one is kept. A replaced table is
+// closed once the calls in progress on it have finished.
+func (r *Router) Watch(log logger.Logger, w *config.Watcher, opts ...llm.ProviderOption) {
+	w.OnChange(func(cfg *config.Config) {
+		table, err := TableFromConfig(log, cfg, opts...)
+		if err != nil {
+			log.Error("[Routing] Keeping the current routes", err)
+			return
+		}
+		previous := r.Swap(table)
+		log.Infof("[Routing] Loaded routes %v", table.Routes())
+		go func() {
+			if err := previous.CloseWhenIdle(); err != nil {
+				log.Error("[Routing] Failed to close the replaced routes", err)
+			}
+		}()
+	})
+}
+
+// Table returns the current routing table.
+func (r *Router) Table() *Table {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.table
+}
+
+// Resolve returns the target a call for model would be sent to.
+func (r *Router) Resolve(model string) (Target, error) {
+	return r.Table().Pick(model)
+}
+
+// route picks the target of a call and registers the call with the current table;
+// done must be called when the call ends. The options passed on name the target's
+// model instead of the route, for middleware such as metrics and caches.
+func (r *Router) route(opts []llm.GenerationOption) (target Target, routed []llm.GenerationOption, done func(), err error) {
+	var model string
+	if options := llm.ResolveOptions(opts...); options.Model != nil {
+		model = *options.Model
+	}
+	r.mu.RLock()
+	table := r.table
+	table.calls.Add(1)
+	r.mu.RUnlock()
+
+	target, err = table.Pick(model)
+	if err != nil {
+		table.calls.Done()
+		return Target{}, nil, nil, err
+	}
+	return target, append(append([]llm.GenerationOption(nil), opts...), llm.WithModel(target.Model)), table.calls.Done, nil
+}
+
+// GenerateText sends the call to a target of the route named with llm.WithModel, or
+// of the default route.
+func (r *Router) GenerateText(ctx context.Context, prompt string, opts ...llm.GenerationOption) (string, *llm.UsageInfo, error) {
+	target, opts, done, err := r.route(opts)
+	if err != nil {
+		return "", nil, err
+	}
+	defer done()
+	return target.Provider.GenerateText(ctx, prompt, opts...)
+}
+
+// GenerateTextStream streams the call from a target of the route named with
+// llm.WithModel, or of the default route.
+func (r *Router) GenerateTextStream(ctx context.Context, prompt string, outChan chan<- llm.StreamChunk, opts ...llm.GenerationOption) (*llm.UsageInfo, error) {
+	target, opts, done, err := r.route(opts)
+	if err != nil {
+		outChan <- llm.StreamChunk{Err: err}
+		close(outChan)
+		return nil, err
+	}
+	defer done()
+	return target.Provider.GenerateTextStream(ctx, prompt, outChan, opts...)
+}
+
+// GetModelName returns the name of the default route.
+func (r *Router) GetModelName() string {
+	return r.Table().defaultRoute
+}
+
+// Close closes the providers of the current table.
+func (r *Router) Close() error {
+	return r.Table().Close()
+}
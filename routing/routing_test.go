@@ -0,0 +1,164 @@
+package routing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ulgerang/llm-module/config"
+	"github.com/ulgerang/llm-module/llm"
+	"github.com/ulgerang/llm-module/logger"
+)
+
+// stubProvider serves one model, like the real providers: it ignores llm.WithModel.
+type stubProvider struct {
+	name  string
+	model string
+	// started and release, when set, hold GenerateText until release is closed.
+	started chan struct{}
+	release chan struct{}
+	closed  atomic.Bool
+}
+
+func (p *stubProvider) GenerateText(ctx context.Context, prompt string, opts ...llm.GenerationOption) (string, *llm.UsageInfo, error) {
+	if p.release != nil {
+		close(p.started)
+		<-p.release
+	}
+	return p.name, &llm.UsageInfo{}, nil
+}
+
+func (p *stubProvider) GenerateTextStream(ctx context.Context, prompt string, outChan chan<- llm.StreamChunk, opts ...llm.GenerationOption) (*llm.UsageInfo, error) {
+	defer close(outChan)
+	outChan <- llm.StreamChunk{Delta: p.name, IsFinal: true}
+	return &llm.UsageInfo{}, nil
+}
+
+func (p *stubProvider) GetModelName() string { return p.model }
+func (p *stubProvider) Close() error         { p.closed.Store(true); return nil }
+
+func TestRouterResolvesAliasesAndSwaps(t *testing.T) {
+	fast := &stubProvider{name: "fast", model: "gpt-4o-mini"}
+	smart := &stubProvider{name: "smart", model: "claude-opus-4"}
+	spare := &stubProvider{name: "spare", model: "gpt-4.1"}
+	table, err := NewTable(map[string][]Target{
+		"fast":  {{Provider: fast, Weight: 1}},
+		"smart": {{Provider: smart, Model: "claude-opus-4", Weight: 1}, {Provider: spare, Weight: 0}},
+	}, "fast")
+	if err != nil {
+		t.Fatalf("NewTable() error = %v", err)
+	}
+	router := New(table)
+
+	if text, _, err := router.GenerateText(context.Background(), "hi", llm.WithModel("smart")); err != nil || text != "smart" {
+		t.Fatalf("GenerateText(smart) = %q, %v", text, err)
+	}
+	if target, _ := router.Resolve("fast"); target.Model != "gpt-4o-mini" {
+		t.Errorf("an empty target model should default to the provider's, got %q", target.Model)
+	}
+	if text, _, _ := router.GenerateText(context.Background(), "hi"); text != "fast" {
+		t.Errorf("a call without a model should use the default route, got %q", text)
+	}
+	if _, _, err := router.GenerateText(context.Background(), "hi", llm.WithModel("cheap-json")); !errors.Is(err, ErrUnknownRoute) {
+		t.Errorf("expected ErrUnknownRoute, got %v", err)
+	}
+
+	swapped, _ := NewTable(map[string][]Target{"smart": {{Provider: spare, Weight: 1}}}, "")
+	if previous := router.Swap(swapped); previous != table {
+		t.Error("Swap should return the previous table")
+	}
+	out := make(chan llm.StreamChunk, 1)
+	if _, err := router.GenerateTextStream(context.Background(), "hi", out, llm.WithModel("smart")); err != nil {
+		t.Fatalf("GenerateTextStream() error = %v", err)
+	}
+	if chunk := <-out; chunk.Delta != "spare" {
+		t.Errorf("expected the swapped route, got %q", chunk.Delta)
+	}
+}
+
+func TestNewTableRejectsModelTheProviderDoesNotServe(t *testing.T) {
+	provider := &stubProvider{name: "fast", model: "gpt-4o-mini"}
+	if _, err := NewTable(map[string][]Target{"fast": {{Provider: provider, Model: "gpt-4o", Weight: 1}}}, ""); err == nil {
+		t.Error("expected an error for a target model the provider does not serve")
+	}
+}
+
+func TestCloseWhenIdleWaitsForCallsInProgress(t *testing.T) {
+	busy := &stubProvider{name: "busy", model: "m", started: make(chan struct{}), release: make(chan struct{})}
+	table, err := NewTable(map[string][]Target{"fast": {{Provider: busy, Weight: 1}}}, "fast")
+	if err != nil {
+		t.Fatalf("NewTable() error = %v", err)
+	}
+	router := New(table)
+
+	called := make(chan struct{})
+	go func() {
+		defer close(called)
+		router.GenerateText(context.Background(), "hi")
+	}()
+	<-busy.started
+
+	replacement, _ := NewTable(map[string][]Target{"fast": {{Provider: &stubProvider{name: "new", model: "m"}, Weight: 1}}}, "fast")
+	closed := make(chan error)
+	go func() { closed <- router.Swap(replacement).CloseWhenIdle() }()
+
+	select {
+	case <-closed:
+		t.Fatal("the replaced table was closed while a call was in progress")
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(busy.release)
+	<-called
+	if err := <-closed; err != nil || !busy.closed.Load() {
+		t.Errorf("CloseWhenIdle() = %v, closed = %v", err, busy.closed.Load())
+	}
+}
+
+func TestTableFromConfig(t *testing.T) {
+	var model string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Model string `json:"model"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		model = body.Model
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id":"c1","object":"chat.completion","model":"gpt-test","choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":"Hi."}}]}`)
+	}))
+	defer server.Close()
+
+	cfg, err := config.Parse([]byte(`
+providers:
+  openai: {api_key: test-key, base_url: "` + server.URL + `"}
+default_route: smart
+routes:
+  smart:
+    - {provider: openai, model: gpt-smart, generation: {temperature: 0.1}}
+    - {provider: openai, model: gpt-unused, weight: 0}
+`))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	router, err := FromConfig(logger.Nop(), cfg)
+	if err != nil {
+		t.Fatalf("FromConfig() error = %v", err)
+	}
+	defer router.Close()
+
+	if _, _, err := router.GenerateText(context.Background(), "Hello", llm.WithModel("smart")); err != nil {
+		t.Fatalf("GenerateText() error = %v", err)
+	}
+	if model != "gpt-smart" {
+		t.Errorf("expected the routed model, got %q", model)
+	}
+
+	if _, err := config.Parse([]byte("routes:\n  smart:\n    - {provider: openai, weight: 0}\n")); err == nil {
+		t.Error("a route without a positive weight should not validate")
+	}
+}
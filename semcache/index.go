@@ -0,0 +1,148 @@
+package semcache
+
+import (
+	"container/list"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/ulgerang/llm-module/cache"
+)
+
+// Match is the nearest cached prompt found for a query.
+type Match struct {
+	Prompt     string
+	Entry      *cache.Entry
+	Similarity float64
+
+	scope   string
+	element *list.Element
+}
+
+// Index is an in-memory vector index of cached responses. Entries are grouped by scope
+// and only compared with queries of the same scope. Once the index holds more than its
+// capacity, the least recently used entry is evicted.
+type Index struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List
+	scopes   map[string]map[*list.Element]struct{}
+}
+
+type indexItem struct {
+	scope  string
+	prompt string
+	vector []float32
+	entry  *cache.Entry
+}
+
+// NewIndex creates an index holding at most capacity entries.
+// A capacity of zero or less means unbounded.
+func NewIndex(capacity int) *Index {
+	return &Index{
+		capacity: capacity,
+		order:    list.New(),
+		scopes:   make(map[string]map[*list.Element]struct{}),
+	}
+}
+
+// Add stores a response under the embedding of its prompt.
+func (idx *Index) Add(scope, prompt string, vector []float32, entry *cache.Entry) {
+	item := &indexItem{scope: scope, prompt: prompt, vector: normalize(vector), entry: entry}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	element := idx.order.PushFront(item)
+	if idx.scopes[scope] == nil {
+		idx.scopes[scope] = make(map[*list.Element]struct{})
+	}
+	idx.scopes[scope][element] = struct{}{}
+
+	if idx.capacity > 0 && idx.order.Len() > idx.capacity {
+		idx.remove(idx.order.Back())
+	}
+}
+
+// Search returns the most similar live entry in scope. Expired entries encountered
+// during the search are removed. The match is returned regardless of its similarity,
+// so callers can apply their own threshold and report near misses.
+func (idx *Index) Search(scope string, vector []float32, now time.Time) (*Match, bool) {
+	query := normalize(vector)
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	var best *list.Element
+	bestSimilarity := math.Inf(-1)
+	for element := range idx.scopes[scope] {
+		item := element.Value.(*indexItem)
+		if item.entry.Expired(now) {
+			idx.remove(element)
+			continue
+		}
+		if similarity := dot(query, item.vector); similarity > bestSimilarity {
+			best, bestSimilarity = element, similarity
+		}
+	}
+	if best == nil {
+		return nil, false
+	}
+
+	item := best.Value.(*indexItem)
+	return &Match{Prompt: item.prompt, Entry: item.entry, Similarity: bestSimilarity, scope: scope, element: best}, true
+}
+
+// Touch marks the entry of a match as recently used, unless it has been evicted since.
+func (idx *Index) Touch(match *Match) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if _, ok := idx.scopes[match.scope][match.element]; ok {
+		idx.order.MoveToFront(match.element)
+	}
+}
+
+// Len returns the number of stored entries.
+func (idx *Index) Len() int {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	return idx.order.Len()
+}
+
+func (idx *Index) remove(element *list.Element) {
+	item := element.Value.(*indexItem)
+	idx.order.Remove(element)
+	delete(idx.scopes[item.scope], element)
+	if len(idx.scopes[item.scope]) == 0 {
+		delete(idx.scopes, item.scope)
+	}
+}
+
+// normalize returns a unit-length copy of vector, so cosine similarity becomes a dot product.
+func normalize(vector []float32) []float32 {
+	var sum float64
+	for _, value := range vector {
+		sum += float64(value) * float64(value)
+	}
+	normalized := make([]float32, len(vector))
+	if sum == 0 {
+		return normalized
+	}
+	norm := math.Sqrt(sum)
+	for i, value := range vector {
+		normalized[i] = float32(float64(value) / norm)
+	}
+	return normalized
+}
+
+func dot(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var sum float64
+	for i := range a {
+		sum += float64(a[i]) * float64(b[i])
+	}
+	return sum
+}
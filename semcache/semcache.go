@@ -0,0 +1,275 @@
+// Package semcache provides a semantic response cache middleware for llm.Provider. Prompts
+// are embedded and a request is answered from the cache when a previously seen prompt is
+// similar enough, so paraphrased questions share a response. Responses that request tool
+// calls are not cached, since their arguments belong to the exact prompt.
+package semcache
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ulgerang/llm-module/cache"
+	"github.com/ulgerang/llm-module/llm"
+	"github.com/ulgerang/llm-module/logger"
+)
+
+const (
+	// DefaultThreshold is the minimum cosine similarity for a cache hit.
+	DefaultThreshold = 0.92
+	// DefaultCapacity is the number of responses kept before eviction.
+	DefaultCapacity = 1000
+)
+
+// Lookup describes one cache lookup, passed to the observer set with WithObserver.
+// Similarity is the best similarity found, or zero when the scope held no entries.
+type Lookup struct {
+	Prompt        string
+	MatchedPrompt string
+	Similarity    float64
+	Hit           bool
+}
+
+// Stats summarizes cache lookups since the Provider was created.
+type Stats struct {
+	Lookups int64
+	Hits    int64
+	// HitSimilaritySum is the sum of similarities of all hits.
+	HitSimilaritySum float64
+}
+
+// HitRate returns the fraction of lookups that were hits.
+func (s Stats) HitRate() float64 {
+	if s.Lookups == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Lookups)
+}
+
+// AverageHitSimilarity returns the mean similarity of hits.
+func (s Stats) AverageHitSimilarity() float64 {
+	if s.Hits == 0 {
+		return 0
+	}
+	return s.HitSimilaritySum / float64(s.Hits)
+}
+
+// Cache answers requests from semantically similar cached requests. Entries are scoped
+// by model, request kind and every generation option, so only the prompt is compared by
+// similarity. Errors are never cached.
+type Cache struct {
+	embedder        llm.Embedder
+	index           *Index
+	threshold       float64
+	ttl             time.Duration
+	replayChunkSize int
+	observer        func(Lookup)
+	logger          logger.Logger
+	now             func() time.Time
+
+	mu    sync.Mutex
+	stats Stats
+}
+
+// Option configures a semantic Cache.
+type Option func(*Cache)
+
+// WithThreshold sets the minimum cosine similarity, between -1 and 1, for a cache hit.
+func WithThreshold(threshold float64) Option {
+	return func(c *Cache) {
+		c.threshold = threshold
+	}
+}
+
+// WithCapacity sets how many responses the index keeps before evicting the least
+// recently used one. Zero or less means unbounded.
+func WithCapacity(capacity int) Option {
+	return func(c *Cache) {
+		c.index = NewIndex(capacity)
+	}
+}
+
+// WithTTL expires entries after ttl. Zero keeps entries until they are evicted.
+func WithTTL(ttl time.Duration) Option {
+	return func(c *Cache) {
+		c.ttl = ttl
+	}
+}
+
+// WithReplayChunkSize sets how many characters each replayed stream chunk carries.
+func WithReplayChunkSize(size int) Option {
+	return func(c *Cache) {
+		if size > 0 {
+			c.replayChunkSize = size
+		}
+	}
+}
+
+// WithObserver calls fn after every lookup, for example to export hit rate and
+// similarity metrics or to tune the threshold.
+func WithObserver(fn func(Lookup)) Option {
+	return func(c *Cache) {
+		c.observer = fn
+	}
+}
+
+// WithLogger reports embedding failures, which otherwise only bypass the cache.
+func WithLogger(log logger.Logger) Option {
+	return func(c *Cache) {
+		c.logger = log
+	}
+}
+
+// NewCache creates a semantic cache that embeds prompts with embedder.
+func NewCache(embedder llm.Embedder, opts ...Option) *Cache {
+	c := &Cache{
+		embedder:  embedder,
+		index:     NewIndex(DefaultCapacity),
+		threshold: DefaultThreshold,
+		now:       time.Now,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Provider is an llm.Provider wrapped with a semantic Cache.
+type Provider struct {
+	llm.Provider
+	cache *Cache
+}
+
+// New wraps provider with a semantic cache that embeds prompts with embedder.
+func New(provider llm.Provider, embedder llm.Embedder, opts ...Option) *Provider {
+	c := NewCache(embedder, opts...)
+	return &Provider{Provider: llm.Wrap(provider, c.Middleware()), cache: c}
+}
+
+// Name returns the name of the wrapped provider.
+func (p *Provider) Name() string {
+	return llm.ProviderName(p.Provider)
+}
+
+// Stats returns a snapshot of the lookup statistics of the provider's cache.
+func (p *Provider) Stats() Stats {
+	return p.cache.Stats()
+}
+
+// Stats returns a snapshot of the lookup statistics.
+func (c *Cache) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
+
+// Middleware returns an llm.Middleware that serves requests from the cache. Streaming
+// hits are replayed as chunks.
+func (c *Cache) Middleware() llm.Middleware {
+	return func(next llm.Handler) llm.Handler {
+		return func(ctx context.Context, req *llm.Request, emit llm.EmitFunc) (*llm.Response, error) {
+			scope, vector, ok := c.prepare(ctx, req)
+			if !ok {
+				return next(ctx, req, emit)
+			}
+			if match := c.lookup(scope, req.Prompt, vector); match != nil {
+				resp := &llm.Response{Text: match.Entry.Text, Usage: &llm.UsageInfo{ResponseCacheHit: true}}
+				if req.Stream {
+					return resp, cache.Replay(match.Entry, c.replayChunkSize, emit)
+				}
+				return resp, nil
+			}
+
+			resp, err := next(ctx, req, emit)
+			if err != nil || resp == nil || ctx.Err() != nil || isToolCall(resp) {
+				return resp, err
+			}
+			entry := &cache.Entry{Text: resp.Text}
+			if resp.Usage != nil {
+				entry.Usage = *resp.Usage
+			}
+			c.add(scope, req.Prompt, vector, entry)
+			return resp, nil
+		}
+	}
+}
+
+// isToolCall reports whether resp requests tool calls. Tool arguments are specific to the
+// exact prompt, so they are not reused for a merely similar one.
+func isToolCall(resp *llm.Response) bool {
+	return len(resp.ToolCalls) > 0 || strings.HasPrefix(resp.Text, llm.ToolCallPrefix)
+}
+
+// prepare computes the scope and prompt embedding of a request. It reports false when the
+// request cannot use the cache, in which case it goes straight to the wrapped provider.
+func (c *Cache) prepare(ctx context.Context, req *llm.Request) (string, []float32, bool) {
+	if req.Prompt == "" {
+		return "", nil, false
+	}
+
+	// The scope is the exact-match key of the request without its prompt.
+	kind := "semantic-text"
+	if req.Stream {
+		kind = "semantic-stream"
+	}
+	scope, err := cache.Key(req.Model, kind, "", req.Options)
+	if err != nil {
+		c.logError("[SemCache] Failed to compute scope", err)
+		return "", nil, false
+	}
+
+	vectors, err := c.embedder.Embed(ctx, []string{req.Prompt})
+	if err == nil && (len(vectors) != 1 || len(vectors[0]) == 0) {
+		err = errors.New("embedder returned no vector")
+	}
+	if err != nil {
+		c.logError("[SemCache] Failed to embed prompt", err)
+		return "", nil, false
+	}
+	return scope, vectors[0], true
+}
+
+// lookup returns the nearest entry if its similarity reaches the threshold, and records
+// the lookup in the statistics.
+func (c *Cache) lookup(scope, prompt string, vector []float32) *Match {
+	match, found := c.index.Search(scope, vector, c.now())
+	result := Lookup{Prompt: prompt}
+	if found {
+		result.MatchedPrompt = match.Prompt
+		result.Similarity = match.Similarity
+		result.Hit = match.Similarity >= c.threshold
+	}
+
+	c.mu.Lock()
+	c.stats.Lookups++
+	if result.Hit {
+		c.stats.Hits++
+		c.stats.HitSimilaritySum += result.Similarity
+	}
+	c.mu.Unlock()
+
+	if c.observer != nil {
+		c.observer(result)
+	}
+	if !result.Hit {
+		return nil
+	}
+	c.index.Touch(match)
+	return match
+}
+
+func (c *Cache) add(scope, prompt string, vector []float32, entry *cache.Entry) {
+	entry.CreatedAt = c.now()
+	if c.ttl > 0 {
+		entry.ExpiresAt = entry.CreatedAt.Add(c.ttl)
+	}
+	c.index.Add(scope, prompt, vector, entry)
+}
+
+func (c *Cache) logError(message string, err error) {
+	if c.logger != nil {
+		c.logger.Error(message, err)
+	}
+}
@@ -0,0 +1,246 @@
+package semcache
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ulgerang/llm-module/cache"
+	"github.com/ulgerang/llm-module/llm"
+)
+
+type countingProvider struct {
+	calls     int
+	toolCalls bool
+}
+
+func (p *countingProvider) GenerateText(_ context.Context, prompt string, _ ...llm.GenerationOption) (string, *llm.UsageInfo, error) {
+	p.calls++
+	if p.toolCalls {
+		text, err := llm.FormatToolCalls([]llm.ToolCall{{ID: "call_1", Name: "search", Input: []byte(`{"query":"` + prompt + `"}`)}})
+		return text, &llm.UsageInfo{InputTokens: 10, OutputTokens: 5}, err
+	}
+	return "answer to " + prompt, &llm.UsageInfo{InputTokens: 10, OutputTokens: 5}, nil
+}
+
+func (p *countingProvider) GenerateTextStream(_ context.Context, prompt string, outChan chan<- llm.StreamChunk, _ ...llm.GenerationOption) (*llm.UsageInfo, error) {
+	defer close(outChan)
+	p.calls++
+	for _, word := range strings.SplitAfter("answer to "+prompt, " ") {
+		outChan <- llm.StreamChunk{Delta: word}
+	}
+	outChan <- llm.StreamChunk{IsFinal: true}
+	return &llm.UsageInfo{InputTokens: 10, OutputTokens: 5}, nil
+}
+
+func (p *countingProvider) GetModelName() string { return "test-model" }
+func (p *countingProvider) Close() error         { return nil }
+
+// fakeEmbedder maps prompts to fixed vectors.
+type fakeEmbedder struct {
+	vectors map[string][]float32
+	err     error
+}
+
+func (e *fakeEmbedder) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vectors[i] = e.vectors[text]
+	}
+	return vectors, nil
+}
+
+func newEmbedder() *fakeEmbedder {
+	return &fakeEmbedder{vectors: map[string][]float32{
+		"how do I reset my password":     {1, 0, 0},
+		"how can I reset my password":    {0.98, 0.2, 0},
+		"what are your opening hours":    {0, 1, 0},
+		"when do you open":               {0.1, 0.99, 0},
+		"how do I delete my account":     {0.6, 0, 0.8},
+		"can I get a refund":             {0, 0, 1},
+		"is a refund possible":           {0, 0.1, 0.99},
+		"do you ship internationally":    {0.7, 0.7, 0},
+		"do you ship to other countries": {0.69, 0.72, 0},
+	}}
+}
+
+func TestParaphraseHitsCache(t *testing.T) {
+	inner := &countingProvider{}
+	var lookups []Lookup
+	provider := New(inner, newEmbedder(), WithObserver(func(l Lookup) { lookups = append(lookups, l) }))
+	ctx := context.Background()
+
+	first, _, err := provider.GenerateText(ctx, "how do I reset my password")
+	if err != nil {
+		t.Fatalf("GenerateText failed: %v", err)
+	}
+	second, usage, err := provider.GenerateText(ctx, "how can I reset my password")
+	if err != nil {
+		t.Fatalf("GenerateText failed: %v", err)
+	}
+	if second != first || !usage.ResponseCacheHit {
+		t.Errorf("paraphrase should hit the cache, got %q (hit=%v)", second, usage.ResponseCacheHit)
+	}
+
+	if _, usage, _ := provider.GenerateText(ctx, "how do I delete my account"); usage.ResponseCacheHit {
+		t.Error("dissimilar prompt should miss the cache")
+	}
+	if inner.calls != 2 {
+		t.Errorf("provider called %d times, want 2", inner.calls)
+	}
+
+	stats := provider.Stats()
+	if stats.Lookups != 3 || stats.Hits != 1 {
+		t.Errorf("stats = %+v, want 3 lookups and 1 hit", stats)
+	}
+	if rate := stats.HitRate(); math.Abs(rate-1.0/3) > 1e-9 {
+		t.Errorf("hit rate = %v", rate)
+	}
+	if len(lookups) != 3 || !lookups[1].Hit || lookups[1].MatchedPrompt != "how do I reset my password" {
+		t.Errorf("observer got %+v", lookups)
+	}
+	if lookups[2].Hit || lookups[2].Similarity <= 0 || lookups[2].Similarity >= DefaultThreshold {
+		t.Errorf("near miss should report its similarity, got %+v", lookups[2])
+	}
+}
+
+func TestToolCallsAreNotCached(t *testing.T) {
+	inner := &countingProvider{toolCalls: true}
+	provider := New(inner, newEmbedder())
+	ctx := context.Background()
+
+	for _, prompt := range []string{"how do I reset my password", "how can I reset my password"} {
+		text, _, err := provider.GenerateText(ctx, prompt)
+		if err != nil {
+			t.Fatalf("GenerateText failed: %v", err)
+		}
+		if calls, _, _ := llm.ParseToolCalls(text); len(calls) != 1 || !strings.Contains(string(calls[0].Input), prompt) {
+			t.Errorf("GenerateText(%q) = %s", prompt, text)
+		}
+	}
+	if inner.calls != 2 {
+		t.Errorf("expected both calls to reach the provider, got %d", inner.calls)
+	}
+}
+
+func TestScopeSeparatesOptions(t *testing.T) {
+	inner := &countingProvider{}
+	provider := New(inner, newEmbedder())
+	ctx := context.Background()
+
+	provider.GenerateText(ctx, "what are your opening hours", llm.WithSystem("answer in English"))
+	if _, usage, _ := provider.GenerateText(ctx, "when do you open", llm.WithSystem("answer in French")); usage.ResponseCacheHit {
+		t.Error("different options should not share entries")
+	}
+	if _, usage, _ := provider.GenerateText(ctx, "when do you open", llm.WithSystem("answer in English")); !usage.ResponseCacheHit {
+		t.Error("same options should share entries")
+	}
+}
+
+func TestThreshold(t *testing.T) {
+	provider := New(&countingProvider{}, newEmbedder(), WithThreshold(0.9999))
+	ctx := context.Background()
+
+	provider.GenerateText(ctx, "can I get a refund")
+	if _, usage, _ := provider.GenerateText(ctx, "is a refund possible"); usage.ResponseCacheHit {
+		t.Error("similarity below the threshold should miss")
+	}
+}
+
+func TestEmbeddingFailureBypassesCache(t *testing.T) {
+	inner := &countingProvider{}
+	provider := New(inner, &fakeEmbedder{err: errors.New("embedding unavailable")})
+
+	for i := 0; i < 2; i++ {
+		if _, _, err := provider.GenerateText(context.Background(), "can I get a refund"); err != nil {
+			t.Fatalf("GenerateText failed: %v", err)
+		}
+	}
+	if inner.calls != 2 || provider.Stats().Lookups != 0 {
+		t.Errorf("calls = %d, stats = %+v", inner.calls, provider.Stats())
+	}
+}
+
+func TestStreamReplay(t *testing.T) {
+	inner := &countingProvider{}
+	provider := New(inner, newEmbedder(), WithReplayChunkSize(5))
+	ctx := context.Background()
+
+	first, _ := streamText(t, provider, "do you ship internationally")
+	second, usage := streamText(t, provider, "do you ship to other countries")
+	if second != first || !usage.ResponseCacheHit {
+		t.Errorf("paraphrase should replay %q, got %q (hit=%v)", first, second, usage.ResponseCacheHit)
+	}
+	if inner.calls != 1 {
+		t.Errorf("provider called %d times, want 1", inner.calls)
+	}
+
+	if _, usage, _ := provider.GenerateText(ctx, "do you ship to other countries"); usage.ResponseCacheHit {
+		t.Error("stream entries should not serve GenerateText")
+	}
+}
+
+func TestIndexEviction(t *testing.T) {
+	index := NewIndex(2)
+	now := time.Now()
+	index.Add("s", "a", []float32{1, 0}, &cache.Entry{Text: "a"})
+	index.Add("s", "b", []float32{0, 1}, &cache.Entry{Text: "b"})
+
+	match, _ := index.Search("s", []float32{1, 0}, now)
+	index.Touch(match)
+	index.Add("s", "c", []float32{-1, 0}, &cache.Entry{Text: "c"})
+
+	if index.Len() != 2 {
+		t.Fatalf("Len = %d, want 2", index.Len())
+	}
+	if match, _ := index.Search("s", []float32{0, 1}, now); match.Prompt == "b" {
+		t.Error("b should have been evicted")
+	}
+	if _, ok := index.Search("other", []float32{1, 0}, now); ok {
+		t.Error("search should not cross scopes")
+	}
+}
+
+func TestIndexDropsExpiredEntries(t *testing.T) {
+	index := NewIndex(0)
+	now := time.Now()
+	index.Add("s", "a", []float32{1, 0}, &cache.Entry{Text: "a", ExpiresAt: now.Add(time.Minute)})
+
+	if _, ok := index.Search("s", []float32{1, 0}, now); !ok {
+		t.Fatal("entry should be live")
+	}
+	if _, ok := index.Search("s", []float32{1, 0}, now.Add(2*time.Minute)); ok {
+		t.Error("expired entry should not match")
+	}
+	if index.Len() != 0 {
+		t.Errorf("expired entry should be removed, Len = %d", index.Len())
+	}
+}
+
+func streamText(t *testing.T, provider llm.Provider, prompt string) (string, *llm.UsageInfo) {
+	t.Helper()
+	out := make(chan llm.StreamChunk)
+	var usage *llm.UsageInfo
+	var err error
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		usage, err = provider.GenerateTextStream(context.Background(), prompt, out)
+	}()
+
+	var text strings.Builder
+	for chunk := range out {
+		text.WriteString(chunk.Delta)
+	}
+	<-done
+	if err != nil {
+		t.Fatalf("stream failed: %v", err)
+	}
+	return text.String(), usage
+}
@@ -1,4 +1,5 @@
-// Package testutil provides testing utilities for LLM provider smoke tests.
+// Package testutil provides testing utilities for LLM provider tests: stub servers
+// for the OpenAI-compatible providers, and configuration for smoke tests.
 //
 // This package loads API keys and model configurations from ~/.holon/providers.yaml,
 // allowing smoke tests to run without hardcoded credentials or environment variables.
@@ -18,6 +19,7 @@
 package testutil
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -82,46 +84,72 @@ func GetHolonConfigDir() string {
 	return filepath.Join(home, ".holon")
 }
 
-// LoadProvidersConfig loads and caches the providers.yaml configuration.
-// Returns nil if the config file doesn't exist or can't be parsed.
-func LoadProvidersConfig() *ProvidersConfig {
-	if cachedConfig != nil {
-		return cachedConfig
-	}
-
+// ReadProvidersConfig reads the providers.yaml configuration without caching it.
+// Returns nil and no error if the config file doesn't exist.
+func ReadProvidersConfig() (*ProvidersConfig, error) {
 	configDir := GetHolonConfigDir()
 	if configDir == "" {
-		return nil
+		return nil, nil
 	}
 
 	configPath := filepath.Join(configDir, "providers.yaml")
 	data, err := os.ReadFile(configPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
 	if err != nil {
-		return nil
+		return nil, err
 	}
 
 	var config ProvidersConfig
 	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("%s: %w", configPath, err)
+	}
+	return &config, nil
+}
+
+// LoadProvidersConfig loads and caches the providers.yaml configuration.
+// Returns nil if the config file doesn't exist or can't be parsed.
+func LoadProvidersConfig() *ProvidersConfig {
+	if cachedConfig != nil {
+		return cachedConfig
+	}
+
+	config, err := ReadProvidersConfig()
+	if err != nil || config == nil {
 		return nil
 	}
 
-	cachedConfig = &config
+	cachedConfig = config
 	return cachedConfig
 }
 
-// GetProviderConfig returns the configuration for a specific provider.
-// Returns nil if the provider is not configured.
-func GetProviderConfig(providerName string) *ProviderConfig {
-	config := LoadProvidersConfig()
-	if config == nil || config.Providers == nil {
-		return nil
-	}
+// providerAliases maps vendor names to the provider names they share settings with.
+var providerAliases = map[string]string{
+	"anthropic": "claude",
+	"claude":    "anthropic",
+	"google":    "gemini",
+	"gemini":    "google",
+	"xai":       "grok",
+	"grok":      "xai",
+}
 
-	providerConfig, ok := config.Providers[strings.ToLower(providerName)]
-	if !ok {
+// provider returns the settings of a provider, falling back to its alias.
+func (c *ProvidersConfig) provider(providerName string) *ProviderConfig {
+	if c == nil || c.Providers == nil {
 		return nil
 	}
-	return providerConfig
+	providerName = strings.ToLower(providerName)
+	if providerConfig, ok := c.Providers[providerName]; ok {
+		return providerConfig
+	}
+	return c.Providers[providerAliases[providerName]]
+}
+
+// GetProviderConfig returns the configuration for a specific provider or its alias,
+// such as "anthropic" for "claude". Returns nil if the provider is not configured.
+func GetProviderConfig(providerName string) *ProviderConfig {
+	return LoadProvidersConfig().provider(providerName)
 }
 
 // GetAPIKey returns the API key for a provider.
@@ -132,18 +160,8 @@ func GetProviderConfig(providerName string) *ProviderConfig {
 //
 // Returns empty string if no key is found.
 func GetAPIKey(providerName string) string {
-	providerName = strings.ToLower(providerName)
-
-	// 1. Try well-known environment variable first
-	if envVar, ok := wellKnownEnvVars[providerName]; ok {
-		if key := os.Getenv(envVar); key != "" {
-			return key
-		}
-	}
-
-	// Also try generic pattern: PROVIDER_API_KEY
-	genericEnvVar := strings.ToUpper(providerName) + "_API_KEY"
-	if key := os.Getenv(genericEnvVar); key != "" {
+	// 1. Try environment variables first
+	if key := envAPIKey(providerName); key != "" {
 		return key
 	}
 
@@ -166,6 +184,45 @@ func GetAPIKey(providerName string) string {
 	return apiKey
 }
 
+// LookupAPIKey returns the API key for a provider like GetAPIKey, but reads
+// providers.yaml on every call and reports a file that cannot be read or parsed, or an
+// API key file that cannot be read. Returns an empty string and no error if no key is found.
+func LookupAPIKey(providerName string) (string, error) {
+	if key := envAPIKey(providerName); key != "" {
+		return key, nil
+	}
+
+	config, err := ReadProvidersConfig()
+	if err != nil {
+		return "", err
+	}
+	providerConfig := config.provider(providerName)
+	if providerConfig == nil {
+		return "", nil
+	}
+
+	if providerConfig.APIKeyFile != "" {
+		data, err := os.ReadFile(expandPath(providerConfig.APIKeyFile))
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(data)), nil
+	}
+	return expandEnvVars(providerConfig.APIKey), nil
+}
+
+// envAPIKey returns the API key of a provider from its well-known environment
+// variable, or from the generic PROVIDER_API_KEY pattern.
+func envAPIKey(providerName string) string {
+	providerName = strings.ToLower(providerName)
+	if envVar, ok := wellKnownEnvVars[providerName]; ok {
+		if key := os.Getenv(envVar); key != "" {
+			return key
+		}
+	}
+	return os.Getenv(strings.ToUpper(providerName) + "_API_KEY")
+}
+
 // GetDefaultModel returns the default model for a provider.
 func GetDefaultModel(providerName string) string {
 	providerConfig := GetProviderConfig(providerName)
@@ -203,7 +260,8 @@ func expandPath(path string) string {
 	return path
 }
 
-// SkipIfNoAPIKey is a test helper that skips the test if the API key is not available.
+// SkipIfNoAPIKey is a test helper that skips the test if the API key is not available,
+// including when providers.yaml or the API key file cannot be read.
 // Usage:
 //
 //	func TestSomething(t *testing.T) {
@@ -211,7 +269,10 @@ func expandPath(path string) string {
 //	    // ... use apiKey
 //	}
 func SkipIfNoAPIKey(t interface{ Skip(args ...interface{}) }, providerName string) string {
-	apiKey := GetAPIKey(providerName)
+	apiKey, err := LookupAPIKey(providerName)
+	if err != nil {
+		t.Skip(fmt.Sprintf("%s API key not available: %v", providerName, err))
+	}
 	if apiKey == "" {
 		t.Skip(providerName + " API key not configured (set " +
 			strings.ToUpper(providerName) + "_API_KEY or configure in ~/.holon/providers.yaml)")
@@ -222,7 +283,10 @@ func SkipIfNoAPIKey(t interface{ Skip(args ...interface{}) }, providerName strin
 // MustGetAPIKey panics if the API key is not available.
 // Use this in benchmarks or non-test code.
 func MustGetAPIKey(providerName string) string {
-	apiKey := GetAPIKey(providerName)
+	apiKey, err := LookupAPIKey(providerName)
+	if err != nil {
+		panic(fmt.Sprintf("%s API key not available: %v", providerName, err))
+	}
 	if apiKey == "" {
 		panic(providerName + " API key not configured")
 	}
@@ -0,0 +1,47 @@
+package testutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeProvidersConfig(t *testing.T, content string) {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv("CLAUDE_API_KEY", "")
+	if err := os.MkdirAll(filepath.Join(home, ".holon"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(home, ".holon", "providers.yaml"), []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLookupAPIKeyResolvesAliases(t *testing.T) {
+	writeProvidersConfig(t, "providers:\n  anthropic:\n    api_key: sk-test\n    timeout: 30s\n")
+
+	key, err := LookupAPIKey("claude")
+	if err != nil || key != "sk-test" {
+		t.Fatalf("LookupAPIKey() = %q, %v", key, err)
+	}
+}
+
+type skipRecorder struct{ skipped bool }
+
+func (r *skipRecorder) Skip(args ...interface{}) { r.skipped = true }
+
+func TestSkipIfNoAPIKeySkipsUnreadableConfig(t *testing.T) {
+	writeProvidersConfig(t, "providers: [")
+
+	if _, err := LookupAPIKey("claude"); err == nil {
+		t.Error("expected an error for a malformed providers.yaml")
+	}
+	recorder := &skipRecorder{}
+	SkipIfNoAPIKey(recorder, "claude")
+	if !recorder.skipped {
+		t.Error("expected the test to be skipped")
+	}
+}
@@ -0,0 +1,214 @@
+package testutil
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ulgerang/llm-module/llm"
+)
+
+// WeatherTool is a tool with one required argument, for tool-calling tests.
+var WeatherTool = &llm.Tool{
+	Name:        "get_weather",
+	Description: "Look up the weather",
+	InputSchema: &llm.SchemaProperty{
+		Type:       "object",
+		Properties: map[string]*llm.SchemaProperty{"city": {Type: "string"}},
+		Required:   []string{"city"},
+	},
+}
+
+// ChatCompletion returns an OpenAI chat completion response whose message is text.
+func ChatCompletion(text string) string {
+	content, _ := json.Marshal(text)
+	return `{"id":"chatcmpl-1","object":"chat.completion","created":1,"model":"test-model",` +
+		`"choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":` + string(content) + `}}],` +
+		`"usage":{"prompt_tokens":1,"completion_tokens":1,"total_tokens":2}}`
+}
+
+// StubServer starts a server that records the last request body in body and replies
+// with payload. The server is closed when the test ends.
+func StubServer(t testing.TB, contentType, payload string, body *string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		*body = string(data)
+		w.Header().Set("Content-Type", contentType)
+		fmt.Fprint(w, payload)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+// RequestURL creates a provider with newProvider, sends one request through a stub
+// transport and returns the URL the request was sent to.
+func RequestURL(t testing.TB, newProvider func(opts ...llm.ProviderOption) (llm.Provider, error)) string {
+	t.Helper()
+	var url string
+	transport := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		url = r.URL.String()
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(bytes.NewBufferString(ChatCompletion("ok"))),
+			Request:    r,
+		}, nil
+	})
+	provider, err := newProvider(llm.WithTransport(transport))
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+	if _, _, err := provider.GenerateText(context.Background(), "hi"); err != nil {
+		t.Fatalf("GenerateText failed: %v", err)
+	}
+	return url
+}
+
+// RunToolCallTests checks the tool-calling wire format shared by the OpenAI-compatible
+// providers. newProvider returns a provider that sends its requests to baseURL.
+func RunToolCallTests(t *testing.T, newProvider func(baseURL string) (llm.Provider, error)) {
+	t.Run("GenerateText", func(t *testing.T) {
+		var body string
+		server := StubServer(t, "application/json", `{
+			"id": "chatcmpl-1", "object": "chat.completion", "created": 1, "model": "test-model",
+			"choices": [{"index": 0, "finish_reason": "tool_calls", "message": {"role": "assistant", "content": null,
+				"tool_calls": [{"id": "call_2", "type": "function", "function": {"name": "get_weather", "arguments": "{\"city\":\"Busan\"}"}}]}}],
+			"usage": {"prompt_tokens": 12, "completion_tokens": 5, "total_tokens": 17}
+		}`, &body)
+
+		provider, err := newProvider(server.URL)
+		if err != nil {
+			t.Fatalf("failed to create provider: %v", err)
+		}
+
+		history := []llm.Message{
+			llm.UserMessage("Weather in Seoul?"),
+			llm.AssistantToolCallMessage([]llm.ToolCall{{ID: "call_1", Name: "get_weather", Input: json.RawMessage(`{"city":"Seoul"}`)}}),
+			llm.ToolResultMessage(llm.ToolResult{ToolCallID: "call_1", Name: "get_weather", Content: "sunny"}),
+		}
+		result, usage, err := provider.GenerateText(context.Background(), "And in Busan?",
+			llm.WithTools([]*llm.Tool{WeatherTool}),
+			llm.WithToolChoice(llm.ToolChoiceRequired),
+			llm.WithMessages(history),
+		)
+		if err != nil {
+			t.Fatalf("GenerateText failed: %v", err)
+		}
+
+		for _, want := range []string{
+			`"tools":[{"function":{"name":"get_weather","description":"Look up the weather","parameters":{`,
+			`"tool_choice":"required"`,
+			`"tool_calls":[{"id":"call_1","function":{"arguments":"{\"city\":\"Seoul\"}","name":"get_weather"},"type":"function"}]`,
+			`{"content":"sunny","tool_call_id":"call_1","role":"tool"}`,
+			`{"content":"And in Busan?","role":"user"}`,
+		} {
+			if !strings.Contains(body, want) {
+				t.Errorf("request body missing %s\nbody: %s", want, body)
+			}
+		}
+
+		calls, ok, err := llm.ParseToolCalls(result)
+		if err != nil || !ok {
+			t.Fatalf("expected tool call result, got %q (err=%v)", result, err)
+		}
+		if len(calls) != 1 || calls[0].ID != "call_2" || string(calls[0].Input) != `{"city":"Busan"}` {
+			t.Fatalf("unexpected tool calls: %+v", calls)
+		}
+		if usage == nil || usage.InputTokens != 12 || usage.OutputTokens != 5 {
+			t.Fatalf("unexpected usage: %+v", usage)
+		}
+	})
+
+	t.Run("GenerateTextStream", func(t *testing.T) {
+		chunks := []string{
+			`{"id":"c","object":"chat.completion.chunk","created":1,"model":"test-model","choices":[{"index":0,"delta":{"role":"assistant","tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"get_weather","arguments":""}}]}}]}`,
+			`{"id":"c","object":"chat.completion.chunk","created":1,"model":"test-model","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"city\":"}}]}}]}`,
+			`{"id":"c","object":"chat.completion.chunk","created":1,"model":"test-model","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"Seoul\"}"}}]}}]}`,
+			`{"id":"c","object":"chat.completion.chunk","created":1,"model":"test-model","choices":[{"index":0,"delta":{},"finish_reason":"tool_calls"}]}`,
+		}
+		var payload strings.Builder
+		for _, chunk := range chunks {
+			payload.WriteString("data: " + chunk + "\n\n")
+		}
+		payload.WriteString("data: [DONE]\n\n")
+
+		var body string
+		server := StubServer(t, "text/event-stream", payload.String(), &body)
+
+		provider, err := newProvider(server.URL)
+		if err != nil {
+			t.Fatalf("failed to create provider: %v", err)
+		}
+
+		out := make(chan llm.StreamChunk, 16)
+		if _, err := provider.GenerateTextStream(context.Background(), "Weather in Seoul?", out, llm.WithTools([]*llm.Tool{WeatherTool})); err != nil {
+			t.Fatalf("GenerateTextStream failed: %v", err)
+		}
+
+		var calls []llm.ToolCall
+		for chunk := range out {
+			if chunk.Err != nil {
+				t.Fatalf("unexpected stream error: %v", chunk.Err)
+			}
+			calls = append(calls, chunk.ToolCalls...)
+		}
+
+		if !strings.Contains(body, `"tools":[{"function":{"name":"get_weather"`) {
+			t.Errorf("streaming request missing tools: %s", body)
+		}
+		if len(calls) != 1 || calls[0].ID != "call_1" || calls[0].Name != "get_weather" || string(calls[0].Input) != `{"city":"Seoul"}` {
+			t.Fatalf("unexpected streamed tool calls: %+v", calls)
+		}
+	})
+}
+
+// OptionCase is a generation option check for RunOptionTests.
+type OptionCase struct {
+	Name    string
+	Options []llm.GenerationOption
+	// Want is a fragment the request body must contain.
+	Want string
+	// Unsupported means the provider must reject Options in strict mode.
+	Unsupported bool
+}
+
+// RunOptionTests sends one request per case to a provider returned by newProvider and
+// checks how the case's options reach the request body.
+func RunOptionTests(t *testing.T, newProvider func(baseURL string) (llm.Provider, error), cases []OptionCase) {
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			var body string
+			server := StubServer(t, "application/json", ChatCompletion("ok"), &body)
+			provider, err := newProvider(server.URL)
+			if err != nil {
+				t.Fatalf("failed to create provider: %v", err)
+			}
+
+			if tc.Unsupported {
+				opts := append(append([]llm.GenerationOption(nil), tc.Options...), llm.WithStrict())
+				if _, _, err := provider.GenerateText(context.Background(), "hi", opts...); !errors.Is(err, llm.ErrUnsupportedOption) {
+					t.Errorf("expected ErrUnsupportedOption, got %v", err)
+				}
+				return
+			}
+			if _, _, err := provider.GenerateText(context.Background(), "hi", tc.Options...); err != nil {
+				t.Fatalf("GenerateText failed: %v", err)
+			}
+			if !strings.Contains(body, tc.Want) {
+				t.Errorf("request body missing %s: %s", tc.Want, body)
+			}
+		})
+	}
+}
@@ -0,0 +1,260 @@
+// Package tracing instruments llm.Provider calls with OpenTelemetry spans that follow
+// the GenAI semantic conventions.
+package tracing
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/ulgerang/llm-module/llm"
+)
+
+const instrumentationName = "github.com/ulgerang/llm-module/tracing"
+
+// Attribute keys from the OpenTelemetry GenAI semantic conventions.
+const (
+	attrOperationName       = attribute.Key("gen_ai.operation.name")
+	attrSystem              = attribute.Key("gen_ai.system")
+	attrRequestModel        = attribute.Key("gen_ai.request.model")
+	attrRequestTemperature  = attribute.Key("gen_ai.request.temperature")
+	attrRequestMaxTokens    = attribute.Key("gen_ai.request.max_tokens")
+	attrRequestTopP         = attribute.Key("gen_ai.request.top_p")
+	attrRequestTopK         = attribute.Key("gen_ai.request.top_k")
+	attrResponseModel       = attribute.Key("gen_ai.response.model")
+	attrFinishReasons       = attribute.Key("gen_ai.response.finish_reasons")
+	attrInputTokens         = attribute.Key("gen_ai.usage.input_tokens")
+	attrOutputTokens        = attribute.Key("gen_ai.usage.output_tokens")
+	attrCacheReadTokens     = attribute.Key("gen_ai.usage.cache_read.input_tokens")
+	attrCacheCreationTokens = attribute.Key("gen_ai.usage.cache_creation.input_tokens")
+	attrErrorType           = attribute.Key("error.type")
+	attrContent             = attribute.Key("content")
+)
+
+// Attribute keys specific to this module.
+const (
+	attrStreaming        = attribute.Key("llm.streaming")
+	attrResponseCacheHit = attribute.Key("llm.response_cache_hit")
+	attrTimeToFirstToken = attribute.Key("llm.time_to_first_token")
+	attrChunkIndex       = attribute.Key("llm.chunk.index")
+	attrChunkLength      = attribute.Key("llm.chunk.length")
+)
+
+const (
+	eventFirstToken    = "gen_ai.first_token"
+	eventChunk         = "gen_ai.chunk"
+	eventSystemMessage = "gen_ai.system.message"
+	eventUserMessage   = "gen_ai.user.message"
+	eventAssistant     = "gen_ai.assistant.message"
+	eventToolMessage   = "gen_ai.tool.message"
+	eventChoice        = "gen_ai.choice"
+)
+
+type config struct {
+	tracerProvider trace.TracerProvider
+	system         string
+	captureContent bool
+	chunkEvents    bool
+}
+
+// Option configures the tracing middleware.
+type Option func(*config)
+
+// WithTracerProvider sets the tracer provider. The global provider is used by default.
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(c *config) {
+		c.tracerProvider = provider
+	}
+}
+
+// WithSystem sets gen_ai.system, for example "openai" or "anthropic". By default it is
+// derived from the name of the wrapped provider.
+func WithSystem(system string) Option {
+	return func(c *config) {
+		c.system = system
+	}
+}
+
+// WithContentCapture records prompts, messages and responses as span events. Content
+// is not captured by default because it may contain sensitive data.
+func WithContentCapture(enabled bool) Option {
+	return func(c *config) {
+		c.captureContent = enabled
+	}
+}
+
+// WithChunkEvents controls whether streaming spans get an event per received chunk.
+// Enabled by default; the first-token event is always recorded.
+func WithChunkEvents(enabled bool) Option {
+	return func(c *config) {
+		c.chunkEvents = enabled
+	}
+}
+
+func newConfig(opts []Option) *config {
+	c := &config{chunkEvents: true}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.tracerProvider == nil {
+		c.tracerProvider = otel.GetTracerProvider()
+	}
+	return c
+}
+
+// Wrap instruments provider with a span per call.
+func Wrap(provider llm.Provider, opts ...Option) llm.Provider {
+	return llm.Wrap(provider, Middleware(opts...))
+}
+
+// Middleware returns an llm.Middleware that records a span per call.
+func Middleware(opts ...Option) llm.Middleware {
+	return newConfig(opts).middleware
+}
+
+func (c *config) middleware(next llm.Handler) llm.Handler {
+	tracer := c.tracerProvider.Tracer(instrumentationName)
+	return func(ctx context.Context, req *llm.Request, emit llm.EmitFunc) (*llm.Response, error) {
+		ctx, span := tracer.Start(ctx, "chat "+req.Model,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(c.requestAttributes(req)...),
+		)
+		defer span.End()
+
+		if c.captureContent {
+			recordRequestContent(span, req)
+		}
+
+		if req.Stream {
+			emit = c.instrumentStream(span, emit)
+		}
+
+		resp, err := next(ctx, req, emit)
+		if resp != nil {
+			span.SetAttributes(responseAttributes(req, resp)...)
+			if c.captureContent {
+				span.AddEvent(eventChoice, trace.WithAttributes(attrContent.String(resp.Text)))
+			}
+		}
+		if err != nil {
+			span.SetAttributes(attrErrorType.String(string(llm.ClassifyError(err))))
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		return resp, err
+	}
+}
+
+func (c *config) requestAttributes(req *llm.Request) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		attrOperationName.String("chat"),
+		attrRequestModel.String(req.Model),
+		attrStreaming.Bool(req.Stream),
+	}
+	if system := c.systemFor(req.Provider); system != "" {
+		attrs = append(attrs, attrSystem.String(system))
+	}
+	options := req.Options
+	if options.Temperature != nil {
+		attrs = append(attrs, attrRequestTemperature.Float64(float64(*options.Temperature)))
+	}
+	if options.MaxTokens != nil {
+		attrs = append(attrs, attrRequestMaxTokens.Int(int(*options.MaxTokens)))
+	}
+	if options.TopP != nil {
+		attrs = append(attrs, attrRequestTopP.Float64(float64(*options.TopP)))
+	}
+	if options.TopK != nil {
+		attrs = append(attrs, attrRequestTopK.Float64(float64(*options.TopK)))
+	}
+	return attrs
+}
+
+func responseAttributes(req *llm.Request, resp *llm.Response) []attribute.KeyValue {
+	responseModel := req.Model
+	var attrs []attribute.KeyValue
+	if usage := resp.Usage; usage != nil {
+		if usage.ResponseModel != "" {
+			responseModel = usage.ResponseModel
+		}
+		if usage.FinishReason != "" {
+			attrs = append(attrs, attrFinishReasons.StringSlice([]string{usage.FinishReason}))
+		}
+		attrs = append(attrs,
+			attrInputTokens.Int(usage.InputTokens),
+			attrOutputTokens.Int(usage.OutputTokens),
+			attrCacheReadTokens.Int(usage.CacheHitTokens),
+			attrResponseCacheHit.Bool(usage.ResponseCacheHit),
+		)
+		if usage.CacheCreateTokens > 0 {
+			attrs = append(attrs, attrCacheCreationTokens.Int(usage.CacheCreateTokens))
+		}
+	}
+	return append(attrs, attrResponseModel.String(responseModel))
+}
+
+// instrumentStream records the time to the first content chunk and, when enabled,
+// an event per chunk.
+func (c *config) instrumentStream(span trace.Span, emit llm.EmitFunc) llm.EmitFunc {
+	start := time.Now()
+	index := 0
+	firstToken := false
+	return func(chunk llm.StreamChunk) error {
+		if !firstToken && (chunk.Delta != "" || len(chunk.ToolCalls) > 0) {
+			firstToken = true
+			ttft := time.Since(start)
+			span.SetAttributes(attrTimeToFirstToken.Float64(ttft.Seconds()))
+			span.AddEvent(eventFirstToken)
+		}
+		if c.chunkEvents && chunk.Delta != "" {
+			attrs := []attribute.KeyValue{attrChunkIndex.Int(index), attrChunkLength.Int(len(chunk.Delta))}
+			if c.captureContent {
+				attrs = append(attrs, attrContent.String(chunk.Delta))
+			}
+			span.AddEvent(eventChunk, trace.WithAttributes(attrs...))
+			index++
+		}
+		return emit(chunk)
+	}
+}
+
+func recordRequestContent(span trace.Span, req *llm.Request) {
+	if req.Options.System != "" {
+		span.AddEvent(eventSystemMessage, trace.WithAttributes(attrContent.String(req.Options.System)))
+	}
+	for _, block := range req.Options.SystemBlocks {
+		span.AddEvent(eventSystemMessage, trace.WithAttributes(attrContent.String(block.Text)))
+	}
+	for _, message := range req.Messages() {
+		name := eventUserMessage
+		switch message.Role {
+		case llm.RoleAssistant:
+			name = eventAssistant
+		case llm.RoleTool:
+			name = eventToolMessage
+		}
+		span.AddEvent(name, trace.WithAttributes(attrContent.String(message.Content)))
+	}
+}
+
+// systemNames maps provider package names to gen_ai.system values where they differ.
+var systemNames = map[string]string{
+	"claude": "anthropic",
+	"gemini": "gcp.gemini",
+	"grok":   "xai",
+	"zai":    "z.ai",
+}
+
+func (c *config) systemFor(provider string) string {
+	if c.system != "" {
+		return c.system
+	}
+	if system, ok := systemNames[provider]; ok {
+		return system
+	}
+	return provider
+}
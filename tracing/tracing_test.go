@@ -0,0 +1,175 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/ulgerang/llm-module/llm"
+)
+
+type fakeProvider struct {
+	err error
+}
+
+func (p *fakeProvider) GenerateText(_ context.Context, prompt string, _ ...llm.GenerationOption) (string, *llm.UsageInfo, error) {
+	if p.err != nil {
+		return "", nil, p.err
+	}
+	return "hello back", &llm.UsageInfo{InputTokens: 12, OutputTokens: 3, CacheHitTokens: 8, ResponseModel: "fake-model-2024", FinishReason: "stop"}, nil
+}
+
+func (p *fakeProvider) GenerateTextStream(_ context.Context, prompt string, outChan chan<- llm.StreamChunk, _ ...llm.GenerationOption) (*llm.UsageInfo, error) {
+	defer close(outChan)
+	if p.err != nil {
+		outChan <- llm.StreamChunk{Err: p.err}
+		return nil, p.err
+	}
+	outChan <- llm.StreamChunk{Delta: "hello "}
+	outChan <- llm.StreamChunk{Delta: "back"}
+	outChan <- llm.StreamChunk{IsFinal: true}
+	return &llm.UsageInfo{InputTokens: 12, OutputTokens: 2}, nil
+}
+
+func (p *fakeProvider) GetModelName() string { return "fake-model" }
+func (p *fakeProvider) Close() error         { return nil }
+
+func setup(opts ...Option) (*tracetest.InMemoryExporter, []Option) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	return exporter, append([]Option{WithTracerProvider(tp)}, opts...)
+}
+
+func attrs(span tracetest.SpanStub) map[attribute.Key]attribute.Value {
+	values := make(map[attribute.Key]attribute.Value)
+	for _, kv := range span.Attributes {
+		values[kv.Key] = kv.Value
+	}
+	return values
+}
+
+func eventNames(span tracetest.SpanStub) []string {
+	var names []string
+	for _, event := range span.Events {
+		names = append(names, event.Name)
+	}
+	return names
+}
+
+func TestGenerateTextSpan(t *testing.T) {
+	exporter, opts := setup()
+	provider := Wrap(&fakeProvider{}, opts...)
+
+	_, _, err := provider.GenerateText(context.Background(), "hello", llm.WithTemperature(0.25), llm.WithMaxTokens(64))
+	if err != nil {
+		t.Fatalf("GenerateText failed: %v", err)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	span := spans[0]
+	if span.Name != "chat fake-model" {
+		t.Errorf("span name = %q", span.Name)
+	}
+	values := attrs(span)
+	checks := map[attribute.Key]interface{}{
+		attrOperationName:      "chat",
+		attrSystem:             "tracing",
+		attrRequestModel:       "fake-model",
+		attrRequestTemperature: 0.25,
+		attrRequestMaxTokens:   int64(64),
+		attrResponseModel:      "fake-model-2024",
+		attrInputTokens:        int64(12),
+		attrOutputTokens:       int64(3),
+		attrCacheReadTokens:    int64(8),
+	}
+	for key, want := range checks {
+		if got := values[key].AsInterface(); got != want {
+			t.Errorf("%s = %v, want %v", key, got, want)
+		}
+	}
+	if reasons := values[attrFinishReasons].AsStringSlice(); len(reasons) != 1 || reasons[0] != "stop" {
+		t.Errorf("finish reasons = %v", reasons)
+	}
+	if len(span.Events) != 0 {
+		t.Errorf("content should not be captured by default, got events %v", eventNames(span))
+	}
+}
+
+func TestStreamSpanRecordsFirstTokenAndChunks(t *testing.T) {
+	exporter, opts := setup(WithSystem("openai"))
+	provider := Wrap(&fakeProvider{}, opts...)
+
+	out := make(chan llm.StreamChunk)
+	go func() {
+		for range out {
+		}
+	}()
+	if _, err := provider.GenerateTextStream(context.Background(), "hello", out); err != nil {
+		t.Fatalf("stream failed: %v", err)
+	}
+
+	span := exporter.GetSpans()[0]
+	values := attrs(span)
+	if values[attrSystem].AsString() != "openai" || !values[attrStreaming].AsBool() {
+		t.Errorf("unexpected attributes %v", values)
+	}
+	if values[attrTimeToFirstToken].AsFloat64() <= 0 {
+		t.Error("time to first token not recorded")
+	}
+	if values[attrResponseModel].AsString() != "fake-model" {
+		t.Errorf("response model should fall back to the request model, got %q", values[attrResponseModel].AsString())
+	}
+	names := eventNames(span)
+	if len(names) != 3 || names[0] != eventFirstToken || names[1] != eventChunk || names[2] != eventChunk {
+		t.Errorf("events = %v", names)
+	}
+}
+
+func TestContentCaptureIsOptIn(t *testing.T) {
+	exporter, opts := setup(WithContentCapture(true))
+	provider := Wrap(&fakeProvider{}, opts...)
+
+	provider.GenerateText(context.Background(), "hello", llm.WithSystem("be nice"))
+
+	span := exporter.GetSpans()[0]
+	content := map[string]string{}
+	for _, event := range span.Events {
+		for _, kv := range event.Attributes {
+			if kv.Key == attrContent {
+				content[event.Name] = kv.Value.AsString()
+			}
+		}
+	}
+	if content[eventSystemMessage] != "be nice" || content[eventUserMessage] != "hello" || content[eventChoice] != "hello back" {
+		t.Errorf("captured content = %v", content)
+	}
+}
+
+func TestErrorsAreClassified(t *testing.T) {
+	exporter, opts := setup()
+	apiErr := &llm.APIError{Provider: "fake", StatusCode: http.StatusTooManyRequests, Message: "slow down"}
+	provider := Wrap(&fakeProvider{err: apiErr}, opts...)
+
+	if _, _, err := provider.GenerateText(context.Background(), "hello"); err == nil {
+		t.Fatal("expected error")
+	}
+
+	span := exporter.GetSpans()[0]
+	if span.Status.Code != codes.Error {
+		t.Errorf("status = %v", span.Status)
+	}
+	if got := attrs(span)[attrErrorType].AsString(); got != string(llm.ErrorClassRateLimit) {
+		t.Errorf("error.type = %q", got)
+	}
+	if names := eventNames(span); len(names) != 1 || names[0] != "exception" {
+		t.Errorf("events = %v", names)
+	}
+}
@@ -0,0 +1,65 @@
+package transport
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/ulgerang/llm-module/logger"
+)
+
+// LogSink writes each exchange to log at info level: a summary line followed by the
+// exchange as JSON.
+func LogSink(log logger.Logger) Sink {
+	return SinkFunc(func(exchange *Exchange) {
+		data, err := json.Marshal(exchange)
+		if err != nil {
+			log.Error("[HTTP] Failed to encode exchange", err)
+			return
+		}
+		log.Infof("[HTTP] %s %s -> %d in %s\n%s", exchange.Method, exchange.URL, exchange.StatusCode, exchange.Duration, data)
+	})
+}
+
+// WriterSink writes each exchange to a writer as one JSON object per line.
+type WriterSink struct {
+	mu     sync.Mutex
+	w      io.Writer
+	closer io.Closer
+}
+
+// NewWriterSink writes exchanges to w.
+func NewWriterSink(w io.Writer) *WriterSink {
+	return &WriterSink{w: w}
+}
+
+// OpenFileSink appends exchanges to the file at path, creating it if needed.
+func OpenFileSink(path string) (*WriterSink, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open trace file: %w", err)
+	}
+	return &WriterSink{w: file, closer: file}, nil
+}
+
+// Record writes the exchange. Write errors are dropped, since tracing must not fail
+// the traced request.
+func (s *WriterSink) Record(exchange *Exchange) {
+	data, err := json.Marshal(exchange)
+	if err != nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.w.Write(append(data, '\n'))
+}
+
+// Close closes the file opened by OpenFileSink; it is a no-op for NewWriterSink.
+func (s *WriterSink) Close() error {
+	if s.closer == nil {
+		return nil
+	}
+	return s.closer.Close()
+}
@@ -0,0 +1,217 @@
+package transport
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ulgerang/llm-module/llm"
+	"github.com/ulgerang/llm-module/redact"
+)
+
+// maxCapturedBody caps the bytes of each request and response body kept in an Exchange.
+const maxCapturedBody = 1 << 20
+
+// Exchange is a traced HTTP round trip. Headers, bodies, frames and the URL are
+// redacted with the redact package before an Exchange reaches a sink.
+type Exchange struct {
+	Method         string        `json:"method"`
+	URL            string        `json:"url"`
+	RequestHeader  http.Header   `json:"request_header,omitempty"`
+	RequestBody    string        `json:"request_body,omitempty"`
+	StatusCode     int           `json:"status_code,omitempty"`
+	ResponseHeader http.Header   `json:"response_header,omitempty"`
+	ResponseBody   string        `json:"response_body,omitempty"`
+	Frames         []Frame       `json:"frames,omitempty"`
+	Truncated      bool          `json:"truncated,omitempty"`
+	Start          time.Time     `json:"start"`
+	TimeToHeaders  time.Duration `json:"time_to_headers"`
+	Duration       time.Duration `json:"duration"`
+	Error          string        `json:"error,omitempty"`
+}
+
+// Frame is one server-sent event of a streaming response. Offset is measured from the
+// start of the request.
+type Frame struct {
+	Offset time.Duration `json:"offset"`
+	Data   string        `json:"data"`
+}
+
+// Sink receives completed exchanges. Implementations must be safe for concurrent use.
+type Sink interface {
+	Record(exchange *Exchange)
+}
+
+// SinkFunc adapts a function to a Sink.
+type SinkFunc func(exchange *Exchange)
+
+// Record calls f.
+func (f SinkFunc) Record(exchange *Exchange) {
+	f(exchange)
+}
+
+// WithTrace records every HTTP exchange of a provider into sink. The exchange is
+// recorded once the response body has been read to the end or closed.
+func WithTrace(sink Sink) llm.ProviderOption {
+	return llm.WithRoundTripper(func(next http.RoundTripper) http.RoundTripper {
+		return NewTracer(next, sink)
+	})
+}
+
+// Tracer is an http.RoundTripper that records exchanges into a Sink.
+type Tracer struct {
+	next http.RoundTripper
+	sink Sink
+	now  func() time.Time
+}
+
+// NewTracer wraps next; a nil next uses http.DefaultTransport.
+func NewTracer(next http.RoundTripper, sink Sink) *Tracer {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &Tracer{next: next, sink: sink, now: time.Now}
+}
+
+// RoundTrip sends req and records the exchange.
+func (t *Tracer) RoundTrip(req *http.Request) (*http.Response, error) {
+	exchange := &Exchange{
+		Method:        req.Method,
+		URL:           redact.String(req.URL.String()),
+		RequestHeader: redact.Headers(req.Header),
+		Start:         t.now(),
+	}
+
+	if req.Body != nil && req.Body != http.NoBody {
+		data, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		exchange.RequestBody, exchange.Truncated = capture(data)
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(data))
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(data)), nil
+		}
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	exchange.TimeToHeaders = t.now().Sub(exchange.Start)
+	if err != nil {
+		exchange.Duration = exchange.TimeToHeaders
+		exchange.Error = redact.String(err.Error())
+		t.sink.Record(exchange)
+		return nil, err
+	}
+
+	exchange.StatusCode = resp.StatusCode
+	exchange.ResponseHeader = redact.Headers(resp.Header)
+	resp.Body = &tracedBody{
+		body:     resp.Body,
+		tracer:   t,
+		exchange: exchange,
+		sse:      strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream"),
+	}
+	return resp, nil
+}
+
+// tracedBody copies a response body into its exchange as it is read, splitting
+// event streams into frames, and records the exchange when the body ends.
+type tracedBody struct {
+	body     io.ReadCloser
+	tracer   *Tracer
+	exchange *Exchange
+	sse      bool
+
+	mu      sync.Mutex
+	data    bytes.Buffer
+	pending string
+	done    bool
+}
+
+func (b *tracedBody) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if n > 0 {
+		b.write(p[:n])
+	}
+	if err != nil {
+		b.finish(err)
+	}
+	return n, err
+}
+
+func (b *tracedBody) Close() error {
+	err := b.body.Close()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.finish(nil)
+	return err
+}
+
+func (b *tracedBody) write(p []byte) {
+	if b.done {
+		return
+	}
+	if !b.sse {
+		if room := maxCapturedBody - b.data.Len(); room < len(p) {
+			p = p[:max(room, 0)]
+			b.exchange.Truncated = true
+		}
+		b.data.Write(p)
+		return
+	}
+
+	b.pending += strings.ReplaceAll(string(p), "\r\n", "\n")
+	for {
+		end := strings.Index(b.pending, "\n\n")
+		if end < 0 {
+			break
+		}
+		b.addFrame(b.pending[:end])
+		b.pending = b.pending[end+2:]
+	}
+}
+
+func (b *tracedBody) addFrame(data string) {
+	if data == "" {
+		return
+	}
+	b.exchange.Frames = append(b.exchange.Frames, Frame{
+		Offset: b.tracer.now().Sub(b.exchange.Start),
+		Data:   redact.String(data),
+	})
+}
+
+// finish records the exchange once, on EOF, a read error or Close.
+func (b *tracedBody) finish(err error) {
+	if b.done {
+		return
+	}
+	b.done = true
+
+	if b.sse {
+		b.addFrame(strings.TrimRight(b.pending, "\n"))
+	} else {
+		b.exchange.ResponseBody = redact.String(b.data.String())
+	}
+	if err != nil && err != io.EOF {
+		b.exchange.Error = redact.String(err.Error())
+	}
+	b.exchange.Duration = b.tracer.now().Sub(b.exchange.Start)
+	b.tracer.sink.Record(b.exchange)
+}
+
+// capture returns data as a redacted string, cut to maxCapturedBody bytes.
+func capture(data []byte) (string, bool) {
+	truncated := len(data) > maxCapturedBody
+	if truncated {
+		data = data[:maxCapturedBody]
+	}
+	return redact.String(string(data)), truncated
+}
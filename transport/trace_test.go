@@ -0,0 +1,152 @@
+package transport
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ulgerang/llm-module/llm"
+)
+
+const testKey = "sk-proj-abcdefghijklmnopqrstuvwxyz012345"
+
+type recordingSink struct {
+	mu        sync.Mutex
+	exchanges []*Exchange
+}
+
+func (s *recordingSink) Record(exchange *Exchange) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.exchanges = append(s.exchanges, exchange)
+}
+
+func tracedClient(sink Sink) *http.Client {
+	client, _ := NewClient(llm.ResolveProviderOptions(WithTrace(sink)), nil)
+	return client
+}
+
+func TestTracerCapturesJSONExchange(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != `{"model":"m"}` {
+			t.Errorf("server received %q", body)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session=abc")
+		fmt.Fprint(w, `{"id":"1","echo":"`+testKey+`"}`)
+	}))
+	defer server.Close()
+
+	sink := &recordingSink{}
+	req, _ := http.NewRequest(http.MethodPost, server.URL+"/chat", strings.NewReader(`{"model":"m"}`))
+	req.Header.Set("Authorization", "Bearer "+testKey)
+	resp, err := tracedClient(sink).Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), testKey) {
+		t.Error("tracing must not change the body seen by the caller")
+	}
+
+	if len(sink.exchanges) != 1 {
+		t.Fatalf("expected 1 exchange, got %d", len(sink.exchanges))
+	}
+	exchange := sink.exchanges[0]
+	if exchange.Method != http.MethodPost || exchange.StatusCode != 200 || exchange.RequestBody != `{"model":"m"}` {
+		t.Errorf("unexpected exchange %+v", exchange)
+	}
+	if !strings.HasPrefix(exchange.ResponseBody, `{"id":"1"`) || exchange.Duration < exchange.TimeToHeaders {
+		t.Errorf("unexpected exchange %+v", exchange)
+	}
+	encoded, _ := json.Marshal(exchange)
+	if strings.Contains(string(encoded), testKey) || strings.Contains(string(encoded), "session=abc") {
+		t.Errorf("exchange leaks credentials: %s", encoded)
+	}
+}
+
+func TestTracerSplitsEventStreamFrames(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		flusher := w.(http.Flusher)
+		for _, frame := range []string{"data: {\"delta\":\"Hel\"}\n\n", "data: {\"delta\":\"lo\"}\r\n\r\n", "data: [DONE]\n\n"} {
+			fmt.Fprint(w, frame)
+			flusher.Flush()
+		}
+	}))
+	defer server.Close()
+
+	sink := &recordingSink{}
+	resp, err := tracedClient(sink).Get(server.URL)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	if len(sink.exchanges) != 1 {
+		t.Fatalf("expected 1 exchange, got %d", len(sink.exchanges))
+	}
+	frames := sink.exchanges[0].Frames
+	if len(frames) != 3 || frames[0].Data != `data: {"delta":"Hel"}` || frames[2].Data != "data: [DONE]" {
+		t.Errorf("unexpected frames %+v", frames)
+	}
+	if sink.exchanges[0].ResponseBody != "" {
+		t.Error("event streams should be captured as frames only")
+	}
+}
+
+type failingTransport struct{}
+
+func (failingTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, errors.New("dial failed for api_key=" + testKey)
+}
+
+func TestTracerRecordsTransportErrors(t *testing.T) {
+	sink := &recordingSink{}
+	client := &http.Client{Transport: NewTracer(failingTransport{}, sink)}
+	if _, err := client.Get("https://example.invalid/v1?key=" + testKey); err == nil {
+		t.Fatal("expected an error")
+	}
+	if len(sink.exchanges) != 1 || sink.exchanges[0].Error == "" {
+		t.Fatalf("expected a failed exchange, got %+v", sink.exchanges)
+	}
+	if exchange := sink.exchanges[0]; strings.Contains(exchange.Error+exchange.URL, testKey) {
+		t.Errorf("exchange leaks the key: %+v", exchange)
+	}
+}
+
+func TestWriterSinks(t *testing.T) {
+	var buf bytes.Buffer
+	NewWriterSink(&buf).Record(&Exchange{Method: "GET", URL: "https://example.com"})
+	var decoded Exchange
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil || decoded.URL != "https://example.com" {
+		t.Errorf("unexpected line %q: %v", buf.String(), err)
+	}
+
+	path := filepath.Join(t.TempDir(), "trace.jsonl")
+	sink, err := OpenFileSink(path)
+	if err != nil {
+		t.Fatalf("OpenFileSink failed: %v", err)
+	}
+	sink.Record(&Exchange{Method: "POST"})
+	sink.Record(&Exchange{Method: "GET"})
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if lines := strings.Split(strings.TrimSpace(string(data)), "\n"); len(lines) != 2 {
+		t.Errorf("expected 2 lines, got %q", data)
+	}
+}
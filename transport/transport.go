@@ -0,0 +1,187 @@
+// Package transport builds the HTTP clients used by providers from llm.ProviderConfig
+// and provides wire-level tracing of their requests and responses.
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ulgerang/llm-module/llm"
+	"github.com/ulgerang/llm-module/redact"
+)
+
+// NewClient builds the client a provider should use from its default client base and
+// config. config.HTTPClient replaces base, config.Transport replaces its transport,
+// proxy, TLS, pool, connect and first-byte settings are applied to a clone of the
+// *http.Transport, the stream inactivity watchdog wraps it, the RoundTrippers wrap the
+// result, the credential source sets the API key and default headers are set before
+// everything else. A nil base starts from
+// http.DefaultTransport without a timeout. When config sets nothing, base is returned
+// as is, so a nil base keeps an SDK's default client. Neither base nor
+// config.HTTPClient is modified.
+func NewClient(config *llm.ProviderConfig, base *http.Client) (*http.Client, error) {
+	if config == nil {
+		return base, nil
+	}
+	if config.HTTPClient != nil {
+		base = config.HTTPClient
+	}
+	timeouts := llm.Timeouts{}
+	if config.Timeouts != nil {
+		timeouts = *config.Timeouts
+	}
+	tuned := config.Proxy != nil || config.TLSConfig != nil || config.Pool != nil ||
+		timeouts.Connect > 0 || timeouts.FirstByte > 0
+	if config.Transport == nil && !tuned && len(config.RoundTrippers) == 0 &&
+		len(config.Headers) == 0 && config.Credentials == nil && timeouts.Total == 0 && timeouts.StreamIdle == 0 {
+		return base, nil
+	}
+
+	client := &http.Client{}
+	if base != nil {
+		*client = *base
+	}
+	rt := client.Transport
+	if config.Transport != nil {
+		rt = config.Transport
+	}
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+
+	if tuned {
+		httpTransport, ok := rt.(*http.Transport)
+		if !ok {
+			return nil, errors.New("proxy, TLS, connection pool, connect and first-byte timeout settings need an *http.Transport")
+		}
+		httpTransport = httpTransport.Clone()
+		if config.Proxy != nil {
+			httpTransport.Proxy = config.Proxy
+		}
+		if config.TLSConfig != nil {
+			httpTransport.TLSClientConfig = config.TLSConfig.Clone()
+		}
+		if pool := config.Pool; pool != nil {
+			if pool.MaxIdleConns > 0 {
+				httpTransport.MaxIdleConns = pool.MaxIdleConns
+			}
+			if pool.MaxIdleConnsPerHost > 0 {
+				httpTransport.MaxIdleConnsPerHost = pool.MaxIdleConnsPerHost
+			}
+			if pool.MaxConnsPerHost > 0 {
+				httpTransport.MaxConnsPerHost = pool.MaxConnsPerHost
+			}
+			if pool.IdleConnTimeout > 0 {
+				httpTransport.IdleConnTimeout = pool.IdleConnTimeout
+			}
+		}
+		if timeouts.Connect > 0 {
+			httpTransport.DialContext = (&net.Dialer{Timeout: timeouts.Connect, KeepAlive: 30 * time.Second}).DialContext
+			httpTransport.TLSHandshakeTimeout = timeouts.Connect
+		}
+		if timeouts.FirstByte > 0 {
+			httpTransport.ResponseHeaderTimeout = timeouts.FirstByte
+		}
+		rt = httpTransport
+	}
+	if timeouts.Total > 0 {
+		client.Timeout = timeouts.Total
+	}
+	if timeouts.StreamIdle > 0 {
+		rt = &idleWatchdog{next: rt, timeout: timeouts.StreamIdle}
+	}
+
+	for i := len(config.RoundTrippers) - 1; i >= 0; i-- {
+		rt = config.RoundTrippers[i](rt)
+	}
+	if config.Credentials != nil {
+		rt = &credentialTransport{next: rt, source: config.Credentials, now: time.Now}
+	}
+	// Headers are set outermost so traced exchanges show them.
+	if len(config.Headers) > 0 {
+		rt = &headerTransport{next: rt, headers: config.Headers}
+	}
+	client.Transport = rt
+	return client, nil
+}
+
+// headerTransport sets fixed headers on every request.
+type headerTransport struct {
+	next    http.RoundTripper
+	headers http.Header
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for name, values := range t.headers {
+		req.Header[name] = values
+	}
+	return t.next.RoundTrip(req)
+}
+
+// secretRetention is how long a key the credential source no longer returns stays
+// registered for redaction, so that logs of requests still using it are scrubbed.
+const secretRetention = time.Hour
+
+// credentialTransport sets the API key from a credential source on every request and
+// reports the response status back to it. The key goes into the header the provider
+// authenticates with, X-Api-Key or X-Goog-Api-Key when present and a bearer
+// Authorization header otherwise.
+type credentialTransport struct {
+	next   http.RoundTripper
+	source llm.CredentialSource
+	now    func() time.Time
+
+	mu sync.Mutex
+	// keys holds when each key registered for redaction was last used.
+	keys map[string]time.Time
+}
+
+func (t *credentialTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	key, err := t.source.Credential(req.Context())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get credential: %w", err)
+	}
+	t.register(key)
+
+	req = req.Clone(req.Context())
+	switch {
+	case req.Header.Get("X-Api-Key") != "":
+		req.Header.Set("X-Api-Key", key)
+	case req.Header.Get("X-Goog-Api-Key") != "":
+		req.Header.Set("X-Goog-Api-Key", key)
+	default:
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if reporter, ok := t.source.(llm.CredentialReporter); ok && err == nil {
+		reporter.Report(key, resp.StatusCode)
+	}
+	return resp, err
+}
+
+// register registers key for redaction the first time it is used and unregisters keys
+// that have not been used for secretRetention, such as keys that were rotated out.
+func (t *credentialTransport) register(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.now()
+	if t.keys == nil {
+		t.keys = make(map[string]time.Time)
+	}
+	if _, ok := t.keys[key]; !ok {
+		redact.AddSecret(key)
+	}
+	t.keys[key] = now
+	for old, used := range t.keys {
+		if now.Sub(used) > secretRetention {
+			delete(t.keys, old)
+			redact.RemoveSecret(old)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package transport
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/ulgerang/llm-module/llm"
+	"github.com/ulgerang/llm-module/redact"
+)
+
+type stubTransport struct {
+	requests int
+}
+
+func (t *stubTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.requests++
+	return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+}
+
+func TestNewClientKeepsUnconfiguredBase(t *testing.T) {
+	if client, err := NewClient(&llm.ProviderConfig{}, nil); client != nil || err != nil {
+		t.Error("an empty config should keep the SDK default client")
+	}
+	base := &http.Client{Timeout: 5 * time.Second}
+	client, err := NewClient(llm.ResolveProviderOptions(WithTrace(&recordingSink{})), base)
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	if client == base || client.Timeout != 5*time.Second || base.Transport != nil {
+		t.Error("NewClient should copy base and leave it unmodified")
+	}
+}
+
+func TestNewClientAppliesTransportSettings(t *testing.T) {
+	proxyURL, _ := url.Parse("http://proxy.internal:3128")
+	tlsConfig := &tls.Config{ServerName: "api.internal", MinVersion: tls.VersionTLS13}
+	config := llm.ResolveProviderOptions(
+		llm.WithProxy(http.ProxyURL(proxyURL)),
+		llm.WithTLSConfig(tlsConfig),
+		llm.WithConnectionPool(llm.ConnectionPool{MaxIdleConnsPerHost: 32, IdleConnTimeout: time.Minute}),
+	)
+	base := &http.Client{Timeout: time.Minute}
+
+	client, err := NewClient(config, base)
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	httpTransport, ok := client.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("expected an *http.Transport, got %T", client.Transport)
+	}
+	req, _ := http.NewRequest(http.MethodGet, "https://api.example.com", nil)
+	if got, _ := httpTransport.Proxy(req); got.String() != proxyURL.String() {
+		t.Errorf("proxy = %v", got)
+	}
+	if httpTransport.TLSClientConfig.ServerName != "api.internal" || httpTransport.TLSClientConfig == tlsConfig {
+		t.Error("TLS config should be a clone of the configured one")
+	}
+	if httpTransport.MaxIdleConnsPerHost != 32 || httpTransport.IdleConnTimeout != time.Minute {
+		t.Errorf("pool settings not applied: %d %s", httpTransport.MaxIdleConnsPerHost, httpTransport.IdleConnTimeout)
+	}
+	if http.DefaultTransport.(*http.Transport).MaxIdleConnsPerHost == 32 {
+		t.Error("http.DefaultTransport must not be modified")
+	}
+}
+
+func TestNewClientUsesInjectedClientAndTransport(t *testing.T) {
+	stub := &stubTransport{}
+	injected := &http.Client{Timeout: 3 * time.Second}
+	client, err := NewClient(llm.ResolveProviderOptions(llm.WithHTTPClient(injected), llm.WithTransport(stub)), &http.Client{Timeout: time.Minute})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	if client.Timeout != 3*time.Second || injected.Transport != nil {
+		t.Errorf("expected a copy of the injected client, got %+v", client)
+	}
+	if _, err := client.Get("http://example.com"); err != nil || stub.requests != 1 {
+		t.Errorf("request did not go through the injected transport: %v", err)
+	}
+
+	if _, err := NewClient(llm.ResolveProviderOptions(llm.WithTransport(stub), llm.WithTLSConfig(&tls.Config{})), nil); err == nil {
+		t.Error("TLS settings on a custom RoundTripper should fail")
+	}
+}
+
+type rotatingSource struct{ key string }
+
+func (s *rotatingSource) Credential(context.Context) (string, error) { return s.key, nil }
+
+func TestCredentialTransportUnregistersRotatedKeys(t *testing.T) {
+	oldKey, newKey := "rotated-out-key-0123456789", "rotated-in-key-9876543210"
+	source := &rotatingSource{key: oldKey}
+	now := time.Now()
+	rt := &credentialTransport{next: &stubTransport{}, source: source, now: func() time.Time { return now }}
+	send := func() {
+		req, _ := http.NewRequest(http.MethodGet, "https://api.example.com/v1/models", nil)
+		if _, err := rt.RoundTrip(req); err != nil {
+			t.Fatalf("RoundTrip failed: %v", err)
+		}
+	}
+
+	send()
+	if got := redact.String(oldKey); got == oldKey {
+		t.Fatal("the key in use should be redacted")
+	}
+
+	source.key = newKey
+	now = now.Add(time.Minute)
+	send()
+	if got := redact.String(oldKey); got == oldKey {
+		t.Error("a recently rotated key should stay redacted")
+	}
+
+	now = now.Add(2 * secretRetention)
+	send()
+	if got := redact.String(oldKey); got != oldKey {
+		t.Errorf("a key unused for the retention period should be unregistered, got %q", got)
+	}
+	if got := redact.String(newKey); got == newKey {
+		t.Error("the current key should stay redacted")
+	}
+}
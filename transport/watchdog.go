@@ -0,0 +1,73 @@
+package transport
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ulgerang/llm-module/llm"
+)
+
+// idleWatchdog is an http.RoundTripper that aborts server-sent event streams which
+// send no data for longer than timeout.
+type idleWatchdog struct {
+	next    http.RoundTripper
+	timeout time.Duration
+}
+
+func (w *idleWatchdog) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := w.next.RoundTrip(req)
+	if err != nil || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		return resp, err
+	}
+	resp.Body = newIdleBody(resp.Body, w.timeout)
+	return resp, nil
+}
+
+// idleBody closes the underlying body when no data has been read for timeout. Reads
+// then fail with a *llm.StreamStallError instead of the error of the closed body.
+type idleBody struct {
+	body    io.ReadCloser
+	timeout time.Duration
+	timer   *time.Timer
+
+	mu      sync.Mutex
+	stalled bool
+}
+
+func newIdleBody(body io.ReadCloser, timeout time.Duration) *idleBody {
+	b := &idleBody{body: body, timeout: timeout}
+	b.timer = time.AfterFunc(timeout, b.stall)
+	return b
+}
+
+func (b *idleBody) stall() {
+	b.mu.Lock()
+	b.stalled = true
+	b.mu.Unlock()
+	b.body.Close()
+}
+
+func (b *idleBody) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+	if n > 0 && err == nil {
+		b.timer.Reset(b.timeout)
+	}
+	if err != nil {
+		b.timer.Stop()
+		b.mu.Lock()
+		stalled := b.stalled
+		b.mu.Unlock()
+		if stalled {
+			return n, &llm.StreamStallError{Timeout: b.timeout}
+		}
+	}
+	return n, err
+}
+
+func (b *idleBody) Close() error {
+	b.timer.Stop()
+	return b.body.Close()
+}
@@ -0,0 +1,91 @@
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ulgerang/llm-module/llm"
+)
+
+// stallingServer sends one event and then nothing until the client goes away.
+func stallingServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: first\n\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+}
+
+func TestStreamIdleTimeoutAbortsStalledStream(t *testing.T) {
+	server := stallingServer()
+	defer server.Close()
+
+	client, err := NewClient(llm.ResolveProviderOptions(llm.WithTimeouts(llm.Timeouts{StreamIdle: 50 * time.Millisecond})), nil)
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	start := time.Now()
+	data, err := io.ReadAll(resp.Body)
+	var stall *llm.StreamStallError
+	if !errors.As(err, &stall) || stall.Timeout != 50*time.Millisecond {
+		t.Fatalf("expected a StreamStallError, got %v", err)
+	}
+	if string(data) != "data: first\n\n" || time.Since(start) > 2*time.Second {
+		t.Errorf("unexpected data %q after %s", data, time.Since(start))
+	}
+	if llm.ClassifyError(err) != llm.ErrorClassTimeout {
+		t.Errorf("ClassifyError = %q", llm.ClassifyError(err))
+	}
+}
+
+func TestStreamIdleTimeoutIgnoresOtherResponses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(80 * time.Millisecond)
+		fmt.Fprint(w, `{"ok":true}`)
+	}))
+	defer server.Close()
+
+	client, _ := NewClient(llm.ResolveProviderOptions(llm.WithTimeouts(llm.Timeouts{StreamIdle: 20 * time.Millisecond})), nil)
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if data, err := io.ReadAll(resp.Body); err != nil || string(data) != `{"ok":true}` {
+		t.Errorf("unexpected body %q: %v", data, err)
+	}
+}
+
+func TestFirstByteAndConnectTimeouts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClient(llm.ResolveProviderOptions(llm.WithTimeouts(llm.Timeouts{Connect: time.Second, FirstByte: 30 * time.Millisecond, Total: time.Minute})), nil)
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	httpTransport := client.Transport.(*http.Transport)
+	if httpTransport.TLSHandshakeTimeout != time.Second || client.Timeout != time.Minute {
+		t.Errorf("timeouts not applied: %s %s", httpTransport.TLSHandshakeTimeout, client.Timeout)
+	}
+	if _, err := client.Get(server.URL); err == nil || llm.ClassifyError(err) != llm.ErrorClassTimeout {
+		t.Errorf("expected a first-byte timeout, got %v", err)
+	}
+}
@@ -29,7 +29,8 @@ func GetLangName(code string) string {
 }
 
 // ExtractJSONFromString attempts to extract the first valid JSON payload from a string.
-// It supports markdown ```json code fences``` and raw JSON strings.
+// It supports markdown ```json code fences``` and raw JSON strings, falling back to
+// RepairJSON for malformed or truncated payloads that start with an object or array.
 func ExtractJSONFromString(s string) (string, error) {
 	re := regexp.MustCompile("(?s)```json\\s*(.*?)\\s*```")
 	matches := re.FindStringSubmatch(s)
@@ -51,11 +52,16 @@ func ExtractJSONFromString(s string) (string, error) {
 		}
 	}
 
+	if repaired, ok := repairJSONReply(s); ok {
+		return repaired, nil
+	}
+
 	return "", errors.New("no valid JSON found in the string or markdown block")
 }
 
 // ExtractValidJSON returns the most likely JSON fragment from a free-form string.
-// If no valid JSON is found, the trimmed original string is returned.
+// Malformed or truncated JSON that starts with an object or array is repaired with
+// RepairJSON when possible; otherwise the trimmed original string is returned.
 func ExtractValidJSON(raw string) string {
 	raw = strings.TrimSpace(raw)
 
@@ -134,9 +140,28 @@ func ExtractValidJSON(raw string) string {
 		}
 	}
 
+	if repaired, ok := repairJSONReply(raw); ok {
+		return repaired
+	}
+
 	return raw
 }
 
+// repairJSONReply repairs s with RepairJSON when its trimmed text, or the body of its
+// fenced code block, starts with a JSON object or array. Prose that merely contains
+// brackets, such as "Hello {name}" or a refusal citing "[policy]", is not turned into JSON.
+func repairJSONReply(s string) (string, bool) {
+	body := strings.TrimSpace(s)
+	if match := fencedBlockRe.FindStringSubmatch(body); match != nil {
+		body = strings.TrimSpace(match[1])
+	}
+	if body == "" || (body[0] != '{' && body[0] != '[') || !looksLikeJSONStart(body, 0) {
+		return "", false
+	}
+	repaired, _, err := RepairJSON(s)
+	return repaired, err == nil
+}
+
 // SafeUnmarshalJSON extracts JSON from a string and unmarshals it into the provided destination.
 func SafeUnmarshalJSON(data string, v interface{}) error {
 	extracted := ExtractValidJSON(data)
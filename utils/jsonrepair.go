@@ -0,0 +1,656 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// RepairKind identifies a class of fix applied by RepairJSON.
+type RepairKind string
+
+const (
+	RepairCodeFence          RepairKind = "code_fence"
+	RepairSurroundingText    RepairKind = "surrounding_text"
+	RepairComment            RepairKind = "comment"
+	RepairTrailingComma      RepairKind = "trailing_comma"
+	RepairMissingComma       RepairKind = "missing_comma"
+	RepairMissingColon       RepairKind = "missing_colon"
+	RepairSingleQuotes       RepairKind = "single_quotes"
+	RepairUnquotedKey        RepairKind = "unquoted_key"
+	RepairUnquotedString     RepairKind = "unquoted_string"
+	RepairControlCharacter   RepairKind = "control_character"
+	RepairInvalidEscape      RepairKind = "invalid_escape"
+	RepairInvalidNumber      RepairKind = "invalid_number"
+	RepairNonStandardLiteral RepairKind = "non_standard_literal"
+	RepairUnterminatedString RepairKind = "unterminated_string"
+	RepairMissingValue       RepairKind = "missing_value"
+	RepairUnclosedContainer  RepairKind = "unclosed_container"
+	RepairMismatchedBracket  RepairKind = "mismatched_bracket"
+)
+
+// Repair records a single fix and the byte offset in the input where it was applied.
+type Repair struct {
+	Kind   RepairKind
+	Offset int
+}
+
+// RepairReport lists every fix RepairJSON applied to produce valid JSON.
+type RepairReport struct {
+	Repairs []Repair
+}
+
+// Repaired reports whether any fix was applied.
+func (r *RepairReport) Repaired() bool {
+	return r != nil && len(r.Repairs) > 0
+}
+
+// Has reports whether a fix of the given kind was applied.
+func (r *RepairReport) Has(kind RepairKind) bool {
+	if r == nil {
+		return false
+	}
+	for _, repair := range r.Repairs {
+		if repair.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// Kinds returns the distinct repair kinds in the order they were first applied.
+func (r *RepairReport) Kinds() []RepairKind {
+	if r == nil {
+		return nil
+	}
+	seen := make(map[RepairKind]bool)
+	var kinds []RepairKind
+	for _, repair := range r.Repairs {
+		if !seen[repair.Kind] {
+			seen[repair.Kind] = true
+			kinds = append(kinds, repair.Kind)
+		}
+	}
+	return kinds
+}
+
+func (r *RepairReport) String() string {
+	if !r.Repaired() {
+		return "no repairs"
+	}
+	parts := make([]string, 0, len(r.Repairs))
+	for _, repair := range r.Repairs {
+		parts = append(parts, fmt.Sprintf("%s@%d", repair.Kind, repair.Offset))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (r *RepairReport) add(kind RepairKind, offset int) {
+	r.Repairs = append(r.Repairs, Repair{Kind: kind, Offset: offset})
+}
+
+// JSONBlock is a JSON value found in a larger response by ExtractJSONBlocks.
+type JSONBlock struct {
+	JSON   string
+	Start  int
+	End    int
+	Report *RepairReport
+}
+
+// ErrNoJSON is returned when the input contains no JSON object or array.
+var ErrNoJSON = errors.New("no JSON object or array found")
+
+const maxRepairDepth = 1000
+
+var fencedBlockRe = regexp.MustCompile("(?s)```[a-zA-Z0-9_-]*[ \t]*\r?\n?(.*?)(?:```|$)")
+
+// RepairJSON extracts the first JSON object or array from raw model output and repairs
+// common defects: markdown fences, surrounding prose, comments, trailing or missing
+// commas, single quotes, unquoted keys, Python literals, invalid numbers, and values
+// truncated by a token limit (unterminated strings, arrays and objects are closed).
+// The returned report lists every fix that was applied; it is empty for valid input.
+func RepairJSON(raw string) (string, *RepairReport, error) {
+	report := &RepairReport{}
+	trimmed := strings.TrimSpace(raw)
+	if isJSONContainer(trimmed) && json.Valid([]byte(trimmed)) {
+		return trimmed, report, nil
+	}
+
+	text, base := trimmed, strings.Index(raw, trimmed)
+	if match := fencedBlockRe.FindStringSubmatchIndex(trimmed); match != nil && strings.ContainsAny(trimmed[match[2]:match[3]], "{[") {
+		report.add(RepairCodeFence, base+match[0])
+		text = trimmed[match[2]:match[3]]
+		base += match[2]
+	}
+
+	start := strings.IndexAny(text, "{[")
+	if start < 0 {
+		return "", report, ErrNoJSON
+	}
+	if strings.TrimSpace(text[:start]) != "" {
+		report.add(RepairSurroundingText, base)
+	}
+
+	repaired, end, err := repairValueAt(text, start, base, report)
+	if err != nil {
+		return "", report, err
+	}
+	if strings.TrimSpace(text[end:]) != "" {
+		report.add(RepairSurroundingText, base+end)
+	}
+	return repaired, report, nil
+}
+
+// ExtractJSONBlocks returns every JSON object or array found in raw, repairing each one.
+// Fenced code blocks are preferred; without fences the text is scanned for top-level values.
+func ExtractJSONBlocks(raw string) []JSONBlock {
+	var blocks []JSONBlock
+
+	fences := fencedBlockRe.FindAllStringSubmatchIndex(raw, -1)
+	for _, match := range fences {
+		body := raw[match[2]:match[3]]
+		start := strings.IndexAny(body, "{[")
+		if start < 0 {
+			continue
+		}
+		report := &RepairReport{}
+		report.add(RepairCodeFence, match[0])
+		repaired, _, err := repairValueAt(body, start, match[2], report)
+		if err != nil {
+			continue
+		}
+		if len(report.Repairs) == 1 && json.Valid([]byte(strings.TrimSpace(body))) {
+			report = &RepairReport{}
+		}
+		blocks = append(blocks, JSONBlock{JSON: repaired, Start: match[0], End: match[1], Report: report})
+	}
+	if len(blocks) > 0 {
+		return blocks
+	}
+
+	for pos := 0; pos < len(raw); {
+		offset := strings.IndexAny(raw[pos:], "{[")
+		if offset < 0 {
+			break
+		}
+		start := pos + offset
+		if !looksLikeJSONStart(raw, start) {
+			pos = start + 1
+			continue
+		}
+		report := &RepairReport{}
+		repaired, end, err := repairValueAt(raw, start, 0, report)
+		if err != nil || end <= start {
+			pos = start + 1
+			continue
+		}
+		blocks = append(blocks, JSONBlock{JSON: repaired, Start: start, End: end, Report: report})
+		pos = end
+	}
+	return blocks
+}
+
+func isJSONContainer(s string) bool {
+	return (strings.HasPrefix(s, "{") && strings.HasSuffix(s, "}")) ||
+		(strings.HasPrefix(s, "[") && strings.HasSuffix(s, "]"))
+}
+
+// looksLikeJSONStart rejects prose such as "[note]" or "{placeholder}" when scanning
+// unfenced text for JSON values.
+func looksLikeJSONStart(s string, start int) bool {
+	for i := start + 1; i < len(s); i++ {
+		c := s[i]
+		if isJSONSpace(c) {
+			continue
+		}
+		if s[start] == '{' {
+			return c == '"' || c == '\'' || c == '}'
+		}
+		return c == '"' || c == '\'' || c == '{' || c == '[' || c == ']' || c == '-' || (c >= '0' && c <= '9') ||
+			strings.HasPrefix(s[i:], "true") || strings.HasPrefix(s[i:], "false") || strings.HasPrefix(s[i:], "null")
+	}
+	return false
+}
+
+func repairValueAt(text string, start, base int, report *RepairReport) (string, int, error) {
+	r := &jsonRepairer{src: text, pos: start, base: base, report: report}
+	if err := r.value(0); err != nil {
+		return "", r.pos, err
+	}
+	out := r.out.String()
+	if !json.Valid([]byte(out)) {
+		return "", r.pos, fmt.Errorf("unable to repair JSON: %s", truncateForError(out))
+	}
+	return out, r.pos, nil
+}
+
+func truncateForError(s string) string {
+	const limit = 80
+	if len(s) <= limit {
+		return s
+	}
+	return s[:limit] + "..."
+}
+
+type jsonRepairer struct {
+	src    string
+	pos    int
+	base   int
+	out    strings.Builder
+	report *RepairReport
+}
+
+func (r *jsonRepairer) eof() bool { return r.pos >= len(r.src) }
+
+func (r *jsonRepairer) peek() byte { return r.src[r.pos] }
+
+func (r *jsonRepairer) note(kind RepairKind) { r.report.add(kind, r.base+r.pos) }
+
+// skip consumes whitespace and comments, copying whitespace to the output.
+func (r *jsonRepairer) skip() { r.skipComments(true) }
+
+// skipToValue is skip for value position, where '#' starts a bare value such as a
+// colour code rather than a comment.
+func (r *jsonRepairer) skipToValue() { r.skipComments(false) }
+
+func (r *jsonRepairer) skipComments(hash bool) {
+	for !r.eof() {
+		c := r.peek()
+		switch {
+		case isJSONSpace(c):
+			r.out.WriteByte(c)
+			r.pos++
+		case strings.HasPrefix(r.src[r.pos:], "//") || (hash && c == '#'):
+			r.note(RepairComment)
+			end := strings.IndexByte(r.src[r.pos:], '\n')
+			if end < 0 {
+				r.pos = len(r.src)
+			} else {
+				r.pos += end
+			}
+		case strings.HasPrefix(r.src[r.pos:], "/*"):
+			r.note(RepairComment)
+			end := strings.Index(r.src[r.pos+2:], "*/")
+			if end < 0 {
+				r.pos = len(r.src)
+			} else {
+				r.pos += end + 4
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (r *jsonRepairer) value(depth int) error {
+	if depth > maxRepairDepth {
+		return errors.New("JSON nesting too deep to repair")
+	}
+	r.skipToValue()
+	if r.eof() {
+		r.note(RepairMissingValue)
+		r.out.WriteString("null")
+		return nil
+	}
+
+	switch c := r.peek(); {
+	case c == '{':
+		return r.object(depth)
+	case c == '[':
+		return r.array(depth)
+	case c == '"' || c == '\'' || strings.HasPrefix(r.src[r.pos:], "“"):
+		r.str()
+	case c == '-' || c == '+' || c == '.' || (c >= '0' && c <= '9'):
+		r.number()
+	case c == '}' || c == ']' || c == ',' || c == ':':
+		r.note(RepairMissingValue)
+		r.out.WriteString("null")
+	default:
+		r.word()
+	}
+	return nil
+}
+
+func (r *jsonRepairer) object(depth int) error {
+	r.out.WriteByte('{')
+	r.pos++
+	first := true
+	for {
+		r.skip()
+		if r.eof() {
+			r.note(RepairUnclosedContainer)
+			r.out.WriteByte('}')
+			return nil
+		}
+		c := r.peek()
+		switch c {
+		case '}':
+			r.out.WriteByte('}')
+			r.pos++
+			return nil
+		case ']':
+			r.note(RepairMismatchedBracket)
+			r.out.WriteByte('}')
+			r.pos++
+			return nil
+		case ',':
+			r.note(RepairTrailingComma)
+			r.pos++
+			continue
+		}
+
+		if !first {
+			r.out.WriteByte(',')
+		}
+		first = false
+
+		if err := r.key(); err != nil {
+			return err
+		}
+		r.skip()
+		if !r.eof() && r.peek() == ':' {
+			r.pos++
+		} else {
+			r.note(RepairMissingColon)
+		}
+		r.out.WriteByte(':')
+		if err := r.value(depth + 1); err != nil {
+			return err
+		}
+
+		r.skip()
+		if r.eof() {
+			continue
+		}
+		switch r.peek() {
+		case ',':
+			r.pos++
+			r.skip()
+			if !r.eof() && r.peek() == '}' {
+				r.note(RepairTrailingComma)
+			}
+		case '}', ']':
+		default:
+			r.note(RepairMissingComma)
+		}
+	}
+}
+
+func (r *jsonRepairer) key() error {
+	c := r.peek()
+	if c == '"' || c == '\'' || strings.HasPrefix(r.src[r.pos:], "“") {
+		r.str()
+		return nil
+	}
+
+	r.note(RepairUnquotedKey)
+	start := r.pos
+	for !r.eof() {
+		c := r.peek()
+		if c == ':' || c == ',' || c == '}' || c == ']' || c == '{' || c == '[' || isJSONSpace(c) {
+			break
+		}
+		r.pos++
+	}
+	if r.pos == start {
+		// A stray character that cannot begin a key; consume it so parsing always advances.
+		r.pos++
+	}
+	r.writeQuoted(r.src[start:r.pos])
+	return nil
+}
+
+func (r *jsonRepairer) array(depth int) error {
+	r.out.WriteByte('[')
+	r.pos++
+	first := true
+	for {
+		r.skip()
+		if r.eof() {
+			r.note(RepairUnclosedContainer)
+			r.out.WriteByte(']')
+			return nil
+		}
+		switch r.peek() {
+		case ']':
+			r.out.WriteByte(']')
+			r.pos++
+			return nil
+		case '}':
+			r.note(RepairMismatchedBracket)
+			r.out.WriteByte(']')
+			r.pos++
+			return nil
+		case ',':
+			r.note(RepairTrailingComma)
+			r.pos++
+			continue
+		case ':':
+			r.note(RepairMissingComma)
+			r.pos++
+			continue
+		}
+
+		if !first {
+			r.out.WriteByte(',')
+		}
+		first = false
+		if err := r.value(depth + 1); err != nil {
+			return err
+		}
+
+		r.skip()
+		if r.eof() {
+			continue
+		}
+		switch r.peek() {
+		case ',':
+			r.pos++
+			r.skip()
+			if !r.eof() && r.peek() == ']' {
+				r.note(RepairTrailingComma)
+			}
+		case ']', '}':
+		default:
+			r.note(RepairMissingComma)
+		}
+	}
+}
+
+// str copies a quoted string, normalising single or typographic quotes to double quotes.
+func (r *jsonRepairer) str() {
+	var closing string
+	switch {
+	case r.peek() == '"':
+		closing = "\""
+		r.pos++
+	case r.peek() == '\'':
+		r.note(RepairSingleQuotes)
+		closing = "'"
+		r.pos++
+	default:
+		r.note(RepairSingleQuotes)
+		closing = "”"
+		r.pos += len("“")
+	}
+
+	r.out.WriteByte('"')
+	for {
+		if r.eof() {
+			r.note(RepairUnterminatedString)
+			r.out.WriteByte('"')
+			return
+		}
+		if strings.HasPrefix(r.src[r.pos:], closing) {
+			r.pos += len(closing)
+			r.out.WriteByte('"')
+			return
+		}
+
+		c := r.peek()
+		switch {
+		case c == '\\':
+			if r.pos+1 >= len(r.src) {
+				r.pos++
+				continue
+			}
+			next := r.src[r.pos+1]
+			switch next {
+			case '"', '\\', '/', 'b', 'f', 'n', 'r', 't':
+				r.out.WriteByte('\\')
+				r.out.WriteByte(next)
+				r.pos += 2
+			case 'u':
+				if r.pos+6 <= len(r.src) && isHex(r.src[r.pos+2:r.pos+6]) {
+					r.out.WriteString(r.src[r.pos : r.pos+6])
+					r.pos += 6
+				} else {
+					r.note(RepairInvalidEscape)
+					r.out.WriteString(`\\`)
+					r.pos++
+				}
+			case '\'':
+				r.out.WriteByte('\'')
+				r.pos += 2
+			default:
+				r.note(RepairInvalidEscape)
+				r.out.WriteString(`\\`)
+				r.pos++
+			}
+		case c == '"':
+			r.out.WriteString(`\"`)
+			r.pos++
+		case c < 0x20:
+			r.note(RepairControlCharacter)
+			r.out.WriteString(escapeControl(c))
+			r.pos++
+		default:
+			r.out.WriteByte(c)
+			r.pos++
+		}
+	}
+}
+
+func (r *jsonRepairer) number() {
+	start := r.pos
+	for !r.eof() && strings.IndexByte("0123456789+-.eE", r.peek()) >= 0 {
+		r.pos++
+	}
+	literal := r.src[start:r.pos]
+	if !r.eof() && !isNumberEnd(r.peek()) {
+		// Digits run into other text, as in 2024-01-01T10 or 3px: keep it as a string.
+		r.pos = start
+		r.word()
+		return
+	}
+	if json.Valid([]byte(literal)) {
+		r.out.WriteString(literal)
+		return
+	}
+
+	cleaned := strings.TrimRight(literal, "+-.eE")
+	if f, err := strconv.ParseFloat(cleaned, 64); err == nil {
+		r.report.add(RepairInvalidNumber, r.base+start)
+		r.out.WriteString(strconv.FormatFloat(f, 'f', -1, 64))
+		return
+	}
+	// Dates and versions such as 2024-01-01 or 1.2.3 are not numbers; quote them
+	// rather than lose the value.
+	r.pos = start
+	r.word()
+}
+
+func isNumberEnd(c byte) bool {
+	return c == ',' || c == '}' || c == ']' || c == ':' || c == '/' || c == '#' || isJSONSpace(c)
+}
+
+var jsonLiterals = map[string]string{
+	"true":      "true",
+	"false":     "false",
+	"null":      "null",
+	"True":      "true",
+	"False":     "false",
+	"None":      "null",
+	"undefined": "null",
+	"NaN":       "null",
+	"Infinity":  "null",
+}
+
+// word handles bare tokens: JSON and Python literals, literals truncated at end of input,
+// and unquoted strings.
+func (r *jsonRepairer) word() {
+	start := r.pos
+	for !r.eof() {
+		c := r.peek()
+		if c == ',' || c == '}' || c == ']' || c == ':' || c == '\n' || c == '\r' {
+			break
+		}
+		r.pos++
+	}
+	token := strings.TrimRight(r.src[start:r.pos], " \t")
+	r.pos = start + len(token)
+	if token == "" {
+		r.pos++
+		r.report.add(RepairMissingValue, r.base+start)
+		r.out.WriteString("null")
+		return
+	}
+
+	if literal, ok := jsonLiterals[token]; ok {
+		if literal != token {
+			r.report.add(RepairNonStandardLiteral, r.base+start)
+		}
+		r.out.WriteString(literal)
+		return
+	}
+	if r.eof() {
+		for _, literal := range []string{"true", "false", "null"} {
+			if strings.HasPrefix(literal, token) {
+				r.report.add(RepairMissingValue, r.base+start)
+				r.out.WriteString(literal)
+				return
+			}
+		}
+	}
+
+	r.report.add(RepairUnquotedString, r.base+start)
+	r.writeQuoted(token)
+}
+
+func (r *jsonRepairer) writeQuoted(s string) {
+	quoted, _ := json.Marshal(s)
+	r.out.Write(quoted)
+}
+
+func isJSONSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func isHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
+
+func escapeControl(c byte) string {
+	switch c {
+	case '\n':
+		return `\n`
+	case '\r':
+		return `\r`
+	case '\t':
+		return `\t`
+	case '\b':
+		return `\b`
+	case '\f':
+		return `\f`
+	}
+	return fmt.Sprintf(`\u%04x`, c)
+}
@@ -0,0 +1,178 @@
+package utils
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRepairJSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+		kinds []RepairKind
+	}{
+		{name: "valid", input: `{"a": 1}`, want: `{"a": 1}`},
+		{name: "trailing comma object", input: `{"a": 1, "b": 2,}`, want: `{"a":1,"b":2}`, kinds: []RepairKind{RepairTrailingComma}},
+		{name: "trailing comma array", input: `[1, 2, 3,]`, want: `[1,2,3]`, kinds: []RepairKind{RepairTrailingComma}},
+		{name: "single quotes", input: `{'name': 'O"Brien'}`, want: `{"name":"O\"Brien"}`, kinds: []RepairKind{RepairSingleQuotes}},
+		{name: "unquoted keys", input: `{name: "x", count: 2}`, want: `{"name":"x","count":2}`, kinds: []RepairKind{RepairUnquotedKey}},
+		{name: "line comment", input: "{\n  \"a\": 1, // first\n  \"b\": 2\n}", want: `{"a":1,"b":2}`, kinds: []RepairKind{RepairComment}},
+		{name: "block comment", input: `{"a": /* note */ 1}`, want: `{"a":1}`, kinds: []RepairKind{RepairComment}},
+		{name: "python literals", input: `{"ok": True, "v": None, "f": False}`, want: `{"ok":true,"v":null,"f":false}`, kinds: []RepairKind{RepairNonStandardLiteral}},
+		{name: "truncated string", input: `{"text": "hello wor`, want: `{"text":"hello wor"}`, kinds: []RepairKind{RepairUnterminatedString, RepairUnclosedContainer}},
+		{name: "truncated array", input: `{"items": [1, 2,`, want: `{"items":[1,2]}`, kinds: []RepairKind{RepairUnclosedContainer}},
+		{name: "truncated after colon", input: `{"a": 1, "b":`, want: `{"a":1,"b":null}`, kinds: []RepairKind{RepairMissingValue, RepairUnclosedContainer}},
+		{name: "truncated literal", input: `{"done": tr`, want: `{"done":true}`, kinds: []RepairKind{RepairMissingValue, RepairUnclosedContainer}},
+		{name: "truncated key", input: `{"a": 1, "be`, want: `{"a":1,"be":null}`, kinds: []RepairKind{RepairUnterminatedString, RepairMissingColon}},
+		{name: "missing comma", input: `{"a": 1 "b": 2}`, want: `{"a":1,"b":2}`, kinds: []RepairKind{RepairMissingComma}},
+		{name: "code fence and prose", input: "Sure! Here it is:\n```json\n{\"a\": [1, 2,]}\n```\nLet me know.", want: `{"a":[1,2]}`, kinds: []RepairKind{RepairCodeFence, RepairTrailingComma}},
+		{name: "surrounding text", input: `Result: {"a": 1} done`, want: `{"a":1}`, kinds: []RepairKind{RepairSurroundingText}},
+		{name: "raw newline in string", input: "{\"a\": \"line1\nline2\"}", want: `{"a":"line1\nline2"}`, kinds: []RepairKind{RepairControlCharacter}},
+		{name: "invalid number", input: `{"a": .5, "b": +1, "c": 2.}`, want: `{"a":0.5,"b":1,"c":2}`, kinds: []RepairKind{RepairInvalidNumber}},
+		{name: "date and version", input: `{"date": 2024-01-01, "version": 1.2.3}`, want: `{"date":"2024-01-01","version":"1.2.3"}`, kinds: []RepairKind{RepairUnquotedString}},
+		{name: "hash value", input: "{\"color\": #ff0000, # note\n \"n\": 1}", want: `{"color":"#ff0000","n":1}`, kinds: []RepairKind{RepairUnquotedString, RepairComment}},
+		{name: "unquoted value", input: `{"status": ok}`, want: `{"status":"ok"}`, kinds: []RepairKind{RepairUnquotedString}},
+		{name: "nested truncation", input: `[{"a": {"b": [1, {"c": "d`, want: `[{"a":{"b":[1,{"c":"d"}]}}]`, kinds: []RepairKind{RepairUnterminatedString, RepairUnclosedContainer}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, report, err := RepairJSON(tt.input)
+			if err != nil {
+				t.Fatalf("RepairJSON(%q) returned error: %v", tt.input, err)
+			}
+			assertSameJSON(t, got, tt.want)
+			for _, kind := range tt.kinds {
+				if !report.Has(kind) {
+					t.Errorf("expected repair %q, got %s", kind, report)
+				}
+			}
+			if len(tt.kinds) == 0 && report.Repaired() {
+				t.Errorf("expected no repairs, got %s", report)
+			}
+		})
+	}
+}
+
+func TestRepairJSONNoJSON(t *testing.T) {
+	if _, _, err := RepairJSON("I could not produce an answer."); err != ErrNoJSON {
+		t.Fatalf("expected ErrNoJSON, got %v", err)
+	}
+}
+
+func TestExtractJSONBlocks(t *testing.T) {
+	raw := "First:\n```json\n{\"id\": 1}\n```\nSecond:\n```json\n{\"id\": 2,}\n```"
+	blocks := ExtractJSONBlocks(raw)
+	if len(blocks) != 2 {
+		t.Fatalf("expected 2 blocks, got %d", len(blocks))
+	}
+	assertSameJSON(t, blocks[0].JSON, `{"id":1}`)
+	assertSameJSON(t, blocks[1].JSON, `{"id":2}`)
+	if blocks[0].Report.Repaired() {
+		t.Errorf("first block should not need repairs, got %s", blocks[0].Report)
+	}
+	if !blocks[1].Report.Has(RepairTrailingComma) {
+		t.Errorf("second block should report trailing comma, got %s", blocks[1].Report)
+	}
+
+	unfenced := `Answer {"a": 1} and [1, 2] but not [note] or {placeholder}.`
+	blocks = ExtractJSONBlocks(unfenced)
+	if len(blocks) != 2 {
+		t.Fatalf("expected 2 unfenced blocks, got %d: %+v", len(blocks), blocks)
+	}
+	assertSameJSON(t, blocks[0].JSON, `{"a":1}`)
+	assertSameJSON(t, blocks[1].JSON, `[1,2]`)
+}
+
+func TestExtractJSONFromStringRepairs(t *testing.T) {
+	got, err := ExtractJSONFromString("```json\n{\"a\": 1,}\n```")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertSameJSON(t, got, `{"a":1}`)
+
+	var dst struct {
+		Items []string `json:"items"`
+	}
+	if err := SafeUnmarshalJSON(`{"items": ['a', 'b'`, &dst); err != nil {
+		t.Fatalf("SafeUnmarshalJSON failed: %v", err)
+	}
+	if !reflect.DeepEqual(dst.Items, []string{"a", "b"}) {
+		t.Errorf("unexpected items: %v", dst.Items)
+	}
+}
+
+func TestExtractJSONFromStringIgnoresProse(t *testing.T) {
+	for _, prose := range []string{
+		"Hello {name}, how are you?",
+		"Sorry, I cannot help with that [policy].",
+	} {
+		if got, err := ExtractJSONFromString(prose); err == nil {
+			t.Errorf("ExtractJSONFromString(%q) = %q, want an error", prose, got)
+		}
+		if got := ExtractValidJSON(prose); got != prose {
+			t.Errorf("ExtractValidJSON(%q) = %q, want the input unchanged", prose, got)
+		}
+	}
+}
+
+func FuzzRepairJSON(f *testing.F) {
+	seeds := []string{
+		`{"a": 1}`,
+		`[1, 2, 3,]`,
+		`{'a': 'b'}`,
+		`{a: 1, b: [true, None]}`,
+		"```json\n{\"a\": \"x\"\n```",
+		`{"text": "unterminated`,
+		`{"a": /* c */ 1, // d
+"b": 2}`,
+		`[{"a": [1, {"b": "c`,
+		`{"a" 1 "b" 2}`,
+		`{"é": "\q"}`,
+	}
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		repaired, report, err := RepairJSON(input)
+		if err != nil {
+			return
+		}
+		if !json.Valid([]byte(repaired)) {
+			t.Fatalf("RepairJSON(%q) produced invalid JSON %q (%s)", input, repaired, report)
+		}
+
+		trimmed := strings.TrimSpace(input)
+		if isJSONContainer(trimmed) && json.Valid([]byte(trimmed)) {
+			if repaired != trimmed || report.Repaired() {
+				t.Fatalf("valid input %q was modified to %q (%s)", trimmed, repaired, report)
+			}
+		}
+
+		again, _, err := RepairJSON(repaired)
+		if err != nil {
+			t.Fatalf("repaired output %q could not be re-parsed: %v", repaired, err)
+		}
+		if again != repaired {
+			t.Fatalf("repair is not idempotent: %q -> %q", repaired, again)
+		}
+	})
+}
+
+func assertSameJSON(t *testing.T, got, want string) {
+	t.Helper()
+	var gotValue, wantValue interface{}
+	if err := json.Unmarshal([]byte(got), &gotValue); err != nil {
+		t.Fatalf("output %q is not valid JSON: %v", got, err)
+	}
+	if err := json.Unmarshal([]byte(want), &wantValue); err != nil {
+		t.Fatalf("expected %q is not valid JSON: %v", want, err)
+	}
+	if !reflect.DeepEqual(gotValue, wantValue) {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}